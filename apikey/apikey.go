@@ -0,0 +1,240 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"file-transfer/middlewire"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+var ErrKeyNotFound = errors.New("API密钥不存在")
+
+// Key API密钥的元信息，不包含密钥本身，可以安全地返回给调用方
+type Key struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`               // 密钥开头部分，便于辨认
+	Owner      string     `json:"owner"`                // 密钥代表的用户，服务器归属按该用户校验
+	Role       string     `json:"role,omitempty"`       // 为空时使用 Owner 的角色
+	Operations []string   `json:"operations,omitempty"` // 允许的操作，为空表示角色的全部权限
+	Hosts      []string   `json:"hosts,omitempty"`      // 允许访问的主机ID或主机名，为空表示不限
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // 为空表示永不过期
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// record 持久化的API密钥，只保存密钥的 SHA-256 摘要
+type record struct {
+	Key
+	Hash string `json:"hash"`
+}
+
+// Store API密钥存储，保存在本地 JSON 文件中
+type Store struct {
+	mu   sync.RWMutex
+	path string
+	keys map[string]*record
+}
+
+var Default *Store // 全局API密钥存储
+
+// Open 加载API密钥文件，文件不存在时创建空存储
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, keys: make(map[string]*record)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取API密钥文件失败: %v", err)
+	}
+
+	var records []*record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("解析API密钥文件失败: %v", err)
+	}
+	for _, r := range records {
+		s.keys[r.ID] = r
+	}
+	return s, nil
+}
+
+// save 将密钥写入文件，先写临时文件再重命名；调用方需持有写锁
+func (s *Store) save() error {
+	records := make([]*record, 0, len(s.keys))
+	for _, r := range s.keys {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		logx.Errorf("保存API密钥失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// hash 计算密钥摘要；密钥本身是高熵随机数，不需要加盐慢哈希
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+// Create 生成新的API密钥，返回元信息和密钥明文；明文只在此时返回一次
+func (s *Store) Create(k Key, createdBy string) (Key, string, error) {
+	if k.Owner == "" {
+		return Key{}, "", errors.New("必须指定密钥所属用户")
+	}
+	if k.Role != "" && !middlewire.ValidRole(k.Role) {
+		return Key{}, "", fmt.Errorf("无效的角色: %s", k.Role)
+	}
+	for _, op := range k.Operations {
+		if !middlewire.ValidPermission(op) {
+			return Key{}, "", fmt.Errorf("无效的操作: %s", op)
+		}
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return Key{}, "", errors.New("过期时间必须晚于当前时间")
+	}
+
+	idBytes, err := randomBytes(8)
+	if err != nil {
+		return Key{}, "", err
+	}
+	secret, err := randomBytes(32)
+	if err != nil {
+		return Key{}, "", err
+	}
+	// 密钥形如 ftk_<ID>_<随机串>，ID 用于查找，校验时比较整个密钥的摘要
+	k.ID = hex.EncodeToString(idBytes)
+	plaintext := middlewire.APIKeyPrefix + k.ID + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	k.Prefix = plaintext[:len(middlewire.APIKeyPrefix)+len(k.ID)]
+	k.CreatedBy = createdBy
+	k.CreatedAt = time.Now()
+	k.LastUsedAt = nil
+	k.RevokedAt = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[k.ID] = &record{Key: k, Hash: hash(plaintext)}
+	if err := s.save(); err != nil {
+		delete(s.keys, k.ID)
+		return Key{}, "", err
+	}
+	return k, plaintext, nil
+}
+
+// List 返回所有API密钥（含已吊销和已过期的）
+func (s *Store) List() []Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]Key, 0, len(s.keys))
+	for _, r := range s.keys {
+		keys = append(keys, r.Key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// Revoke 吊销API密钥，吊销后立即失效；记录保留以便审计
+func (s *Store) Revoke(id string) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.keys[id]
+	if !exists {
+		return Key{}, ErrKeyNotFound
+	}
+	if r.RevokedAt != nil {
+		return r.Key, nil
+	}
+	now := time.Now()
+	r.RevokedAt = &now
+	if err := s.save(); err != nil {
+		r.RevokedAt = nil
+		return Key{}, err
+	}
+	return r.Key, nil
+}
+
+// Verify 校验API密钥并返回其代表的调用方，用于 middlewire.VerifyAPIKey
+func (s *Store) Verify(ctx context.Context, plaintext string) (middlewire.Identity, error) {
+	rest, ok := strings.CutPrefix(plaintext, middlewire.APIKeyPrefix)
+	if !ok {
+		return middlewire.Identity{}, middlewire.ErrInvalidAPIKey
+	}
+	id, _, _ := strings.Cut(rest, "_")
+
+	s.mu.Lock()
+	r, exists := s.keys[id]
+	if !exists || subtle.ConstantTimeCompare([]byte(r.Hash), []byte(hash(plaintext))) != 1 {
+		s.mu.Unlock()
+		return middlewire.Identity{}, middlewire.ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if r.RevokedAt != nil {
+		s.mu.Unlock()
+		return middlewire.Identity{}, fmt.Errorf("%w: 密钥 %s 已吊销", middlewire.ErrInvalidAPIKey, id)
+	}
+	if r.ExpiresAt != nil && now.After(*r.ExpiresAt) {
+		s.mu.Unlock()
+		return middlewire.Identity{}, fmt.Errorf("%w: 密钥 %s 已过期", middlewire.ErrInvalidAPIKey, id)
+	}
+	// 最近使用时间只记在内存中，随下一次写入一起保存，避免每次请求都写文件
+	r.LastUsedAt = &now
+	key := r.Key
+	s.mu.Unlock()
+
+	role := key.Role
+	if role == "" {
+		var err error
+		role, err = middlewire.ResolveRole(ctx, &middlewire.Claims{Username: key.Owner})
+		if err != nil {
+			return middlewire.Identity{}, err
+		}
+	}
+	return middlewire.Identity{
+		Username:   key.Owner,
+		Role:       role,
+		APIKeyID:   key.ID,
+		Operations: key.Operations,
+		Hosts:      key.Hosts,
+	}, nil
+}
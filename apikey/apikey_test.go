@@ -0,0 +1,173 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"file-transfer/config"
+	"file-transfer/middlewire"
+)
+
+// newTestStore 创建保存在临时目录中的密钥存储，并设置角色配置
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	old := middlewire.RBAC
+	middlewire.RBAC = config.RBACConfig{Admins: []string{"root"}, DefaultRole: middlewire.RoleReadOnly}
+	t.Cleanup(func() { middlewire.RBAC = old })
+
+	s, err := Open(filepath.Join(t.TempDir(), "apikeys.json"))
+	if err != nil {
+		t.Fatalf("打开密钥存储失败: %v", err)
+	}
+	return s
+}
+
+func TestVerify(t *testing.T) {
+	s := newTestStore(t)
+	create := func(k Key) (Key, string) {
+		t.Helper()
+		key, plaintext, err := s.Create(k, "root")
+		if err != nil {
+			t.Fatalf("创建密钥失败: %v", err)
+		}
+		return key, plaintext
+	}
+	future := time.Now().Add(time.Hour)
+
+	scoped, scopedKey := create(Key{Name: "ci", Owner: "alice", Role: middlewire.RoleOperator,
+		Operations: []string{middlewire.PermUpload}, Hosts: []string{"web-1"}, ExpiresAt: &future})
+	_, inheritKey := create(Key{Name: "inherit", Owner: "bob"})
+	_, adminKey := create(Key{Name: "admin", Owner: "root"})
+	revoked, revokedKey := create(Key{Name: "revoked", Owner: "alice"})
+	if _, err := s.Revoke(revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	expired, expiredKey := create(Key{Name: "expired", Owner: "alice", ExpiresAt: &future})
+	past := time.Now().Add(-time.Second)
+	s.keys[expired.ID].ExpiresAt = &past
+
+	// 用另一个密钥的ID拼接随机串，ID 存在但摘要不一致
+	forged := middlewire.APIKeyPrefix + scoped.ID + "_" + strings.Repeat("A", 43)
+
+	tests := []struct {
+		name       string
+		plaintext  string
+		wantUser   string
+		wantRole   string
+		wantOps    []string
+		wantHosts  []string
+		wantReason string // 校验失败时错误信息中应包含的内容
+	}{
+		{name: "限定操作和主机的密钥", plaintext: scopedKey, wantUser: "alice", wantRole: middlewire.RoleOperator, wantOps: []string{middlewire.PermUpload}, wantHosts: []string{"web-1"}},
+		{name: "未指定角色时使用所属用户的角色", plaintext: inheritKey, wantUser: "bob", wantRole: middlewire.RoleReadOnly},
+		{name: "所属用户为管理员", plaintext: adminKey, wantUser: "root", wantRole: middlewire.RoleAdmin},
+		{name: "已吊销", plaintext: revokedKey, wantReason: "已吊销"},
+		{name: "已过期", plaintext: expiredKey, wantReason: "已过期"},
+		{name: "摘要不一致", plaintext: forged},
+		{name: "密钥被截断", plaintext: scopedKey[:len(scopedKey)-1]},
+		{name: "ID 不存在", plaintext: middlewire.APIKeyPrefix + "0000000000000000_" + strings.Repeat("A", 43)},
+		{name: "缺少前缀", plaintext: strings.TrimPrefix(scopedKey, middlewire.APIKeyPrefix)},
+		{name: "空密钥", plaintext: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := s.Verify(context.Background(), tt.plaintext)
+			if tt.wantUser == "" {
+				if !errors.Is(err, middlewire.ErrInvalidAPIKey) {
+					t.Fatalf("err = %v, want ErrInvalidAPIKey", err)
+				}
+				if !strings.Contains(err.Error(), tt.wantReason) {
+					t.Errorf("err = %v, want 包含 %q", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify 失败: %v", err)
+			}
+			if id.Username != tt.wantUser || id.Role != tt.wantRole {
+				t.Errorf("Identity = %s/%s, want %s/%s", id.Username, id.Role, tt.wantUser, tt.wantRole)
+			}
+			if !slices.Equal(id.Operations, tt.wantOps) || !slices.Equal(id.Hosts, tt.wantHosts) {
+				t.Errorf("Operations = %v, Hosts = %v, want %v, %v", id.Operations, id.Hosts, tt.wantOps, tt.wantHosts)
+			}
+			if id.APIKeyID == "" {
+				t.Error("APIKeyID 为空")
+			}
+		})
+	}
+
+	// 限定操作的密钥只拥有角色权限与限定操作的交集
+	id, err := s.Verify(context.Background(), scopedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !id.Can(middlewire.PermUpload) || id.Can(middlewire.PermDownload) || id.Can(middlewire.PermAdmin) {
+		t.Errorf("限定为 upload 的密钥权限不正确")
+	}
+	if s.keys[scoped.ID].LastUsedAt == nil {
+		t.Error("校验成功后应记录最近使用时间")
+	}
+}
+
+func TestVerifyAfterReopen(t *testing.T) {
+	s := newTestStore(t)
+	key, plaintext, err := s.Create(Key{Name: "ci", Owner: "alice", Role: middlewire.RoleUploader}, "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), plaintext) {
+		t.Fatal("密钥文件中不应保存密钥明文")
+	}
+
+	reopened, err := Open(s.path)
+	if err != nil {
+		t.Fatalf("重新打开密钥存储失败: %v", err)
+	}
+	id, err := reopened.Verify(context.Background(), plaintext)
+	if err != nil || id.APIKeyID != key.ID || id.Role != middlewire.RoleUploader {
+		t.Errorf("Verify = %+v, %v", id, err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	s := newTestStore(t)
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name    string
+		key     Key
+		wantErr bool
+	}{
+		{"最简配置", Key{Owner: "alice"}, false},
+		{"缺少所属用户", Key{Name: "x"}, true},
+		{"无效的角色", Key{Owner: "alice", Role: "superuser"}, true},
+		{"无效的操作", Key{Owner: "alice", Operations: []string{"delete"}}, true},
+		{"过期时间早于当前时间", Key{Owner: "alice", ExpiresAt: &past}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, plaintext, err := s.Create(tt.key, "root")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("应当返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create 失败: %v", err)
+			}
+			if !strings.HasPrefix(plaintext, key.Prefix) || !strings.HasPrefix(key.Prefix, middlewire.APIKeyPrefix+key.ID) {
+				t.Errorf("Prefix = %q, 密钥 = %q", key.Prefix, plaintext)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"file-transfer/logs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type CreateRequest struct {
+	Name       string     `json:"name" binding:"required"`
+	Owner      string     `json:"owner"`      // 密钥代表的用户，默认为创建者
+	Role       string     `json:"role"`       // 可选，为空时使用所属用户的角色
+	Operations []string   `json:"operations"` // 可选，upload / download / transfer / view_logs / admin
+	Hosts      []string   `json:"hosts"`      // 可选，主机ID或主机名
+	ExpiresIn  string     `json:"expires_in"` // 可选，有效期，如 720h
+	ExpiresAt  *time.Time `json:"expires_at"` // 可选，过期时间，优先于 expires_in
+}
+
+// 创建API密钥，密钥明文只在响应中返回一次
+func CreateAPIKey(c *gin.Context) {
+	username := c.GetString("username")
+
+	var request CreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+
+	key := Key{
+		Name:       request.Name,
+		Owner:      request.Owner,
+		Role:       request.Role,
+		Operations: request.Operations,
+		Hosts:      request.Hosts,
+		ExpiresAt:  request.ExpiresAt,
+	}
+	if key.Owner == "" {
+		key.Owner = username
+	}
+	if key.ExpiresAt == nil && request.ExpiresIn != "" {
+		d, err := time.ParseDuration(request.ExpiresIn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("无效的有效期: %v", err)})
+			return
+		}
+		expiresAt := time.Now().Add(d)
+		key.ExpiresAt = &expiresAt
+	}
+
+	key, plaintext, err := Default.Create(key, username)
+	if err != nil {
+		logx.Errorf("创建API密钥失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("创建API密钥失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("创建API密钥", "username", username, "detail", "密钥ID："+key.ID+"，所属用户："+key.Owner)
+	c.JSON(http.StatusOK, gin.H{"message": "创建API密钥成功，密钥只显示一次，请妥善保存", "api_key": key, "key": plaintext})
+}
+
+// 查看API密钥列表（不含密钥本身）
+func ListAPIKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"api_keys": Default.List()})
+}
+
+// 吊销API密钥
+func RevokeAPIKey(c *gin.Context) {
+	username := c.GetString("username")
+	id := c.Param("id")
+
+	if _, err := Default.Revoke(id); err != nil {
+		logx.Errorf("吊销API密钥失败: %v", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrKeyNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"message": fmt.Sprintf("吊销API密钥失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("吊销API密钥", "username", username, "detail", "密钥ID："+id)
+	c.JSON(http.StatusOK, gin.H{"message": "吊销API密钥成功"})
+}
@@ -9,6 +9,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 
 	logs "github.com/chenzanhong/go-logs"
 	"github.com/zeromicro/go-zero/core/logx"
@@ -43,8 +44,9 @@ func main() {
 		FileData: data,
 	}
 
-	// 发送请求
-	resp, err := client.CommonUpload(context.Background(), req)
+	// 发送请求，Token 与 HTTP 接口相同，放在 metadata 的 authorization 中
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+os.Getenv("FILE_TRANSFER_TOKEN"))
+	resp, err := client.CommonUpload(ctx, req)
 	if err != nil {
 		logx.Errorf("could not upload file: %v", err)
 	}
@@ -0,0 +1,159 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+
+	"file-transfer/logs"
+	ft "file-transfer/proto/file-transfer"
+	g "file-transfer/transfer/global"
+	trans "file-transfer/transfer/trans-init"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// connectHost 解析并校验请求中的服务器，确保连接可用
+func connectHost(ctx context.Context, h *ft.RemoteHost) (trans.SSHTarget, error) {
+	target, err := resolveTarget(ctx, h.GetHostId(), h.GetServer(), h.GetUser(), h.GetAuth(), h.GetProxy())
+	if err != nil {
+		return trans.SSHTarget{}, err
+	}
+	if _, err := trans.EnsureConnection(g.Pool, target); err != nil {
+		return trans.SSHTarget{}, connError(err)
+	}
+	return target, nil
+}
+
+func toRemoteFile(info g.FileInfo) *ft.RemoteFile {
+	return &ft.RemoteFile{
+		Name:   info.Name,
+		Path:   info.Path,
+		Size:   info.Size,
+		Mode:   info.Mode,
+		Perm:   info.Perm,
+		IsDir:  info.IsDir,
+		IsLink: info.IsLink,
+		Uid:    info.UID,
+		Gid:    info.GID,
+		Mtime:  info.ModTime.Unix(),
+	}
+}
+
+// fileOpDone 记录文件管理操作的审计日志并返回结果
+func fileOpDone(ctx context.Context, operation, detail, path string, err error) (*ft.FileOpResponse, error) {
+	if err != nil {
+		logs.Sugar.Errorw(operation, "username", usernameFrom(ctx), "detail", "gRPC"+operation+"失败："+err.Error())
+		return nil, connError(err)
+	}
+	logs.Sugar.Infow(operation, "username", usernameFrom(ctx), "detail", "gRPC"+operation+"，"+detail)
+	return &ft.FileOpResponse{Message: operation + "成功", Path: path}, nil
+}
+
+func (s *Server) ListDir(ctx context.Context, req *ft.ListDirRequest) (*ft.ListDirResponse, error) {
+	target, err := connectHost(ctx, req.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := g.FTS.ListDir(ctx, target.Server, req.Path, g.ListOptions{
+		Offset:    int(req.Offset),
+		Limit:     int(req.Limit),
+		SortBy:    req.SortBy,
+		Desc:      req.Desc,
+		DirsFirst: req.DirsFirst,
+	})
+	if err != nil {
+		return nil, connError(err)
+	}
+
+	resp := &ft.ListDirResponse{Path: result.Path, Total: int32(min(result.Total, math.MaxInt32))}
+	for _, info := range result.Entries {
+		resp.Entries = append(resp.Entries, toRemoteFile(info))
+	}
+	return resp, nil
+}
+
+func (s *Server) Stat(ctx context.Context, req *ft.StatRequest) (*ft.StatResponse, error) {
+	target, err := connectHost(ctx, req.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := g.FTS.Stat(ctx, target.Server, req.Path)
+	if err != nil {
+		return nil, connError(err)
+	}
+	return &ft.StatResponse{File: toRemoteFile(info)}, nil
+}
+
+func (s *Server) Mkdir(ctx context.Context, req *ft.MkdirRequest) (*ft.FileOpResponse, error) {
+	target, err := connectHost(ctx, req.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := g.FTS.Mkdir(ctx, target.Server, req.Path, req.Parents)
+	return fileOpDone(ctx, "创建目录", "服务器："+target.Hostname()+"，路径："+dir, dir, err)
+}
+
+func (s *Server) Remove(ctx context.Context, req *ft.RemoveRequest) (*ft.FileOpResponse, error) {
+	target, err := connectHost(ctx, req.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := g.FTS.Remove(ctx, target.Server, req.Path, req.Recursive)
+	return fileOpDone(ctx, "删除文件", "服务器："+target.Hostname()+"，路径："+removed, removed, err)
+}
+
+func (s *Server) Rename(ctx context.Context, req *ft.RenameRequest) (*ft.FileOpResponse, error) {
+	if req.NewPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "缺少 new_path")
+	}
+	target, err := connectHost(ctx, req.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := g.FTS.Rename(ctx, target.Server, req.Path, req.NewPath, req.Overwrite)
+	return fileOpDone(ctx, "重命名文件", "服务器："+target.Hostname()+"，"+from+" -> "+to, to, err)
+}
+
+func (s *Server) Chmod(ctx context.Context, req *ft.ChmodRequest) (*ft.FileOpResponse, error) {
+	if req.Mode > 0o777 {
+		return nil, status.Errorf(codes.InvalidArgument, "无效的权限: %#o", req.Mode)
+	}
+	target, err := connectHost(ctx, req.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := g.FTS.Chmod(ctx, target.Server, req.Path, os.FileMode(req.Mode))
+	return fileOpDone(ctx, "修改文件权限", fmt.Sprintf("服务器：%s，路径：%s，权限：%#o", target.Hostname(), changed, req.Mode), changed, err)
+}
+
+func (s *Server) Symlink(ctx context.Context, req *ft.SymlinkRequest) (*ft.FileOpResponse, error) {
+	target, err := connectHost(ctx, req.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := g.FTS.Symlink(ctx, target.Server, req.Target, req.Path)
+	return fileOpDone(ctx, "创建符号链接", "服务器："+target.Hostname()+"，"+link+" -> "+req.Target, link, err)
+}
+
+func (s *Server) Readlink(ctx context.Context, req *ft.ReadlinkRequest) (*ft.ReadlinkResponse, error) {
+	target, err := connectHost(ctx, req.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err := g.FTS.Readlink(ctx, target.Server, req.Path)
+	if err != nil {
+		return nil, connError(err)
+	}
+	return &ft.ReadlinkResponse{Target: dest}, nil
+}
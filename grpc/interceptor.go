@@ -0,0 +1,151 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"file-transfer/config"
+	"file-transfer/middlewire"
+	ft "file-transfer/proto/file-transfer"
+	"file-transfer/tlsconf"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var TLS config.TLSServerConfig // gRPC 的 TLS 配置，用于将客户端证书映射为用户，由 main 在启动时设置
+
+// 各方法需要的权限，未列出的方法一律拒绝
+var methodPermissions = map[string]string{
+	ft.FileTransferService_CommonUpload_FullMethodName:              middlewire.PermUpload,
+	ft.FileTransferService_UploadStream_FullMethodName:              middlewire.PermUpload,
+	ft.FileTransferService_CommonDownload_FullMethodName:            middlewire.PermDownload,
+	ft.FileTransferService_TransferBetweenTwoServers_FullMethodName: middlewire.PermTransfer,
+	ft.FileTransferService_TransferWithProgress_FullMethodName:      middlewire.PermTransfer,
+	ft.FileTransferService_ListDir_FullMethodName:                   middlewire.PermDownload,
+	ft.FileTransferService_Stat_FullMethodName:                      middlewire.PermDownload,
+	ft.FileTransferService_Readlink_FullMethodName:                  middlewire.PermDownload,
+	ft.FileTransferService_Mkdir_FullMethodName:                     middlewire.PermUpload,
+	ft.FileTransferService_Remove_FullMethodName:                    middlewire.PermUpload,
+	ft.FileTransferService_Rename_FullMethodName:                    middlewire.PermUpload,
+	ft.FileTransferService_Chmod_FullMethodName:                     middlewire.PermUpload,
+	ft.FileTransferService_Symlink_FullMethodName:                   middlewire.PermUpload,
+	ft.FileTransferService_ListPoolConnections_FullMethodName:       middlewire.PermAdmin,
+	ft.FileTransferService_EvictPoolConnections_FullMethodName:      middlewire.PermAdmin,
+	ft.FileTransferService_WarmupPool_FullMethodName:                middlewire.PermAdmin,
+	ft.FileTransferService_ResetBreaker_FullMethodName:              middlewire.PermAdmin,
+}
+
+// AuthUnaryInterceptor 校验调用者的Token（metadata 中的 authorization）及方法权限，并将用户信息放入 context
+func AuthUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor 流式方法的认证与鉴权，与 AuthUnaryInterceptor 相同
+func AuthStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authedStream 替换流的 context，使处理函数能取到用户信息
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize 校验Token、确定角色并检查方法权限，返回带有用户信息的 context
+func authorize(ctx context.Context, method string) (context.Context, error) {
+	perm, exists := methodPermissions[method]
+	if !exists {
+		logx.Errorf("方法 %s 未配置权限，拒绝访问", method)
+		return nil, status.Error(codes.PermissionDenied, "拒绝访问")
+	}
+
+	id, err := authenticate(ctx)
+	if err != nil {
+		logx.Errorf("请求 %s 认证失败: %v", method, err)
+		return nil, err
+	}
+	if !id.Can(perm) {
+		logx.Errorf("用户 %s（角色：%s）无 %s 权限", id.Username, id.Role, perm)
+		return nil, status.Error(codes.PermissionDenied, "权限不足")
+	}
+
+	return middlewire.WithIdentity(ctx, id), nil
+}
+
+// authenticate 校验 metadata 中 authorization 携带的Token或 x-api-key 携带的API密钥；
+// 都没有时使用已校验的客户端证书确定用户
+func authenticate(ctx context.Context) (middlewire.Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	authorization := firstValue(md, "authorization")
+	apiKey := firstValue(md, "x-api-key")
+	if authorization == "" && apiKey == "" {
+		username, ok := certUser(ctx)
+		if !ok {
+			return middlewire.Identity{}, status.Error(codes.Unauthenticated, "缺少Token")
+		}
+		role, err := middlewire.ResolveRole(ctx, &middlewire.Claims{Username: username})
+		if err != nil {
+			logx.Errorf("查询用户 %s 的角色失败: %v", username, err)
+			return middlewire.Identity{}, status.Error(codes.Unavailable, "查询用户角色失败")
+		}
+		return middlewire.Identity{Username: username, Role: role}, nil
+	}
+
+	id, err := middlewire.Authenticate(ctx, authorization, apiKey)
+	if errors.Is(err, middlewire.ErrUnauthenticated) {
+		return middlewire.Identity{}, status.Error(codes.Unauthenticated, "无效的Token或API密钥")
+	}
+	if err != nil {
+		logx.Errorf("认证失败: %v", err)
+		return middlewire.Identity{}, status.Error(codes.Unavailable, "查询用户角色失败")
+	}
+	return id, nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// certUser 从双向TLS连接的客户端证书中取出映射的用户名，证书未经CA校验时不采用
+func certUser(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return tlsconf.UserFromCert(TLS, info.State.VerifiedChains[0][0])
+}
+
+// usernameFrom 返回拦截器放入 context 的用户名
+func usernameFrom(ctx context.Context) string {
+	id, _ := middlewire.IdentityFrom(ctx)
+	return id.Username
+}
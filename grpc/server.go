@@ -1,55 +1,448 @@
 package grpcserver
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"time"
 
+	"file-transfer/inventory"
+	"file-transfer/logs"
 	ft "file-transfer/proto/file-transfer"
 	"file-transfer/transfer"
+	g "file-transfer/transfer/global"
+	trans "file-transfer/transfer/trans-init"
+	"file-transfer/usersvc"
 
 	"github.com/zeromicro/go-zero/core/logx"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
 	ft.UnimplementedFileTransferServiceServer
 }
 
+// resolveTarget 解析请求中的服务器，并校验服务器是否属于调用方（所在公司）；调用方由拦截器放入 context
+func resolveTarget(ctx context.Context, hostID, server, user, auth, proxy string) (trans.SSHTarget, error) {
+	target, err := transfer.ResolveTarget(hostID, server, user, auth, proxy)
+	if err != nil {
+		if errors.Is(err, inventory.ErrHostNotFound) {
+			return trans.SSHTarget{}, status.Error(codes.NotFound, err.Error())
+		}
+		return trans.SSHTarget{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	flag, err := transfer.CheckServerBelongs(ctx, usernameFrom(ctx), target.Hostname())
+	if err != nil {
+		logx.Errorf("查询服务器与用户（所在公司）的关系失败: %v", err)
+		return trans.SSHTarget{}, status.Error(codes.Unavailable, "查询服务器与用户（所在公司）的关系失败")
+	}
+	if !flag {
+		return trans.SSHTarget{}, status.Error(codes.PermissionDenied, "该服务器不属于用户（所在公司）")
+	}
+	return target, nil
+}
+
 func (s *Server) CommonUpload(ctx context.Context, req *ft.CommonUploadRequest) (*ft.CommonUploadResponse, error) {
-	err := transfer.UploadFileToServer(req.Server, req.Path, req.User, req.Auth, req.FileData)
+	target, err := resolveTarget(ctx, req.HostId, req.Server, req.User, req.Auth, req.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	err = transfer.UploadFileToServer(ctx, target, req.Path, req.FileData)
 	if err != nil {
 		logx.Errorf("文件上传失败: %v", err)
-		return &ft.CommonUploadResponse{Message: "上传失败"}, err
+		logs.Sugar.Errorw("文件上传", "username", usernameFrom(ctx), "detail", "gRPC文件上传失败："+err.Error())
+		return &ft.CommonUploadResponse{Message: "上传失败"}, connError(err)
 	}
+	logs.Sugar.Infow("文件上传", "username", usernameFrom(ctx), "detail", "gRPC文件上传成功，路径："+req.Path)
 	return &ft.CommonUploadResponse{Message: "上传成功"}, nil
 }
 
+// UploadStream 流式上传：第一条消息携带元数据，之后的文件内容边收边写入远程文件，不在内存中缓存整个文件
+func (s *Server) UploadStream(stream ft.FileTransferService_UploadStreamServer) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	meta := first.GetMetadata()
+	if meta == nil {
+		return status.Error(codes.InvalidArgument, "第一条消息必须携带元数据")
+	}
+	if meta.Path == "" {
+		return status.Error(codes.InvalidArgument, "缺少上传路径")
+	}
+	var expected []byte
+	if meta.Sha256 != "" {
+		if expected, err = hex.DecodeString(meta.Sha256); err != nil || len(expected) != sha256.Size {
+			return status.Error(codes.InvalidArgument, "无效的 sha256")
+		}
+	}
+	mode := os.FileMode(meta.Mode & 0o7777)
+	if mode == 0 {
+		mode = 0644
+	}
+	size := meta.Size
+	if size <= 0 {
+		size = -1
+	}
+
+	target, err := resolveTarget(ctx, meta.HostId, meta.Server, meta.User, meta.Auth, meta.Proxy)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	var r io.Reader = &chunkReader{stream: stream}
+	if size >= 0 {
+		r = io.LimitReader(r, size+1) // 多读一个字节即可判断超出声明的大小，不必收完剩余内容
+	}
+	r = io.TeeReader(r, hash)
+	var written int64
+	verify := func(n int64) error {
+		written = n
+		if size >= 0 && n != size {
+			return status.Errorf(codes.InvalidArgument, "收到的内容与声明的大小 %d 字节不一致", size)
+		}
+		if expected != nil && !bytes.Equal(hash.Sum(nil), expected) {
+			return status.Error(codes.DataLoss, "文件内容的 sha256 不一致")
+		}
+		return nil
+	}
+
+	err = transfer.UploadStreamToServer(ctx, target, meta.Path, r, size, mode, verify)
+	if err != nil {
+		logx.Errorf("文件上传失败: %v", err)
+		logs.Sugar.Errorw("文件上传", "username", usernameFrom(ctx), "detail", "gRPC流式上传失败："+err.Error())
+		return connError(err)
+	}
+	logs.Sugar.Infow("文件上传", "username", usernameFrom(ctx), "detail", "gRPC流式上传成功，路径："+meta.Path)
+	return stream.SendAndClose(&ft.UploadStreamResponse{
+		Message: "上传成功",
+		Size:    written,
+		Sha256:  hex.EncodeToString(hash.Sum(nil)),
+	})
+}
+
+// chunkReader 将 UploadStream 中元数据之后的消息按顺序拼接为 io.Reader
+type chunkReader struct {
+	stream ft.FileTransferService_UploadStreamServer
+	buf    []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		req, err := r.stream.Recv()
+		if err != nil {
+			return 0, err // 客户端结束发送时为 io.EOF
+		}
+		if req.GetMetadata() != nil {
+			return 0, status.Error(codes.InvalidArgument, "元数据只能在第一条消息中发送")
+		}
+		r.buf = req.GetChunk()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// CommonDownload 流式下载：第一条消息为文件信息，之后边读远程文件边发送，不在内存中缓存整个文件；
+// 客户端取消或断开时立即停止读取
 func (s *Server) CommonDownload(req *ft.CommonDownloadRequest, stream ft.FileTransferService_CommonDownloadServer) error {
-	data, err := transfer.DownloadFileFromServer(req.Server, req.Path, req.User, req.Auth)
+	ctx := stream.Context()
+	if req.Offset < 0 || req.Length < 0 {
+		return status.Error(codes.InvalidArgument, "offset 和 length 不能为负数")
+	}
+	target, err := resolveTarget(ctx, req.HostId, req.Server, req.User, req.Auth, req.Proxy)
 	if err != nil {
 		return err
 	}
 
-	const chunkSize = 1024 * 32 // 32KB per chunk
-	for i := 0; i < len(data); i += chunkSize {
-		end := i + chunkSize
-		if end > len(data) {
-			end = len(data)
+	// 配额按实际读取的范围计算
+	length := func(size int64) int64 {
+		n := max(size-req.Offset, 0)
+		if req.Length > 0 {
+			n = min(n, req.Length)
 		}
+		return n
+	}
+	file, release, err := transfer.OpenFileOnServer(ctx, target, req.Path, length)
+	if err != nil {
+		logs.Sugar.Errorw("文件下载", "username", usernameFrom(ctx), "detail", "gRPC文件下载失败："+err.Error())
+		return connError(err)
+	}
+	var sent int64
+	defer func() { release(sent) }()
 
-		if err := stream.Send(&ft.FileChunk{Content: data[i:end]}); err != nil {
-			return err
+	stat, err := file.Stat()
+	if err != nil {
+		return status.Errorf(codes.Internal, "获取文件信息失败: %v", err)
+	}
+	if stat.IsDir() {
+		return status.Error(codes.FailedPrecondition, "路径是一个目录")
+	}
+	if req.Offset > stat.Size() {
+		return status.Errorf(codes.OutOfRange, "offset %d 超出文件大小 %d", req.Offset, stat.Size())
+	}
+	remaining := stat.Size() - req.Offset
+	if req.Length > 0 && req.Length < remaining {
+		remaining = req.Length
+	}
+
+	if err := stream.Send(&ft.FileChunk{Header: &ft.FileHeader{
+		Size:   stat.Size(),
+		Mode:   uint32(stat.Mode().Perm()),
+		Mtime:  stat.ModTime().Unix(),
+		Offset: req.Offset,
+		Length: remaining,
+	}}); err != nil {
+		return err
+	}
+
+	if _, err := file.Seek(req.Offset, io.SeekStart); err != nil {
+		return status.Errorf(codes.Internal, "定位文件失败: %v", err)
+	}
+	// 不截断时直接复制 *sftp.File，可以利用其并发读取
+	var src io.Reader = file
+	if remaining < stat.Size()-req.Offset {
+		src = io.LimitReader(file, remaining)
+	}
+	sent, err = io.Copy(&chunkWriter{ctx: ctx, stream: stream}, src)
+	if err != nil {
+		if ctx.Err() != nil {
+			logx.Errorf("客户端已取消下载: %v", ctx.Err())
+			return status.FromContextError(ctx.Err()).Err()
 		}
+		logs.Sugar.Errorw("文件下载", "username", usernameFrom(ctx), "detail", "gRPC文件下载失败："+err.Error())
+		return err
 	}
+	logs.Sugar.Infow("文件下载", "username", usernameFrom(ctx), "detail", "gRPC文件下载，路径："+req.Path)
 	return nil
 }
 
+// chunkWriter 将写入的内容按块发送到下载流，context 结束后不再发送
+type chunkWriter struct {
+	ctx    context.Context
+	stream ft.FileTransferService_CommonDownloadServer
+}
+
+const chunkSize = 1024 * 32 // 32KB per chunk
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if err := w.ctx.Err(); err != nil {
+			return written, err
+		}
+		n := min(len(p), chunkSize)
+		if err := w.stream.Send(&ft.FileChunk{Content: p[:n]}); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
 func (s *Server) TransferBetweenTwoServers(ctx context.Context, req *ft.TransferBetweenRequest) (*ft.TransferResponse, error) {
-	err := transfer.TransferBetweenTwoServers(
-		req.SourceServer, req.TargetServer, req.SourcePath, req.TargetPath,
-		req.SourceUser, req.TargetUser, req.SourceAuth, req.TargetAuth,
-	)
+	source, err := resolveTarget(ctx, req.SourceHostId, req.SourceServer, req.SourceUser, req.SourceAuth, req.SourceProxy)
+	if err != nil {
+		return nil, err
+	}
+	target, err := resolveTarget(ctx, req.TargetHostId, req.TargetServer, req.TargetUser, req.TargetAuth, req.TargetProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	err = transfer.TransferBetweenTwoServers(ctx, source, req.SourcePath, target, req.TargetPath)
 	if err != nil {
 		logx.Errorf("文件传输失败: %v", err)
-		return &ft.TransferResponse{Message: "传输失败"}, err
+		logs.Sugar.Errorw("两服务器间单文件传输", "username", usernameFrom(ctx), "detail", "gRPC文件传输失败："+err.Error())
+		return &ft.TransferResponse{Message: "传输失败"}, connError(err)
 	}
+	logs.Sugar.Infow("两服务器间单文件传输", "username", usernameFrom(ctx), "detail", "gRPC文件传输成功，目标路径："+req.TargetPath)
 	return &ft.TransferResponse{Message: "传输完成"}, nil
 }
+
+// 两次进度事件的最小间隔
+const progressInterval = time.Second
+
+// TransferWithProgress 两个服务器间传输文件，依次返回任务创建、进度及完成事件；
+// 失败时返回带有错误码的失败事件，随后以同样的状态结束调用。客户端断开时中止传输
+func (s *Server) TransferWithProgress(req *ft.TransferBetweenRequest, stream ft.FileTransferService_TransferWithProgressServer) error {
+	ctx := stream.Context()
+	var (
+		taskID      string
+		written     int64
+		total       int64
+		start       time.Time
+		lastAt      time.Time
+		lastWritten int64
+	)
+	send := func(event *ft.TransferEvent) error {
+		event.TaskId = taskID
+		event.Bytes = written
+		event.Total = total
+		event.Timestamp = time.Now().UnixMilli()
+		return stream.Send(event)
+	}
+	fail := func(err error) error {
+		st := status.Convert(connError(err))
+		if taskID != "" {
+			if withInfo, e := st.WithDetails(&errdetails.ErrorInfo{
+				Reason:   st.Code().String(),
+				Domain:   "file-transfer",
+				Metadata: map[string]string{"task_id": taskID},
+			}); e == nil {
+				st = withInfo
+			}
+		}
+		logs.Sugar.Errorw("两服务器间单文件传输", "username", usernameFrom(ctx), "detail", "gRPC文件传输失败："+st.Message())
+		if ctx.Err() == nil {
+			send(&ft.TransferEvent{Type: ft.TransferEvent_FAILED, Error: st.Proto()})
+		}
+		return st.Err()
+	}
+
+	source, err := resolveTarget(ctx, req.SourceHostId, req.SourceServer, req.SourceUser, req.SourceAuth, req.SourceProxy)
+	if err != nil {
+		return fail(err)
+	}
+	target, err := resolveTarget(ctx, req.TargetHostId, req.TargetServer, req.TargetUser, req.TargetAuth, req.TargetProxy)
+	if err != nil {
+		return fail(err)
+	}
+
+	progress := func(id string, n, size int64) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		now := time.Now()
+		written = n
+		if taskID == "" { // 第一次回调表示任务已创建
+			taskID, total = id, size
+			start, lastAt = now, now
+			return send(&ft.TransferEvent{Type: ft.TransferEvent_CREATED})
+		}
+		if now.Sub(lastAt) < progressInterval {
+			return nil
+		}
+		rate := float64(n-lastWritten) / now.Sub(lastAt).Seconds()
+		lastAt, lastWritten = now, n
+		return send(&ft.TransferEvent{Type: ft.TransferEvent_PROGRESS, Rate: rate})
+	}
+	id, err := transfer.TransferBetweenTwoServersWithProgress(ctx, source, req.SourcePath, target, req.TargetPath, progress)
+	if id != "" {
+		taskID = id
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	var rate float64
+	if elapsed := time.Since(start).Seconds(); elapsed > 0 {
+		rate = float64(written) / elapsed
+	}
+	logs.Sugar.Infow("两服务器间单文件传输", "username", usernameFrom(ctx), "detail", "gRPC文件传输成功，任务ID："+taskID+"，目标路径："+req.TargetPath)
+	return send(&ft.TransferEvent{Type: ft.TransferEvent_COMPLETED, Rate: rate})
+}
+
+func (s *Server) ListPoolConnections(ctx context.Context, req *ft.ListPoolConnectionsRequest) (*ft.ListPoolConnectionsResponse, error) {
+	infos := g.Pool.Snapshot()
+
+	conns := make([]*ft.PoolConnection, 0, len(infos))
+	for _, info := range infos {
+		conns = append(conns, &ft.PoolConnection{
+			Server:      info.Server,
+			User:        info.User,
+			CreatedAt:   info.CreatedAt.Unix(),
+			LastUsedAt:  info.LastUsedAt.Unix(),
+			LastAliveAt: info.LastAliveAt.Unix(),
+			AgeSeconds:  info.AgeSeconds,
+			InUse:       int32(info.InUse),
+			SftpActive:  info.SftpActive,
+		})
+	}
+
+	breakers := make([]*ft.BreakerState, 0)
+	for _, b := range g.Pool.Breakers() {
+		state := &ft.BreakerState{
+			Server:      b.Server,
+			State:       b.State,
+			Failures:    int32(b.Failures),
+			LastError:   b.LastError,
+			LastFailure: b.LastFailure.Unix(),
+		}
+		if !b.OpenUntil.IsZero() {
+			state.OpenUntil = b.OpenUntil.Unix()
+		}
+		breakers = append(breakers, state)
+	}
+
+	return &ft.ListPoolConnectionsResponse{Connections: conns, Capacity: int32(g.Pool.Capacity), Breakers: breakers}, nil
+}
+
+func (s *Server) EvictPoolConnections(ctx context.Context, req *ft.EvictPoolConnectionsRequest) (*ft.EvictPoolConnectionsResponse, error) {
+	evicted, err := transfer.EvictConnections(req.Server, req.All)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &ft.EvictPoolConnectionsResponse{Message: "驱逐完成", Evicted: int32(evicted)}, nil
+}
+
+func (s *Server) WarmupPool(ctx context.Context, req *ft.WarmupPoolRequest) (*ft.WarmupPoolResponse, error) {
+	hosts := make([]trans.WarmupHost, 0, len(req.Hosts))
+	for _, h := range req.Hosts {
+		hosts = append(hosts, trans.WarmupHost{HostID: h.HostId, Server: h.Server, User: h.User, Auth: h.Auth, Proxy: h.Proxy})
+	}
+
+	results := make([]*ft.WarmupResult, 0, len(hosts))
+	for _, r := range transfer.WarmupHosts(hosts) {
+		results = append(results, &ft.WarmupResult{Server: r.Server, Success: r.Success, Message: r.Message})
+	}
+	return &ft.WarmupPoolResponse{Results: results}, nil
+}
+
+func (s *Server) ResetBreaker(ctx context.Context, req *ft.ResetBreakerRequest) (*ft.ResetBreakerResponse, error) {
+	if req.Server == "" {
+		return nil, status.Error(codes.InvalidArgument, "请指定服务器")
+	}
+	cleared := g.Pool.ResetBreaker(req.Server)
+	return &ft.ResetBreakerResponse{Message: "熔断器已重置", Cleared: cleared}, nil
+}
+
+// connError 将传输错误转换为 gRPC 状态：主机熔断中返回 Unavailable，被访问策略拒绝返回 PermissionDenied，
+// 超出大小上限或配额返回 ResourceExhausted，远程文件不存在返回 NotFound，已是 gRPC 状态的错误原样返回
+func connError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, g.ErrHostUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, g.ErrAccessDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, g.ErrFileTooLarge), errors.Is(err, g.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, usersvc.ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, g.ErrInvalidArgument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, os.ErrNotExist):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, os.ErrExist):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, os.ErrPermission):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,231 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"file-transfer/logs"
+	"file-transfer/middlewire"
+	"file-transfer/usersvc"
+	"file-transfer/vault"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type HostRequest struct {
+	Name          string   `json:"name"`
+	Address       string   `json:"address" binding:"required"`
+	Port          int      `json:"port"`
+	User          string   `json:"user" binding:"required"`
+	CredentialRef string   `json:"credential_ref" binding:"required"`
+	Tags          []string `json:"tags"`
+	Proxy         string   `json:"proxy"`
+}
+
+type CredentialRequest struct {
+	Name       string `json:"name"`
+	Type       string `json:"type" binding:"required"`   // password / private_key
+	Secret     string `json:"secret" binding:"required"` // 密码或 PEM 格式私钥，只写不读
+	Passphrase string `json:"passphrase"`                // 私钥口令
+}
+
+type RotateRequest struct {
+	Reencrypt bool `json:"reencrypt"` // 为 true 时同时更换数据密钥，否则只用当前主密钥重新加密数据密钥
+}
+
+func (r HostRequest) toHost() Host {
+	return Host{
+		Name:          r.Name,
+		Address:       r.Address,
+		Port:          r.Port,
+		User:          r.User,
+		CredentialRef: r.CredentialRef,
+		Tags:          r.Tags,
+		Proxy:         r.Proxy,
+	}
+}
+
+// 根据错误类型返回HTTP状态码
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrHostNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrCredentialInUse):
+		return http.StatusConflict
+	case errors.Is(err, vault.ErrNoMasterKey):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// 查看主机清单，支持按标签筛选。拥有管理权限的调用方可以看到全部主机，
+// 其他用户只能看到属于自己（所在公司）的主机，且不返回登录账号、凭据引用和代理；
+// 两者都只能看到API密钥限定范围内的主机
+func ListHosts(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, _ := middlewire.IdentityFrom(ctx)
+	admin := id.Can(middlewire.PermAdmin)
+
+	hosts := Default.ListHosts(c.Query("tag"))
+	visible := make([]Host, 0, len(hosts))
+	for _, h := range hosts {
+		if len(id.Hosts) > 0 && !h.InScope(id.Hosts) {
+			continue
+		}
+		if !admin {
+			if usersvc.Default == nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"message": usersvc.ErrNotConfigured.Error()})
+				return
+			}
+			belongs, err := usersvc.Default.ServerBelongs(ctx, id.Username, h.Hostname())
+			if err != nil {
+				logx.Errorf("查询服务器与用户（所在公司）的关系失败: %v", err)
+				c.JSON(http.StatusServiceUnavailable, gin.H{"message": fmt.Sprintf("查询服务器与用户（所在公司）的关系失败: %v", err)})
+				return
+			}
+			if !belongs {
+				continue
+			}
+			h.User, h.CredentialRef, h.Proxy = "", "", ""
+		}
+		visible = append(visible, h)
+	}
+	c.JSON(http.StatusOK, gin.H{"hosts": visible})
+}
+
+// 添加主机
+func CreateHost(c *gin.Context) {
+	username := c.GetString("username")
+
+	var request HostRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+
+	host, err := Default.CreateHost(request.toHost(), username)
+	if err != nil {
+		logx.Errorf("添加主机失败: %v", err)
+		c.JSON(errorStatus(err), gin.H{"message": fmt.Sprintf("添加主机失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("添加主机", "username", username, "detail", "主机ID："+host.ID+"，地址："+host.Address)
+	c.JSON(http.StatusOK, gin.H{"message": "添加主机成功", "host": host})
+}
+
+// 修改主机
+func UpdateHost(c *gin.Context) {
+	username := c.GetString("username")
+	id := c.Param("id")
+
+	var request HostRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+
+	host, err := Default.UpdateHost(id, request.toHost())
+	if err != nil {
+		logx.Errorf("修改主机失败: %v", err)
+		c.JSON(errorStatus(err), gin.H{"message": fmt.Sprintf("修改主机失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("修改主机", "username", username, "detail", "主机ID："+id)
+	c.JSON(http.StatusOK, gin.H{"message": "修改主机成功", "host": host})
+}
+
+// 删除主机
+func DeleteHost(c *gin.Context) {
+	username := c.GetString("username")
+	id := c.Param("id")
+
+	if err := Default.DeleteHost(id); err != nil {
+		logx.Errorf("删除主机失败: %v", err)
+		c.JSON(errorStatus(err), gin.H{"message": fmt.Sprintf("删除主机失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("删除主机", "username", username, "detail", "主机ID："+id)
+	c.JSON(http.StatusOK, gin.H{"message": "删除主机成功"})
+}
+
+// 查看凭据列表（不含密码或私钥）
+func ListCredentials(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"credentials": Default.ListCredentials()})
+}
+
+// 添加凭据
+func CreateCredential(c *gin.Context) {
+	username := c.GetString("username")
+
+	var request CredentialRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+
+	cred, err := Default.CreateCredential(request.Name, request.Type, request.Secret, request.Passphrase, username)
+	if err != nil {
+		logx.Errorf("添加凭据失败: %v", err)
+		c.JSON(errorStatus(err), gin.H{"message": fmt.Sprintf("添加凭据失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("添加凭据", "username", username, "detail", "凭据ID："+cred.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "添加凭据成功", "credential": cred})
+}
+
+// 删除凭据
+func DeleteCredential(c *gin.Context) {
+	username := c.GetString("username")
+	id := c.Param("id")
+
+	if err := Default.DeleteCredential(id); err != nil {
+		logx.Errorf("删除凭据失败: %v", err)
+		status := errorStatus(err)
+		if errors.Is(err, ErrCredentialNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"message": fmt.Sprintf("删除凭据失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("删除凭据", "username", username, "detail", "凭据ID："+id)
+	c.JSON(http.StatusOK, gin.H{"message": "删除凭据成功"})
+}
+
+// 将所有凭据轮换到当前主密钥
+func RotateCredentials(c *gin.Context) {
+	username := c.GetString("username")
+
+	var request RotateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			logx.Errorf("解析请求失败: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+			return
+		}
+	}
+
+	count, err := Default.RotateCredentials(request.Reencrypt)
+	if err != nil {
+		logx.Errorf("轮换凭据失败: %v", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, vault.ErrNoMasterKey) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"message": fmt.Sprintf("轮换凭据失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("轮换凭据", "username", username, "detail", fmt.Sprintf("轮换凭据数量：%d，重新加密内容：%v", count, request.Reencrypt))
+	c.JSON(http.StatusOK, gin.H{"message": "轮换凭据成功", "rotated": count})
+}
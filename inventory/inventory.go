@@ -0,0 +1,507 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	trans "file-transfer/transfer/trans-init"
+	"file-transfer/vault"
+
+	"github.com/google/uuid"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 凭据类型
+const (
+	CredentialPassword   = "password"
+	CredentialPrivateKey = "private_key"
+)
+
+var (
+	ErrHostNotFound       = errors.New("主机不存在")
+	ErrCredentialNotFound = errors.New("凭据不存在")
+	ErrCredentialInUse    = errors.New("凭据仍被主机引用")
+)
+
+// Host 主机清单中的一台服务器
+type Host struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`           // 主机名，用于校验服务器归属，为空时使用地址
+	Address       string    `json:"address"`        // IP 或域名
+	Port          int       `json:"port"`           // SSH端口，默认 22
+	User          string    `json:"user"`           // SSH账号
+	CredentialRef string    `json:"credential_ref"` // 引用的凭据ID
+	Tags          []string  `json:"tags"`
+	Proxy         string    `json:"proxy,omitempty"` // 可选，连接该主机使用的代理
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Credential 凭据的元信息，不包含密码或私钥内容，可以安全地返回给调用方
+type Credential struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`   // password / private_key
+	KeyID     string    `json:"key_id"` // 加密该凭据所用的主密钥ID
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// credentialRecord 持久化的凭据，密码或私钥以信封加密的形式保存，只在服务内部解密
+type credentialRecord struct {
+	Credential
+	Sealed *vault.Envelope `json:"sealed,omitempty"`
+
+	// 早期版本以明文保存的字段，加载时自动加密并清除
+	Secret     string `json:"secret,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// secretPayload 加密前的凭据内容
+type secretPayload struct {
+	Secret     string `json:"secret"`               // 密码或 PEM 格式私钥
+	Passphrase string `json:"passphrase,omitempty"` // 私钥口令
+}
+
+// snapshot 清单文件的内容
+type snapshot struct {
+	Hosts       []*Host             `json:"hosts"`
+	Credentials []*credentialRecord `json:"credentials"`
+}
+
+// Store 主机清单，保存在本地 JSON 文件中
+type Store struct {
+	mu          sync.RWMutex
+	path        string
+	keyring     *vault.Keyring // 为 nil 时无法保存或使用凭据
+	hosts       map[string]*Host
+	credentials map[string]*credentialRecord
+}
+
+var Default *Store // 全局主机清单
+
+// Open 加载主机清单文件，文件不存在时创建空清单；清单中的明文凭据会被加密后写回
+func Open(path string, keyring *vault.Keyring) (*Store, error) {
+	s := &Store{
+		path:        path,
+		keyring:     keyring,
+		hosts:       make(map[string]*Host),
+		credentials: make(map[string]*credentialRecord),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取主机清单失败: %v", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("解析主机清单失败: %v", err)
+	}
+	for _, h := range snap.Hosts {
+		s.hosts[h.ID] = h
+	}
+	migrated := 0
+	for _, c := range snap.Credentials {
+		if c.Secret != "" {
+			if keyring == nil {
+				return nil, fmt.Errorf("主机清单中存在未加密的凭据，请先配置主密钥: %w", vault.ErrNoMasterKey)
+			}
+			if err := s.seal(c, secretPayload{Secret: c.Secret, Passphrase: c.Passphrase}); err != nil {
+				return nil, fmt.Errorf("加密凭据 %s 失败: %v", c.ID, err)
+			}
+			migrated++
+		}
+		s.credentials[c.ID] = c
+	}
+	if migrated > 0 {
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+		logx.Infof("已加密 %d 个明文凭据", migrated)
+	}
+	return s, nil
+}
+
+// seal 加密凭据内容并清除明文字段，凭据ID作为附加认证数据，防止密文被挪用到其他凭据
+func (s *Store) seal(rec *credentialRecord, payload secretPayload) error {
+	plaintext, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	env, err := s.keyring.Seal(plaintext, []byte(rec.ID))
+	if err != nil {
+		return err
+	}
+	rec.Sealed = env
+	rec.KeyID = env.KeyID
+	rec.Secret = ""
+	rec.Passphrase = ""
+	return nil
+}
+
+// unseal 解密凭据内容
+func (s *Store) unseal(rec *credentialRecord) (secretPayload, error) {
+	var payload secretPayload
+	if s.keyring == nil {
+		return payload, vault.ErrNoMasterKey
+	}
+	if rec.Sealed == nil {
+		return payload, errors.New("凭据内容缺失")
+	}
+	plaintext, err := s.keyring.Open(rec.Sealed, []byte(rec.ID))
+	if err != nil {
+		return payload, err
+	}
+	err = json.Unmarshal(plaintext, &payload)
+	return payload, err
+}
+
+// save 将清单写入文件，先写临时文件再重命名，避免写入中断导致文件损坏；调用方需持有写锁
+func (s *Store) save() error {
+	snap := snapshot{
+		Hosts:       make([]*Host, 0, len(s.hosts)),
+		Credentials: make([]*credentialRecord, 0, len(s.credentials)),
+	}
+	for _, h := range s.hosts {
+		snap.Hosts = append(snap.Hosts, h)
+	}
+	for _, c := range s.credentials {
+		snap.Credentials = append(snap.Credentials, c)
+	}
+	sort.Slice(snap.Hosts, func(i, j int) bool { return snap.Hosts[i].ID < snap.Hosts[j].ID })
+	sort.Slice(snap.Credentials, func(i, j int) bool { return snap.Credentials[i].ID < snap.Credentials[j].ID })
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		logx.Errorf("保存主机清单失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// validateHost 检查主机字段并补全默认值；调用方需持有锁
+func (s *Store) validateHost(h *Host) error {
+	if h.Address == "" {
+		return errors.New("主机地址不能为空")
+	}
+	if h.User == "" {
+		return errors.New("SSH账号不能为空")
+	}
+	if h.Port == 0 {
+		h.Port = 22
+	}
+	if h.Port < 0 || h.Port > 65535 {
+		return fmt.Errorf("无效的端口: %d", h.Port)
+	}
+	if _, exists := s.credentials[h.CredentialRef]; !exists {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
+
+// ListHosts 返回主机列表，tag 不为空时只返回带有该标签的主机
+func (s *Store) ListHosts(tag string) []Host {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hosts := make([]Host, 0, len(s.hosts))
+	for _, h := range s.hosts {
+		if tag != "" && !h.HasTag(tag) {
+			continue
+		}
+		hosts = append(hosts, *h)
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].ID < hosts[j].ID })
+	return hosts
+}
+
+// GetHost 按ID查询主机
+func (s *Store) GetHost(id string) (Host, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	h, exists := s.hosts[id]
+	if !exists {
+		return Host{}, ErrHostNotFound
+	}
+	return *h, nil
+}
+
+// CreateHost 添加主机，返回补全ID和默认值后的主机
+func (s *Store) CreateHost(h Host, createdBy string) (Host, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.validateHost(&h); err != nil {
+		return Host{}, err
+	}
+	now := time.Now()
+	h.ID = uuid.New().String()
+	h.CreatedBy = createdBy
+	h.CreatedAt = now
+	h.UpdatedAt = now
+
+	s.hosts[h.ID] = &h
+	if err := s.save(); err != nil {
+		delete(s.hosts, h.ID)
+		return Host{}, err
+	}
+	return h, nil
+}
+
+// UpdateHost 修改主机信息，ID、创建者和创建时间保持不变
+func (s *Store) UpdateHost(id string, h Host) (Host, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.hosts[id]
+	if !exists {
+		return Host{}, ErrHostNotFound
+	}
+	if err := s.validateHost(&h); err != nil {
+		return Host{}, err
+	}
+	h.ID = id
+	h.CreatedBy = old.CreatedBy
+	h.CreatedAt = old.CreatedAt
+	h.UpdatedAt = time.Now()
+
+	s.hosts[id] = &h
+	if err := s.save(); err != nil {
+		s.hosts[id] = old
+		return Host{}, err
+	}
+	return h, nil
+}
+
+// DeleteHost 删除主机
+func (s *Store) DeleteHost(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.hosts[id]
+	if !exists {
+		return ErrHostNotFound
+	}
+	delete(s.hosts, id)
+	if err := s.save(); err != nil {
+		s.hosts[id] = old
+		return err
+	}
+	return nil
+}
+
+// ListCredentials 返回凭据的元信息，不包含密码或私钥
+func (s *Store) ListCredentials() []Credential {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	creds := make([]Credential, 0, len(s.credentials))
+	for _, c := range s.credentials {
+		creds = append(creds, c.Credential)
+	}
+	sort.Slice(creds, func(i, j int) bool { return creds[i].ID < creds[j].ID })
+	return creds
+}
+
+// CreateCredential 保存凭据，只返回元信息
+func (s *Store) CreateCredential(name, credType, secret, passphrase, createdBy string) (Credential, error) {
+	if credType != CredentialPassword && credType != CredentialPrivateKey {
+		return Credential{}, fmt.Errorf("不支持的凭据类型: %s", credType)
+	}
+	if secret == "" {
+		return Credential{}, errors.New("凭据内容不能为空")
+	}
+	if s.keyring == nil {
+		return Credential{}, vault.ErrNoMasterKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := &credentialRecord{
+		Credential: Credential{
+			ID:        uuid.New().String(),
+			Name:      name,
+			Type:      credType,
+			CreatedBy: createdBy,
+			CreatedAt: time.Now(),
+		},
+	}
+	if err := s.seal(rec, secretPayload{Secret: secret, Passphrase: passphrase}); err != nil {
+		return Credential{}, err
+	}
+	s.credentials[rec.ID] = rec
+	if err := s.save(); err != nil {
+		delete(s.credentials, rec.ID)
+		return Credential{}, err
+	}
+	return rec.Credential, nil
+}
+
+// DeleteCredential 删除凭据，仍被主机引用时拒绝删除
+func (s *Store) DeleteCredential(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.credentials[id]
+	if !exists {
+		return ErrCredentialNotFound
+	}
+	for _, h := range s.hosts {
+		if h.CredentialRef == id {
+			return ErrCredentialInUse
+		}
+	}
+	delete(s.credentials, id)
+	if err := s.save(); err != nil {
+		s.credentials[id] = old
+		return err
+	}
+	return nil
+}
+
+// Resolve 根据主机ID生成连接所需的信息（含凭据），仅供服务内部建立连接使用
+func (s *Store) Resolve(id string) (trans.SSHTarget, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	h, exists := s.hosts[id]
+	if !exists {
+		return trans.SSHTarget{}, ErrHostNotFound
+	}
+	cred, exists := s.credentials[h.CredentialRef]
+	if !exists {
+		return trans.SSHTarget{}, ErrCredentialNotFound
+	}
+	payload, err := s.unseal(cred)
+	if err != nil {
+		logx.Errorf("解密凭据 %s 失败: %v", cred.ID, err)
+		return trans.SSHTarget{}, errors.New("凭据不可用")
+	}
+
+	target := trans.SSHTarget{
+		Name:    h.Hostname(),
+		Address: h.Address,
+		Port:    h.Port,
+		User:    h.User,
+		Proxy:   h.Proxy,
+	}
+	switch cred.Type {
+	case CredentialPrivateKey:
+		target.PrivateKey = payload.Secret
+		target.Passphrase = payload.Passphrase
+	default:
+		target.Password = payload.Secret
+	}
+	return target.WithPoolKey(h.Endpoint()), nil
+}
+
+// RotateCredentials 将所有凭据轮换到当前主密钥：默认只重新加密数据密钥，
+// reencrypt 为 true 时同时生成新的数据密钥重新加密凭据内容；返回发生变化的凭据数量
+func (s *Store) RotateCredentials(reencrypt bool) (int, error) {
+	if s.keyring == nil {
+		return 0, vault.ErrNoMasterKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rotated := make(map[string]*vault.Envelope)
+	for id, rec := range s.credentials {
+		if rec.Sealed == nil {
+			continue
+		}
+		var env *vault.Envelope
+		var err error
+		if reencrypt {
+			env, err = s.keyring.Reseal(rec.Sealed, []byte(id))
+		} else {
+			env, err = s.keyring.Rewrap(rec.Sealed)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("轮换凭据 %s 失败: %v", id, err)
+		}
+		if env != rec.Sealed {
+			rotated[id] = env
+		}
+	}
+	if len(rotated) == 0 {
+		return 0, nil
+	}
+
+	// 全部成功后再替换，保存失败时回滚
+	old := make(map[string]*vault.Envelope, len(rotated))
+	for id, env := range rotated {
+		rec := s.credentials[id]
+		old[id] = rec.Sealed
+		rec.Sealed, rec.KeyID = env, env.KeyID
+	}
+	if err := s.save(); err != nil {
+		for id, env := range old {
+			rec := s.credentials[id]
+			rec.Sealed, rec.KeyID = env, env.KeyID
+		}
+		return 0, err
+	}
+	return len(rotated), nil
+}
+
+// Endpoint 返回主机的地址，非默认端口时带端口；连接池的键在此基础上加上凭据指纹
+func (h *Host) Endpoint() string {
+	if h.Port == 0 || h.Port == 22 {
+		return h.Address
+	}
+	return net.JoinHostPort(h.Address, strconv.Itoa(h.Port))
+}
+
+// Hostname 返回用于校验服务器归属的主机名，未设置 Name 时为地址
+func (h *Host) Hostname() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return h.Address
+}
+
+// InScope 判断主机是否在限定范围内，范围中的每一项可以是主机ID、主机名或地址
+func (h *Host) InScope(scope []string) bool {
+	for _, s := range scope {
+		if s == h.ID || s == h.Name || s == h.Address || s == h.Endpoint() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag 判断主机是否带有指定标签
+func (h *Host) HasTag(tag string) bool {
+	for _, t := range h.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 
+	"file-transfer/middlewire"
+
 	"github.com/gin-gonic/gin"
 	"github.com/zeromicro/go-zero/core/logx"
 	"go.uber.org/zap"
@@ -147,7 +149,7 @@ func GetUserOperationLogs(c *gin.Context) {
 	scanner := bufio.NewScanner(file)
 
 	var logs []Log
-	if username == "root" { // 管理员
+	if middlewire.IsAdmin(c.GetString("role")) { // 管理员
 		if logRequest.Username == "" {
 			logs = FilterLogs(scanner, logRequest, "")
 		} else {
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"file-transfer/apikey"
+	"file-transfer/inventory"
 	"file-transfer/logs"
 	"file-transfer/middlewire"
 	cors "file-transfer/middlewire/cors"
+	"file-transfer/policy"
+	"file-transfer/quota"
+	"file-transfer/share"
+	"file-transfer/tlsconf"
 
 	"file-transfer/config"
 	grpcserver "file-transfer/grpc"
@@ -11,13 +17,17 @@ import (
 	"file-transfer/transfer"
 	g "file-transfer/transfer/global"
 	trans "file-transfer/transfer/trans-init"
+	"file-transfer/usersvc"
+	"file-transfer/vault"
 
+	"errors"
 	"net"
-	"time"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zeromicro/go-zero/core/logx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
@@ -31,39 +41,195 @@ func main() {
 
 	config.SetupLogx(cfg)
 
+	// 初始化Token校验
+	if err := middlewire.InitJWT(cfg.JWT); err != nil {
+		logx.Errorf("初始化Token校验失败：%v", err)
+		return
+	}
+
+	// 加载凭据主密钥，未配置时无法保存和使用主机凭据
+	keyring, err := vault.LoadKeyring(cfg.Vault)
+	if errors.Is(err, vault.ErrNoMasterKey) {
+		logx.Infof("未配置凭据主密钥，主机凭据功能不可用")
+		keyring = nil
+	} else if err != nil {
+		logx.Errorf("加载凭据主密钥失败：%v", err)
+		return
+	}
+
+	// 加载主机清单
+	inventory.Default, err = inventory.Open(cfg.Inventory.Path, keyring)
+	if err != nil {
+		logx.Errorf("加载主机清单失败：%v", err)
+		return
+	}
+
+	// 加载API密钥，供自动化客户端代替JWT使用
+	apikey.Default, err = apikey.Open(cfg.APIKey.Path)
+	if err != nil {
+		logx.Errorf("加载API密钥失败：%v", err)
+		return
+	}
+	middlewire.VerifyAPIKey = apikey.Default.Verify
+
+	// 加载分享链接
+	share.Default, err = share.Open(cfg.Share)
+	if err != nil {
+		logx.Errorf("加载分享链接失败：%v", err)
+		return
+	}
+
+	// 连接用户服务，用于校验服务器归属
+	usersvc.Default, err = usersvc.NewClient(cfg.UserService)
+	if err != nil {
+		logx.Errorf("初始化用户服务客户端失败：%v", err)
+		return
+	}
+	defer usersvc.Default.Close()
+
 	router := gin.Default()
 	router.Use(cors.CORSMiddleware())
 
 	// 初始化SSH连接池及文件传输服务
-	g.Pool = trans.NewSSHConnectionPool(10, 10*time.Minute)
+	g.Pool = trans.NewSSHConnectionPoolFromConfig(cfg.Pool)
+	trans.Proxy = cfg.Proxy
+	transfer.MaxUploadBodySize = cfg.Upload.MaxBodySize
+	transfer.Fetch = cfg.Fetch
+	transfer.Share = cfg.Share
+	middlewire.RBAC = cfg.RBAC
 	stopChan := make(chan struct{})
 	defer close(stopChan)
 	go g.Pool.Cleanup(stopChan)          // 启动清理协程
 	trans.NewFileTransferService(g.Pool) // 初始化文件传输服务
 	g.FTS = trans.NewFileTransferService(g.Pool)
 
+	// 加载路径访问策略，打开或创建远程文件前检查
+	engine, err := policy.New(cfg.Policy)
+	if err != nil {
+		logx.Errorf("加载访问策略失败：%v", err)
+		return
+	}
+	g.FTS.Guard = engine.Check
+
+	// 按用户和公司统计传输用量，超出配额时拒绝新任务
+	if cfg.Quota.Enabled {
+		quota.Default, err = quota.Open(cfg.Quota)
+		if err != nil {
+			logx.Errorf("加载用量统计失败：%v", err)
+			return
+		}
+		g.FTS.Meter = quota.Default.Meter
+	}
+
+	// 断点续传上传，定期清理过期的上传
+	transfer.InitTus(cfg.Tus)
+	go transfer.CleanupTusUploads(stopChan)
+
+	// 定期清理失效的分享链接
+	go share.Default.Cleanup(cfg.Share.Retention, stopChan)
+
 	// go monitor.CheckServerStatus()
 	router.Static("/static", "./static")
 
+	// tus 协议的能力查询无需认证
+	router.OPTIONS("/filetransfer/tus", transfer.TusOptions)
+	router.OPTIONS("/filetransfer/tus/:id", transfer.TusOptions)
+
+	// 分享链接下载无需认证，由链接签名和密码校验
+	router.GET("/share/:token", transfer.ShareDownload)
+	router.HEAD("/share/:token", transfer.ShareDownload)
+	router.POST("/share/:token", transfer.ShareDownload) // 表单提交密码
+
 	// 需要 JWT 认证的路由
 	auth := router.Group("/filetransfer", middlewire.JWTAuthMiddleware())
 	{
 		// 文件传输
-		auth.POST("/upload", transfer.CommonUpload)
-		auth.POST("/download", transfer.CommonDownload)
-		auth.POST("/transfer", transfer.TransferBetweenTwoServer)
+		auth.POST("/upload", middlewire.RequirePermission(middlewire.PermUpload), transfer.CommonUpload) // 参数放在查询字符串中或作为表单字段放在第一个文件之前
+		auth.POST("/download", middlewire.RequirePermission(middlewire.PermDownload), transfer.CommonDownload)
+		auth.GET("/download", middlewire.RequirePermission(middlewire.PermDownload), transfer.CommonDownload) // 参数放在查询字符串中，便于浏览器和下载工具断点续传；凭据只能用 host_id 或请求头
+		auth.HEAD("/download", middlewire.RequirePermission(middlewire.PermDownload), transfer.CommonDownload)
+		auth.POST("/transfer", middlewire.RequirePermission(middlewire.PermTransfer), transfer.TransferBetweenTwoServer)
+		auth.POST("/fetch", middlewire.RequirePermission(middlewire.PermUpload), transfer.FetchURL)
+
+		// 断点续传上传（tus 协议）
+		tus := auth.Group("/tus", transfer.TusResumable(), middlewire.RequirePermission(middlewire.PermUpload))
+		tus.POST("", transfer.TusCreate)
+		tus.HEAD("/:id", transfer.TusHead)
+		tus.PATCH("/:id", transfer.TusPatch)
+		tus.DELETE("/:id", transfer.TusDelete)
+
+		// 分享链接
+		auth.POST("/shares", middlewire.RequirePermission(middlewire.PermDownload), transfer.CreateShare)
+		auth.GET("/shares", middlewire.RequirePermission(middlewire.PermDownload), transfer.ListShares)
+		auth.DELETE("/shares/:id", middlewire.RequirePermission(middlewire.PermDownload), transfer.RevokeShare)
+
+		// 远程文件管理
+		auth.POST("/files/list", middlewire.RequirePermission(middlewire.PermDownload), transfer.ListDir)
+		auth.POST("/files/stat", middlewire.RequirePermission(middlewire.PermDownload), transfer.StatFile)
+		auth.POST("/files/readlink", middlewire.RequirePermission(middlewire.PermDownload), transfer.ReadLink)
+		auth.POST("/files/mkdir", middlewire.RequirePermission(middlewire.PermUpload), transfer.MakeDir)
+		auth.POST("/files/remove", middlewire.RequirePermission(middlewire.PermUpload), transfer.RemoveFile)
+		auth.POST("/files/rename", middlewire.RequirePermission(middlewire.PermUpload), transfer.RenameFile)
+		auth.POST("/files/chmod", middlewire.RequirePermission(middlewire.PermUpload), transfer.ChmodFile)
+		auth.POST("/files/symlink", middlewire.RequirePermission(middlewire.PermUpload), transfer.SymlinkFile)
 
 		// 日志
-		auth.POST("/getuseroprationlogs", logs.GetUserOperationLogs)
+		auth.POST("/getuseroprationlogs", middlewire.RequirePermission(middlewire.PermViewLogs), logs.GetUserOperationLogs)
+
+		// 主机清单（只读，按服务器归属和API密钥范围过滤）
+		auth.GET("/hosts", inventory.ListHosts)
+
+		// 当前用户的用量及配额
+		auth.GET("/usage", quota.GetUsage)
+	}
+
+	// 需要管理员权限的路由
+	admin := router.Group("/filetransfer/admin", middlewire.JWTAuthMiddleware(), middlewire.RequirePermission(middlewire.PermAdmin))
+	{
+		// 连接池管理
+		admin.GET("/pool", transfer.ListPoolConnections)
+		admin.POST("/pool/evict", transfer.EvictPoolConnections)
+		admin.POST("/pool/warmup", transfer.WarmupPool)
+		admin.POST("/pool/breaker/reset", transfer.ResetBreaker)
+
+		// 主机清单与凭据管理
+		admin.POST("/hosts", inventory.CreateHost)
+		admin.PUT("/hosts/:id", inventory.UpdateHost)
+		admin.DELETE("/hosts/:id", inventory.DeleteHost)
+		admin.GET("/credentials", inventory.ListCredentials)
+		admin.POST("/credentials", inventory.CreateCredential)
+		admin.DELETE("/credentials/:id", inventory.DeleteCredential)
+		admin.POST("/credentials/rotate", inventory.RotateCredentials)
+
+		// API密钥管理
+		admin.GET("/apikeys", apikey.ListAPIKeys)
+		admin.POST("/apikeys", apikey.CreateAPIKey)
+		admin.DELETE("/apikeys/:id", apikey.RevokeAPIKey)
+
+		// 所有用户和公司的用量
+		admin.GET("/usage", quota.ListUsage)
 	}
 
 	// 启动 gRPC 服务
+	grpcOpts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(grpcserver.AuthUnaryInterceptor()),
+		grpc.StreamInterceptor(grpcserver.AuthStreamInterceptor()),
+	}
+	if cfg.TLS.GRPC.Enabled {
+		tlsConfig, err := tlsconf.NewServerConfig(cfg.TLS.GRPC)
+		if err != nil {
+			logx.Errorf("加载 gRPC 证书失败：%v", err)
+			return
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		grpcserver.TLS = cfg.TLS.GRPC
+	}
 	go func() {
 		lis, err := net.Listen("tcp", ":9002")
 		if err != nil {
 			logx.Errorf("failed to listen: %v", err)
 		}
-		grpcServer := grpc.NewServer()
+		grpcServer := grpc.NewServer(grpcOpts...)
 		ft.RegisterFileTransferServiceServer(grpcServer, &grpcserver.Server{})
 		logx.Info("gRPC 服务正在监听：9002")
 		if err := grpcServer.Serve(lis); err != nil {
@@ -71,5 +237,18 @@ func main() {
 		}
 	}()
 
-	router.Run("0.0.0.0:8082")
+	if !cfg.TLS.HTTP.Enabled {
+		router.Run("0.0.0.0:8082")
+		return
+	}
+	tlsConfig, err := tlsconf.NewServerConfig(cfg.TLS.HTTP)
+	if err != nil {
+		logx.Errorf("加载 HTTP 证书失败：%v", err)
+		return
+	}
+	server := &http.Server{Addr: "0.0.0.0:8082", Handler: router, TLSConfig: tlsConfig}
+	logx.Info("HTTPS 服务正在监听：8082")
+	if err := server.ListenAndServeTLS("", ""); err != nil {
+		logx.Errorf("failed to serve : %v", err)
+	}
 }
@@ -0,0 +1,63 @@
+package middlewire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// APIKeyPrefix API密钥的固定前缀，便于与JWT区分
+const APIKeyPrefix = "ftk_"
+
+var (
+	ErrUnauthenticated = errors.New("未认证")
+	ErrInvalidAPIKey   = errors.New("无效的API密钥")
+)
+
+// VerifyAPIKey 校验API密钥并返回其代表的调用方，由 main 在启动时设置；为 nil 时不接受API密钥
+var VerifyAPIKey func(ctx context.Context, key string) (Identity, error)
+
+// ExtractAPIKey 从 X-API-Key 或 "Authorization: ApiKey <key>" 中取出API密钥，
+// Authorization 中直接携带以 APIKeyPrefix 开头的值也视为API密钥
+func ExtractAPIKey(authorization, xAPIKey string) string {
+	if key := strings.TrimSpace(xAPIKey); key != "" {
+		return key
+	}
+	authorization = strings.TrimSpace(authorization)
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "ApiKey ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	if strings.HasPrefix(authorization, APIKeyPrefix) {
+		return authorization
+	}
+	return ""
+}
+
+// Authenticate 校验请求携带的API密钥或JWT并确定调用方，HTTP 与 gRPC 共用
+// 凭据缺失或无效时返回的错误包含 ErrUnauthenticated，其余错误（如用户服务不可用）应按服务不可用处理
+func Authenticate(ctx context.Context, authorization, xAPIKey string) (Identity, error) {
+	if key := ExtractAPIKey(authorization, xAPIKey); key != "" {
+		if VerifyAPIKey == nil {
+			return Identity{}, fmt.Errorf("%w: 未启用API密钥", ErrUnauthenticated)
+		}
+		id, err := VerifyAPIKey(ctx, key)
+		if errors.Is(err, ErrInvalidAPIKey) {
+			return Identity{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+		}
+		return id, err
+	}
+
+	if strings.TrimSpace(authorization) == "" {
+		return Identity{}, fmt.Errorf("%w: 缺少Token", ErrUnauthenticated)
+	}
+	claims, err := ParseToken(authorization)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: 无效的Token: %v", ErrUnauthenticated, err)
+	}
+	role, err := ResolveRole(ctx, claims)
+	if err != nil {
+		return Identity{}, fmt.Errorf("查询用户 %s 的角色失败: %v", claims.Username, err)
+	}
+	return Identity{Username: claims.Username, Role: role}, nil
+}
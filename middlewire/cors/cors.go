@@ -9,8 +9,12 @@ import (
 func CORSMiddleware() gin.HandlerFunc {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"*"} // 允许的源，可以根据需要修改http://localhost:8081
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-API-Key",
+		"Range", "If-Range", "If-None-Match", "If-Modified-Since",
+		"Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata", "X-Share-Password", "X-SSH-User", "X-SSH-Auth"}
+	config.ExposeHeaders = []string{"Location", "ETag", "Content-Range", "Content-Disposition",
+		"Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Expires"}
 
 	return cors.New(config)
 }
\ No newline at end of file
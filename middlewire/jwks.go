@@ -0,0 +1,195 @@
+package middlewire
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 密钥集中找不到 kid 时，至少间隔这么久才重新拉取，避免伪造的 kid 打满 JWKS 服务
+const jwksMinRefetch = time.Minute
+
+var errUnknownKid = errors.New("未知的密钥ID")
+
+// jwk JWKS 中的单个公钥，只关心 RSA 与 EC 签名密钥
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwks 从文件或URL加载的公钥集合，按刷新间隔缓存
+type jwks struct {
+	url     string
+	file    string
+	refresh time.Duration
+	client  *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func newJWKS(url, file string, refresh time.Duration) (*jwks, error) {
+	if refresh <= 0 {
+		refresh = 10 * time.Minute
+	}
+	s := &jwks{
+		url:     url,
+		file:    file,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	// 启动时加载一次，配置错误尽早暴露
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// key 按 kid 查找公钥：缓存过期或找不到 kid 时重新加载，加载失败时继续使用旧的密钥
+func (s *jwks) key(kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.keys[kid]
+	age := time.Since(s.fetchedAt)
+	if (exists && age > s.refresh) || (!exists && age > jwksMinRefetch) {
+		if err := s.loadLocked(); err != nil {
+			logx.Errorf("刷新JWKS失败: %v", err)
+		} else {
+			key, exists = s.keys[kid]
+		}
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errUnknownKid, kid)
+	}
+	return key, nil
+}
+
+func (s *jwks) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked()
+}
+
+func (s *jwks) loadLocked() error {
+	// 无论成功与否都记录时间，失败时同样受最小间隔限制
+	s.fetchedAt = time.Now()
+
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("解析JWKS失败: %v", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			logx.Errorf("忽略JWKS中的密钥 %s: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS中没有可用的签名密钥")
+	}
+	s.keys = keys
+	return nil
+}
+
+func (s *jwks) read() ([]byte, error) {
+	if s.file != "" {
+		return os.ReadFile(s.file)
+	}
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("获取JWKS失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取JWKS失败: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// publicKey 将 JWK 转换为 RSA 或 ECDSA 公钥
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA指数无效")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC公钥不在曲线上")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("密钥参数格式错误")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
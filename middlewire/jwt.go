@@ -1,43 +1,187 @@
 package middlewire
 
 import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"strings"
+
+	"file-transfer/config"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/zeromicro/go-zero/core/logx"
 )
 
-var JwtKey = []byte("wujinhao123") // 用于加密的密钥，换成你想要的秘钥
-
 type Claims struct {
 	Username string `json:"username"`
-	jwt.StandardClaims
+	Role     string `json:"role,omitempty"` // 可选，未携带时按用户服务的 role_id 确定
+	jwt.RegisteredClaims
+}
+
+// tokenVerifier 按配置校验Token的签名和声明
+type tokenVerifier struct {
+	secret    []byte           // HMAC 密钥
+	publicKey crypto.PublicKey // 没有 kid 时使用的公钥
+	keySet    *jwks            // 按 kid 查找公钥
+	parser    *jwt.Parser
+}
+
+var verifier *tokenVerifier // 由 InitJWT 在启动时设置
+
+// InitJWT 按配置初始化Token校验，至少需要配置一种密钥
+func InitJWT(cfg config.JWTConfig) error {
+	v := &tokenVerifier{}
+
+	v.secret = []byte(cfg.Secret)
+	if cfg.SecretEnv != "" {
+		if secret := os.Getenv(cfg.SecretEnv); secret != "" {
+			v.secret = []byte(secret)
+		}
+	}
+	if len(v.secret) == 0 {
+		v.secret = nil
+	}
+
+	if cfg.PublicKeyFile != "" {
+		key, err := loadPublicKey(cfg.PublicKeyFile)
+		if err != nil {
+			return err
+		}
+		v.publicKey = key
+	}
+	if cfg.JWKSFile != "" || cfg.JWKSURL != "" {
+		keySet, err := newJWKS(cfg.JWKSURL, cfg.JWKSFile, cfg.JWKSRefresh)
+		if err != nil {
+			return fmt.Errorf("加载JWKS失败: %v", err)
+		}
+		v.keySet = keySet
+	}
+	if v.secret == nil && v.publicKey == nil && v.keySet == nil {
+		return errors.New("未配置Token校验密钥（Secret/SecretEnv、PublicKeyFile 或 JWKS）")
+	}
+
+	algorithms := cfg.Algorithms
+	if len(algorithms) == 0 {
+		if v.secret != nil {
+			algorithms = append(algorithms, "HS256")
+		}
+		if v.publicKey != nil || v.keySet != nil {
+			algorithms = append(algorithms, "RS256", "ES256")
+		}
+	}
+	for _, alg := range algorithms {
+		if jwt.GetSigningMethod(alg) == nil || alg == "none" {
+			return fmt.Errorf("不支持的签名算法: %s", alg)
+		}
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(algorithms), jwt.WithLeeway(cfg.Leeway)}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	if cfg.RequireExp {
+		opts = append(opts, jwt.WithExpirationRequired())
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	verifier = v
+	return nil
 }
 
+// loadPublicKey 读取 PEM 格式的公钥或证书
+func loadPublicKey(file string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取公钥失败: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("公钥不是 PEM 格式")
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析证书失败: %v", err)
+		}
+		return cert.PublicKey, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥失败: %v", err)
+	}
+	return key, nil
+}
+
+// keyFunc 按签名算法选择校验密钥：HMAC 使用密钥，RSA/ECDSA 优先按 kid 从 JWKS 查找
+func (v *tokenVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if v.secret == nil {
+			return nil, errors.New("未配置HMAC密钥")
+		}
+		return v.secret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+		if kid, _ := token.Header["kid"].(string); kid != "" && v.keySet != nil {
+			return v.keySet.key(kid)
+		}
+		if v.publicKey == nil {
+			return nil, errors.New("未配置公钥")
+		}
+		return v.publicKey, nil
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %v", token.Header["alg"])
+	}
+}
+
+// ParseToken 校验Token并返回其中的声明，HTTP 与 gRPC 共用；兼容带 "Bearer " 前缀的写法
+func ParseToken(tokenStr string) (*Claims, error) {
+	if verifier == nil {
+		return nil, errors.New("Token校验未初始化")
+	}
+	tokenStr = strings.TrimSpace(tokenStr)
+	if len(tokenStr) > 7 && strings.EqualFold(tokenStr[:7], "Bearer ") {
+		tokenStr = strings.TrimSpace(tokenStr[7:])
+	}
+
+	claims := &Claims{}
+	if _, err := verifier.parser.ParseWithClaims(tokenStr, claims, verifier.keyFunc); err != nil {
+		return nil, err
+	}
+	if claims.Username == "" {
+		return nil, errors.New("Token中缺少用户名")
+	}
+	return claims, nil
+}
+
+// JWTAuthMiddleware 校验请求携带的JWT或API密钥，将调用方保存到上下文
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenStr := c.GetHeader("Authorization")
-		if tokenStr == "" {
-			logx.Errorf("请求头中缺少Token")
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "请求头中缺少Token"})
+		id, err := Authenticate(c.Request.Context(), c.GetHeader("Authorization"), c.GetHeader("X-API-Key"))
+		if errors.Is(err, ErrUnauthenticated) {
+			logx.Errorf("认证失败: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "无效的Token或API密钥"})
 			c.Abort()
 			return
 		}
-
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			return JwtKey, nil // jwtKey 是你的签名密钥
-		})
-		if err != nil || !token.Valid {
-			logx.Errorf("无效的Token")
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "无效的Token"})
+		if err != nil {
+			logx.Errorf("认证失败: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"message": "查询用户角色失败"})
 			c.Abort()
 			return
 		}
 
-		// 将用户名保存到上下文，供后续处理使用
-		c.Set("username", claims.Username)
+		// 将用户名和角色保存到上下文，供后续处理使用
+		c.Set("username", id.Username)
+		c.Set("role", id.Role)
+		c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), id))
 		c.Next()
 	}
-}
\ No newline at end of file
+}
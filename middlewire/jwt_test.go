@@ -0,0 +1,336 @@
+package middlewire
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"file-transfer/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// useJWT 按配置初始化Token校验，测试结束后恢复
+func useJWT(t *testing.T, cfg config.JWTConfig) {
+	t.Helper()
+	old := verifier
+	t.Cleanup(func() { verifier = old })
+	if err := InitJWT(cfg); err != nil {
+		t.Fatalf("InitJWT 失败: %v", err)
+	}
+}
+
+// sign 生成 Token，kid 为空时不设置
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	return s
+}
+
+func claimsFor(username string, ttl time.Duration) *Claims {
+	now := time.Now()
+	return &Claims{
+		Username: username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+}
+
+// rsaJWK 将 RSA 公钥编码为 JWK
+func rsaJWK(kid string, pub *rsa.PublicKey) jwk {
+	return jwk{
+		Kid: kid,
+		Kty: "RSA",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+// ecJWK 将 P-256 公钥编码为 JWK
+func ecJWK(kid string, pub *ecdsa.PublicKey) jwk {
+	return jwk{
+		Kid: kid,
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// jwksServer 提供可替换内容的 JWKS 地址，并统计请求次数
+type jwksServer struct {
+	mu       sync.Mutex
+	keys     []jwk
+	requests int
+}
+
+func (s *jwksServer) set(keys ...jwk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	json.NewEncoder(w).Encode(map[string][]jwk{"keys": s.keys})
+}
+
+func TestParseToken(t *testing.T) {
+	secret := []byte("test-secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pemKey})
+	pemFile := filepath.Join(t.TempDir(), "jwt.pub")
+	if err := os.WriteFile(pemFile, pemBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := &jwksServer{}
+	keys.set(rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey))
+	srv := httptest.NewServer(keys)
+	defer srv.Close()
+
+	t.Setenv("JWT_TEST_SECRET", string(secret))
+	useJWT(t, config.JWTConfig{
+		SecretEnv:     "JWT_TEST_SECRET",
+		PublicKeyFile: pemFile,
+		JWKSURL:       srv.URL,
+		Issuer:        "auth.example.com",
+		Audience:      "file-transfer",
+		Leeway:        5 * time.Second,
+		RequireExp:    true,
+	})
+
+	valid := func(username string) *Claims {
+		c := claimsFor(username, time.Hour)
+		c.Issuer = "auth.example.com"
+		c.Audience = jwt.ClaimStrings{"file-transfer"}
+		return c
+	}
+	with := func(modify func(c *Claims)) *Claims {
+		c := valid("alice")
+		modify(c)
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    string // 为空表示应当校验失败
+		wantErr error
+	}{
+		{name: "HS256", token: sign(t, jwt.SigningMethodHS256, secret, "", valid("alice")), want: "alice"},
+		{name: "带 Bearer 前缀", token: "Bearer " + sign(t, jwt.SigningMethodHS256, secret, "", valid("alice")), want: "alice"},
+		{name: "小写 bearer 前缀", token: "bearer  " + sign(t, jwt.SigningMethodHS256, secret, "", valid("alice")), want: "alice"},
+		{name: "JWKS 中的 RSA 公钥", token: sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", valid("bob")), want: "bob"},
+		{name: "JWKS 中的 EC 公钥", token: sign(t, jwt.SigningMethodES256, ecKey, "ec-1", valid("carol")), want: "carol"},
+		{name: "没有 kid 时使用 PEM 公钥", token: sign(t, jwt.SigningMethodRS256, rsaKey, "", valid("dave")), want: "dave"},
+		{name: "HMAC 密钥错误", token: sign(t, jwt.SigningMethodHS256, []byte("wrong"), "", valid("alice")), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "kid 对应的公钥不匹配", token: sign(t, jwt.SigningMethodRS256, otherRSA, "rsa-1", valid("alice")), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "未知的 kid", token: sign(t, jwt.SigningMethodRS256, otherRSA, "rsa-9", valid("alice")), wantErr: errUnknownKid},
+		{name: "用公钥作为 HMAC 密钥伪造", token: sign(t, jwt.SigningMethodHS256, pemBytes, "", valid("alice"))},
+		{name: "alg 为 none", token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", valid("alice"))},
+		{name: "未允许的算法", token: sign(t, jwt.SigningMethodHS512, secret, "", valid("alice")), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "已过期", token: sign(t, jwt.SigningMethodHS256, secret, "", with(func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) })), wantErr: jwt.ErrTokenExpired},
+		{name: "过期时间在允许的偏差内", token: sign(t, jwt.SigningMethodHS256, secret, "", with(func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Second)) })), want: "alice"},
+		{name: "尚未生效", token: sign(t, jwt.SigningMethodHS256, secret, "", with(func(c *Claims) { c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Minute)) })), wantErr: jwt.ErrTokenNotValidYet},
+		{name: "缺少 exp", token: sign(t, jwt.SigningMethodHS256, secret, "", with(func(c *Claims) { c.ExpiresAt = nil })), wantErr: jwt.ErrTokenRequiredClaimMissing},
+		{name: "iss 不一致", token: sign(t, jwt.SigningMethodHS256, secret, "", with(func(c *Claims) { c.Issuer = "evil.example.com" })), wantErr: jwt.ErrTokenInvalidIssuer},
+		{name: "aud 不一致", token: sign(t, jwt.SigningMethodHS256, secret, "", with(func(c *Claims) { c.Audience = jwt.ClaimStrings{"other"} })), wantErr: jwt.ErrTokenInvalidAudience},
+		{name: "缺少用户名", token: sign(t, jwt.SigningMethodHS256, secret, "", valid(""))},
+		{name: "格式错误", token: "not-a-token", wantErr: jwt.ErrTokenMalformed},
+		{name: "空 Token", token: "", wantErr: jwt.ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseToken(tt.token)
+			if tt.want != "" {
+				if err != nil {
+					t.Fatalf("ParseToken 失败: %v", err)
+				}
+				if claims.Username != tt.want {
+					t.Errorf("Username = %q, want %q", claims.Username, tt.want)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ParseToken 应当失败，得到用户 %q", claims.Username)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInitJWT(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.JWTConfig
+		wantErr bool
+	}{
+		{"HMAC 密钥", config.JWTConfig{Secret: "s"}, false},
+		{"未配置密钥", config.JWTConfig{}, true},
+		{"环境变量为空", config.JWTConfig{SecretEnv: "JWT_TEST_UNSET"}, true},
+		{"不支持的算法", config.JWTConfig{Secret: "s", Algorithms: []string{"XX256"}}, true},
+		{"不允许 none", config.JWTConfig{Secret: "s", Algorithms: []string{"none"}}, true},
+		{"公钥文件不存在", config.JWTConfig{PublicKeyFile: "/nonexistent/jwt.pub"}, true},
+		{"JWKS 文件不存在", config.JWTConfig{JWKSFile: "/nonexistent/jwks.json"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := verifier
+			defer func() { verifier = old }()
+			err := InitJWT(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InitJWT err = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWKSRefresh(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := &jwksServer{}
+	keys.set(rsaJWK("old", &oldKey.PublicKey))
+	srv := httptest.NewServer(keys)
+	defer srv.Close()
+
+	set, err := newJWKS(srv.URL, "", time.Hour)
+	if err != nil {
+		t.Fatalf("加载JWKS失败: %v", err)
+	}
+	keys.set(rsaJWK("old", &oldKey.PublicKey), rsaJWK("new", &newKey.PublicKey))
+	age := func(d time.Duration) {
+		set.mu.Lock()
+		set.fetchedAt = time.Now().Add(-d)
+		set.mu.Unlock()
+	}
+
+	tests := []struct {
+		name         string
+		age          time.Duration // 距上次加载的时间
+		kid          string
+		want         crypto.PublicKey
+		wantRequests int // 本次查找后的累计请求次数
+	}{
+		{"缓存中的 kid 不重新加载", 0, "old", &oldKey.PublicKey, 1},
+		{"新 kid 在最小间隔内不重新加载", 0, "new", nil, 1},
+		{"新 kid 超过最小间隔后重新加载", jwksMinRefetch + time.Second, "new", &newKey.PublicKey, 2},
+		{"伪造的 kid 在最小间隔内不重新加载", 0, "forged", nil, 2},
+		{"缓存过期后重新加载", 2 * time.Hour, "old", &oldKey.PublicKey, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			age(tt.age)
+			got, err := set.key(tt.kid)
+			if tt.want == nil {
+				if !errors.Is(err, errUnknownKid) {
+					t.Errorf("err = %v, want errUnknownKid", err)
+				}
+			} else if err != nil || !got.(*rsa.PublicKey).Equal(tt.want) {
+				t.Errorf("key(%q) = %v, %v", tt.kid, got, err)
+			}
+			keys.mu.Lock()
+			requests := keys.requests
+			keys.mu.Unlock()
+			if requests != tt.wantRequests {
+				t.Errorf("请求次数 = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+
+	// 重新加载失败时继续使用旧的密钥
+	srv.Close()
+	age(2 * time.Hour)
+	if _, err := set.key("new"); err != nil {
+		t.Errorf("JWKS 不可用时应继续使用缓存的密钥: %v", err)
+	}
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offCurve := ecJWK("ec", &ecKey.PublicKey)
+	offCurve.Y = base64.RawURLEncoding.EncodeToString(big.NewInt(1).Bytes())
+	hugeExp := rsaJWK("rsa", &rsaKey.PublicKey)
+	hugeExp.E = base64.RawURLEncoding.EncodeToString(new(big.Int).Lsh(big.NewInt(1), 40).Bytes())
+	badCurve := ecJWK("ec", &ecKey.PublicKey)
+	badCurve.Crv = "secp256k1"
+
+	tests := []struct {
+		name    string
+		key     jwk
+		wantErr bool
+	}{
+		{"RSA", rsaJWK("rsa", &rsaKey.PublicKey), false},
+		{"EC", ecJWK("ec", &ecKey.PublicKey), false},
+		{"EC 公钥不在曲线上", offCurve, true},
+		{"不支持的曲线", badCurve, true},
+		{"RSA 指数过大", hugeExp, true},
+		{"缺少模数", jwk{Kty: "RSA", E: "AQAB"}, true},
+		{"不支持的密钥类型", jwk{Kty: "oct"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.key.publicKey()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("publicKey err = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package middlewire
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"file-transfer/config"
+	"file-transfer/usersvc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 角色
+const (
+	RoleAdmin    = "admin"     // 管理员，拥有全部权限
+	RoleOperator = "operator"  // 运维，可上传、下载、两服务器间传输
+	RoleReadOnly = "read-only" // 只读，只能下载
+	RoleUploader = "uploader"  // 只能上传
+)
+
+// 权限
+const (
+	PermUpload   = "upload"    // 上传文件
+	PermDownload = "download"  // 下载文件
+	PermTransfer = "transfer"  // 两服务器间传输
+	PermViewLogs = "view_logs" // 查看自己的操作日志，管理员可查看所有人的
+	PermAdmin    = "admin"     // 连接池、主机清单等管理接口
+)
+
+// 各角色拥有的权限
+var rolePermissions = map[string][]string{
+	RoleAdmin:    {PermUpload, PermDownload, PermTransfer, PermViewLogs, PermAdmin},
+	RoleOperator: {PermUpload, PermDownload, PermTransfer, PermViewLogs},
+	RoleReadOnly: {PermDownload, PermViewLogs},
+	RoleUploader: {PermUpload, PermViewLogs},
+}
+
+var RBAC config.RBACConfig // 角色配置，由 main 在启动时设置
+
+// Identity 已认证的调用方，随请求的 context 传递给下层（如访问策略）
+type Identity struct {
+	Username   string
+	Role       string
+	APIKeyID   string   // 使用API密钥认证时为密钥ID
+	Operations []string // API密钥限定的操作（权限），为空表示不额外限制
+	Hosts      []string // API密钥限定的服务器（主机ID或主机名），为空表示不额外限制
+}
+
+// Can 判断调用方是否拥有某项权限：角色须拥有该权限，且不超出API密钥限定的操作
+func (id Identity) Can(perm string) bool {
+	if !HasPermission(id.Role, perm) {
+		return false
+	}
+	return len(id.Operations) == 0 || slices.Contains(id.Operations, perm)
+}
+
+// ValidRole 判断是否为已定义的角色
+func ValidRole(role string) bool {
+	_, exists := rolePermissions[role]
+	return exists
+}
+
+// ValidPermission 判断是否为已定义的权限
+func ValidPermission(perm string) bool {
+	return slices.Contains(rolePermissions[RoleAdmin], perm)
+}
+
+type identityKey struct{}
+
+// WithIdentity 将调用方信息保存到 context
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFrom 读取 context 中的调用方信息，未认证时返回 false
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// HasPermission 判断角色是否拥有某项权限，未知角色没有任何权限
+func HasPermission(role, perm string) bool {
+	return slices.Contains(rolePermissions[role], perm)
+}
+
+// IsAdmin 判断角色是否为管理员
+func IsAdmin(role string) bool {
+	return HasPermission(role, PermAdmin)
+}
+
+// ResolveRole 确定用户的角色：配置的管理员 > JWT 中的 role 声明 > 用户服务的 role_id > 默认角色
+// 用户服务不可用时返回错误，调用方应拒绝请求
+func ResolveRole(ctx context.Context, claims *Claims) (string, error) {
+	if slices.Contains(RBAC.Admins, claims.Username) {
+		return RoleAdmin, nil
+	}
+	if claims.Role != "" {
+		return claims.Role, nil
+	}
+
+	if usersvc.Default == nil {
+		return RBAC.DefaultRole, nil
+	}
+	u, err := usersvc.Default.GetUser(ctx, claims.Username)
+	if err != nil {
+		return "", err
+	}
+	if u != nil {
+		if role, exists := RBAC.RoleIDs[u.RoleId]; exists {
+			return role, nil
+		}
+	}
+	return RBAC.DefaultRole, nil
+}
+
+// RequirePermission 仅允许拥有指定权限的用户访问，需在 JWTAuthMiddleware 之后使用
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := IdentityFrom(c.Request.Context())
+		if !id.Can(perm) {
+			logx.Errorf("用户 %s（角色：%s）无 %s 权限", id.Username, id.Role, perm)
+			c.JSON(http.StatusForbidden, gin.H{"message": "权限不足"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,229 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"file-transfer/config"
+	"file-transfer/inventory"
+	"file-transfer/middlewire"
+	g "file-transfer/transfer/global"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 规则效果
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+const anyValue = "*"
+
+// rule 编译后的规则
+type rule struct {
+	config.PolicyRule
+	paths []*regexp.Regexp
+}
+
+// Engine 路径访问策略，按主机组和角色匹配规则
+type Engine struct {
+	defaultAllow bool
+	hostGroups   map[string][]string
+	rules        []rule
+	resolver     *resolver
+}
+
+// New 校验并编译策略配置
+func New(cfg config.PolicyConfig) (*Engine, error) {
+	e := &Engine{hostGroups: cfg.HostGroups, resolver: newResolver()}
+	switch cfg.Default {
+	case "", EffectAllow:
+		e.defaultAllow = true
+	case EffectDeny:
+	default:
+		return nil, fmt.Errorf("无效的默认策略: %s", cfg.Default)
+	}
+
+	for i, r := range cfg.Rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if r.Effect != EffectAllow && r.Effect != EffectDeny {
+			return nil, fmt.Errorf("规则 %s 的效果无效: %s", r.Name, r.Effect)
+		}
+		for _, a := range r.Access {
+			if a != g.AccessRead && a != g.AccessWrite {
+				return nil, fmt.Errorf("规则 %s 的访问类型无效: %s", r.Name, a)
+			}
+		}
+		if len(r.Paths) == 0 {
+			return nil, fmt.Errorf("规则 %s 未指定路径", r.Name)
+		}
+
+		compiled := rule{PolicyRule: r}
+		for _, p := range r.Paths {
+			re, err := compilePattern(p)
+			if err != nil {
+				return nil, fmt.Errorf("规则 %s 的路径 %s 无效: %v", r.Name, p, err)
+			}
+			compiled.paths = append(compiled.paths, re)
+		}
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+// compilePattern 将路径模式转换为正则：* 和 ? 不跨目录，** 跨目录，以 / 结尾的模式匹配该目录及其下所有文件
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if !path.IsAbs(pattern) {
+		return nil, fmt.Errorf("必须是绝对路径")
+	}
+	prefix := strings.HasSuffix(pattern, "/") && pattern != "/"
+	pattern = path.Clean(pattern)
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	switch {
+	case pattern == "/":
+		b.WriteString(".*")
+	case prefix:
+		b.WriteString("(/.*)?")
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// hostAliases 返回服务器的等价形式：去掉端口、转为小写的主机名，主机清单中地址或主机名相同的主机的
+// 名称和地址，以及这些名称解析得到的IP地址；同时返回这些清单主机的标签
+func (e *Engine) hostAliases(ctx context.Context, server string) (names []string, ips []net.IP, tags []string) {
+	host := normalizeHost(server)
+	names = []string{host}
+	if inventory.Default != nil {
+		for _, h := range inventory.Default.ListHosts("") {
+			addr, name := normalizeHost(h.Address), normalizeHost(h.Name)
+			if host != addr && host != name && host != normalizeHost(h.Endpoint()) {
+				continue
+			}
+			for _, n := range []string{addr, name} {
+				if n != "" && !slices.Contains(names, n) {
+					names = append(names, n)
+				}
+			}
+			tags = append(tags, h.Tags...)
+		}
+	}
+	for _, n := range names {
+		ips = append(ips, e.resolver.lookup(ctx, n)...)
+	}
+	return names, ips, tags
+}
+
+// normalizeHost 去掉端口、方括号和末尾的点，并转为小写，使同一主机的不同写法得到相同的结果
+func normalizeHost(server string) string {
+	host := server
+	if h, _, err := net.SplitHostPort(server); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// memberMatches 判断主机组成员是否匹配服务器：成员可以是通配符、主机名、IP 或 CIDR，
+// 不含通配符的主机名按解析得到的IP比较，因此同一主机用主机名或IP访问都能命中
+func (e *Engine) memberMatches(ctx context.Context, member string, names []string, ips []net.IP) bool {
+	m := normalizeHost(member)
+	for _, n := range names {
+		if ok, _ := path.Match(m, n); ok {
+			return true
+		}
+	}
+	if _, cidr, err := net.ParseCIDR(member); err == nil {
+		return slices.ContainsFunc(ips, cidr.Contains)
+	}
+	if strings.ContainsAny(m, "*?[") {
+		return false
+	}
+	for _, ip := range e.resolver.lookup(ctx, m) {
+		if slices.ContainsFunc(ips, ip.Equal) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupsOf 返回服务器所属的主机组：配置中匹配的主机组加上主机清单中的标签。
+// 服务器不属于任何主机组时只有不限主机组的规则适用，都未命中时按默认策略处理
+func (e *Engine) groupsOf(ctx context.Context, server string) []string {
+	names, ips, groups := e.hostAliases(ctx, server)
+	for name, members := range e.hostGroups {
+		if slices.ContainsFunc(members, func(m string) bool { return e.memberMatches(ctx, m, names, ips) }) {
+			groups = append(groups, name)
+		}
+	}
+	return groups
+}
+
+// matches 判断规则是否适用于本次访问
+func (r *rule) matches(groups []string, role string, req g.AccessRequest) bool {
+	if len(r.Access) > 0 && !slices.Contains(r.Access, req.Access) {
+		return false
+	}
+	if len(r.Roles) > 0 && !slices.Contains(r.Roles, anyValue) && !slices.Contains(r.Roles, role) {
+		return false
+	}
+	if len(r.Groups) > 0 && !slices.Contains(r.Groups, anyValue) &&
+		!slices.ContainsFunc(r.Groups, func(group string) bool { return slices.Contains(groups, group) }) {
+		return false
+	}
+	return slices.ContainsFunc(r.paths, func(re *regexp.Regexp) bool { return re.MatchString(req.Path) })
+}
+
+// Check 实现 global.AccessGuard：命中拒绝规则即拒绝，命中允许规则即允许并取最宽松的大小上限，都未命中时按默认策略
+func (e *Engine) Check(ctx context.Context, req g.AccessRequest) (g.AccessGrant, error) {
+	id, _ := middlewire.IdentityFrom(ctx)
+	groups := e.groupsOf(ctx, req.Server)
+
+	allowed := false
+	var maxSize int64
+	for i := range e.rules {
+		r := &e.rules[i]
+		if !r.matches(groups, id.Role, req) {
+			continue
+		}
+		if r.Effect == EffectDeny {
+			logx.Errorf("用户 %s 访问 %s:%s（%s）被规则 %s 拒绝", id.Username, req.Server, req.Path, req.Access, r.Name)
+			return g.AccessGrant{}, fmt.Errorf("%w: %s", g.ErrAccessDenied, req.Path)
+		}
+		if !allowed || r.MaxSize == 0 || (maxSize != 0 && r.MaxSize > maxSize) {
+			maxSize = r.MaxSize
+		}
+		allowed = true
+	}
+
+	if !allowed && !e.defaultAllow {
+		logx.Errorf("用户 %s 访问 %s:%s（%s）未命中任何允许规则", id.Username, req.Server, req.Path, req.Access)
+		return g.AccessGrant{}, fmt.Errorf("%w: %s", g.ErrAccessDenied, req.Path)
+	}
+	return g.AccessGrant{MaxSize: maxSize}, nil
+}
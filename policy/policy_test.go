@@ -0,0 +1,235 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"file-transfer/config"
+	"file-transfer/inventory"
+	"file-transfer/middlewire"
+	g "file-transfer/transfer/global"
+)
+
+// fakeResolve 使用固定的解析结果，测试不依赖 DNS
+func fakeResolve(records map[string]string) func(ctx context.Context, host string) ([]net.IP, error) {
+	return func(ctx context.Context, host string) ([]net.IP, error) {
+		if ip, ok := records[host]; ok {
+			return []net.IP{net.ParseIP(ip)}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+}
+
+// useInventory 将带有给定主机的清单设为全局清单，测试结束后恢复
+func useInventory(t *testing.T, hosts []inventory.Host) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	data, err := json.Marshal(map[string]any{"hosts": hosts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := inventory.Open(path, nil)
+	if err != nil {
+		t.Fatalf("加载主机清单失败: %v", err)
+	}
+	old := inventory.Default
+	inventory.Default = store
+	t.Cleanup(func() { inventory.Default = old })
+}
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/data/", "/data", true},
+		{"/data/", "/data/a/b.txt", true},
+		{"/data/", "/database", false},
+		{"/data", "/data", true},
+		{"/data", "/data/a.txt", false},
+		{"/data/*.log", "/data/app.log", true},
+		{"/data/*.log", "/data/sub/app.log", false},
+		{"/data/**.log", "/data/sub/app.log", true},
+		{"/home/*/.ssh/", "/home/alice/.ssh/authorized_keys", true},
+		{"/home/*/.ssh/", "/home/alice/docs/.ssh", false},
+		{"/tmp/?.txt", "/tmp/a.txt", true},
+		{"/tmp/?.txt", "/tmp/ab.txt", false},
+		{"/", "/etc/passwd", true},
+		{"/etc/../data/", "/data/x", true},
+		{"/a+b/", "/a+b/c", true},
+		{"/a+b/", "/aab/c", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			re, err := compilePattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("compilePattern(%q) 失败: %v", tt.pattern, err)
+			}
+			if got := re.MatchString(tt.path); got != tt.want {
+				t.Errorf("%q 匹配 %q = %t, want %t", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := compilePattern("data/"); err == nil {
+		t.Error("相对路径应当返回错误")
+	}
+}
+
+func TestNew(t *testing.T) {
+	rule := config.PolicyRule{Effect: EffectAllow, Paths: []string{"/data/"}}
+	tests := []struct {
+		name    string
+		cfg     config.PolicyConfig
+		wantErr bool
+	}{
+		{"默认为 allow", config.PolicyConfig{}, false},
+		{"默认为 deny", config.PolicyConfig{Default: EffectDeny, Rules: []config.PolicyRule{rule}}, false},
+		{"无效的默认策略", config.PolicyConfig{Default: "maybe"}, true},
+		{"无效的效果", config.PolicyConfig{Rules: []config.PolicyRule{{Effect: "permit", Paths: []string{"/"}}}}, true},
+		{"无效的访问类型", config.PolicyConfig{Rules: []config.PolicyRule{{Effect: EffectAllow, Paths: []string{"/"}, Access: []string{"exec"}}}}, true},
+		{"未指定路径", config.PolicyConfig{Rules: []config.PolicyRule{{Effect: EffectAllow}}}, true},
+		{"相对路径", config.PolicyConfig{Rules: []config.PolicyRule{{Effect: EffectAllow, Paths: []string{"data/"}}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New err = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Example.COM", "example.com"},
+		{"example.com:22", "example.com"},
+		{"example.com.", "example.com"},
+		{"[::1]:2222", "::1"},
+		{"[::1]", "::1"},
+		{"10.0.0.1", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		if got := normalizeHost(tt.in); got != tt.want {
+			t.Errorf("normalizeHost(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	useInventory(t, []inventory.Host{
+		{ID: "h1", Name: "Build-01", Address: "172.16.0.9", Port: 2222, Tags: []string{"build"}},
+	})
+	e, err := New(config.PolicyConfig{
+		Default: EffectDeny,
+		HostGroups: map[string][]string{
+			"prod":     {"*.prod.example.com", "10.0.0.0/8"},
+			"db":       {"db.example.com"},
+			"internal": {"localhost"},
+		},
+		Rules: []config.PolicyRule{
+			{Name: "no-ssh-keys", Effect: EffectDeny, Paths: []string{"/home/*/.ssh/", "/**/.ssh/"}},
+			{Name: "prod-read-only", Effect: EffectDeny, Groups: []string{"prod"}, Roles: []string{"operator"}, Access: []string{g.AccessWrite}, Paths: []string{"/"}},
+			{Name: "internal-deny", Effect: EffectDeny, Groups: []string{"internal", "db"}, Paths: []string{"/"}},
+			{Name: "data", Effect: EffectAllow, Roles: []string{"*"}, Paths: []string{"/data/"}, MaxSize: 100},
+			{Name: "data-admin", Effect: EffectAllow, Roles: []string{"admin"}, Paths: []string{"/data/"}, MaxSize: 1000},
+			{Name: "data-unlimited", Effect: EffectAllow, Roles: []string{"root"}, Paths: []string{"/data/"}},
+			{Name: "build", Effect: EffectAllow, Groups: []string{"build"}, Paths: []string{"/srv/"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New 失败: %v", err)
+	}
+	e.resolver.resolve = fakeResolve(map[string]string{
+		"db.example.com": "192.168.1.5",
+		"db-alias":       "192.168.1.5",
+		"localhost":      "127.0.0.1",
+		"app.prod.local": "10.1.2.3",
+	})
+
+	tests := []struct {
+		name    string
+		role    string
+		server  string
+		path    string
+		access  string
+		want    bool
+		maxSize int64
+	}{
+		{"允许规则", "operator", "web.example.com", "/data/a.txt", g.AccessWrite, true, 100},
+		{"默认拒绝", "operator", "web.example.com", "/etc/passwd", g.AccessRead, false, 0},
+		{"拒绝规则优先于允许规则", "admin", "web.example.com", "/data/bob/.ssh/id_rsa", g.AccessRead, false, 0},
+		{"拒绝 .ssh 目录", "admin", "web.example.com", "/home/bob/.ssh/authorized_keys", g.AccessWrite, false, 0},
+		{"多条允许规则取最宽松的上限", "admin", "web.example.com", "/data/a.txt", g.AccessWrite, true, 1000},
+		{"不限制的允许规则", "root", "web.example.com", "/data/a.txt", g.AccessWrite, true, 0},
+		{"通配符主机组限制写入", "operator", "api.prod.example.com", "/data/a.txt", g.AccessWrite, false, 0},
+		{"通配符主机组允许读取", "operator", "api.prod.example.com", "/data/a.txt", g.AccessRead, true, 100},
+		{"主机组不区分大小写和端口", "operator", "API.PROD.example.com:22", "/data/a.txt", g.AccessWrite, false, 0},
+		{"其他角色不受只读限制", "admin", "api.prod.example.com", "/data/a.txt", g.AccessWrite, true, 1000},
+		{"CIDR 匹配IP", "operator", "10.20.30.40", "/data/a.txt", g.AccessWrite, false, 0},
+		{"CIDR 匹配解析后的IP", "operator", "app.prod.local", "/data/a.txt", g.AccessWrite, false, 0},
+		{"主机名成员匹配同一IP", "admin", "192.168.1.5", "/data/a.txt", g.AccessRead, false, 0},
+		{"主机名成员匹配别名", "admin", "db-alias", "/data/a.txt", g.AccessRead, false, 0},
+		{"localhost 的不同写法", "admin", "LOCALHOST.:22", "/data/a.txt", g.AccessRead, false, 0},
+		{"用IP访问 localhost", "admin", "127.0.0.1", "/data/a.txt", g.AccessRead, false, 0},
+		{"主机清单标签按地址匹配", "operator", "172.16.0.9:2222", "/srv/app", g.AccessWrite, true, 0},
+		{"主机清单标签按名称匹配", "operator", "build-01", "/srv/app", g.AccessWrite, true, 0},
+		{"没有标签的主机", "operator", "172.16.0.10", "/srv/app", g.AccessWrite, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := middlewire.WithIdentity(context.Background(), middlewire.Identity{Username: "alice", Role: tt.role})
+			grant, err := e.Check(ctx, g.AccessRequest{Server: tt.server, Path: tt.path, Access: tt.access})
+			if tt.want {
+				if err != nil {
+					t.Fatalf("Check 拒绝: %v", err)
+				}
+				if grant.MaxSize != tt.maxSize {
+					t.Errorf("MaxSize = %d, want %d", grant.MaxSize, tt.maxSize)
+				}
+				return
+			}
+			if !errors.Is(err, g.ErrAccessDenied) {
+				t.Errorf("err = %v, want ErrAccessDenied", err)
+			}
+		})
+	}
+}
+
+func TestCheckDefaultAllow(t *testing.T) {
+	e, err := New(config.PolicyConfig{
+		Rules: []config.PolicyRule{{Effect: EffectDeny, Access: []string{g.AccessWrite}, Paths: []string{"/etc/"}}},
+	})
+	if err != nil {
+		t.Fatalf("New 失败: %v", err)
+	}
+	e.resolver.resolve = fakeResolve(nil)
+
+	tests := []struct {
+		path   string
+		access string
+		want   bool
+	}{
+		{"/etc/passwd", g.AccessRead, true},
+		{"/etc/passwd", g.AccessWrite, false},
+		{"/tmp/a", g.AccessWrite, true},
+	}
+	for _, tt := range tests {
+		_, err := e.Check(context.Background(), g.AccessRequest{Server: "web", Path: tt.path, Access: tt.access})
+		if (err == nil) != tt.want {
+			t.Errorf("Check(%s %s) err = %v, want allowed %t", tt.access, tt.path, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package policy
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	resolveTTL     = time.Minute     // 解析结果的缓存时间
+	resolveTimeout = 2 * time.Second // 单次解析的超时时间
+
+	maxResolveEntries = 1024
+)
+
+// resolver 带缓存的主机名解析，用于按IP比较主机组成员
+type resolver struct {
+	mu      sync.Mutex
+	entries map[string]resolveEntry
+	resolve func(ctx context.Context, host string) ([]net.IP, error)
+}
+
+type resolveEntry struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+func newResolver() *resolver {
+	return &resolver{
+		entries: make(map[string]resolveEntry),
+		resolve: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+	}
+}
+
+// lookup 返回主机的IP地址，host 本身是IP时直接返回；解析失败时返回空，按未匹配处理
+func (r *resolver) lookup(ctx context.Context, host string) []net.IP {
+	if host == "" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}
+	}
+
+	r.mu.Lock()
+	entry, ok := r.entries[host]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.ips
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	ips, _ := r.resolve(ctx, host)
+
+	r.mu.Lock()
+	if len(r.entries) >= maxResolveEntries { // 避免缓存无限增长
+		clear(r.entries)
+	}
+	r.entries[host] = resolveEntry{ips: ips, expires: time.Now().Add(resolveTTL)}
+	r.mu.Unlock()
+	return ips
+}
@@ -2,11 +2,12 @@
 // versions:
 // 	protoc-gen-go v1.36.6
 // 	protoc        v3.19.4
-// source: proto/filetransfer.proto
+// source: filetransfer.proto
 
 package proto
 
 import (
+	status "google.golang.org/genproto/googleapis/rpc/status"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -21,6 +22,61 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type TransferEvent_Type int32
+
+const (
+	TransferEvent_TYPE_UNSPECIFIED TransferEvent_Type = 0
+	TransferEvent_CREATED          TransferEvent_Type = 1 // 任务已创建，源文件已打开，total 为源文件大小
+	TransferEvent_PROGRESS         TransferEvent_Type = 2 // 传输进度
+	TransferEvent_COMPLETED        TransferEvent_Type = 3 // 传输完成
+	TransferEvent_FAILED           TransferEvent_Type = 4 // 传输失败，error 中为错误码及详情
+)
+
+// Enum value maps for TransferEvent_Type.
+var (
+	TransferEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "CREATED",
+		2: "PROGRESS",
+		3: "COMPLETED",
+		4: "FAILED",
+	}
+	TransferEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"CREATED":          1,
+		"PROGRESS":         2,
+		"COMPLETED":        3,
+		"FAILED":           4,
+	}
+)
+
+func (x TransferEvent_Type) Enum() *TransferEvent_Type {
+	p := new(TransferEvent_Type)
+	*p = x
+	return p
+}
+
+func (x TransferEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TransferEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_filetransfer_proto_enumTypes[0].Descriptor()
+}
+
+func (TransferEvent_Type) Type() protoreflect.EnumType {
+	return &file_filetransfer_proto_enumTypes[0]
+}
+
+func (x TransferEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TransferEvent_Type.Descriptor instead.
+func (TransferEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{10, 0}
+}
+
 type CommonUploadRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
@@ -28,13 +84,15 @@ type CommonUploadRequest struct {
 	User          string                 `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
 	Auth          string                 `protobuf:"bytes,4,opt,name=auth,proto3" json:"auth,omitempty"`
 	FileData      []byte                 `protobuf:"bytes,5,opt,name=file_data,json=fileData,proto3" json:"file_data,omitempty"` // 上传的文件二进制数据
+	Proxy         string                 `protobuf:"bytes,6,opt,name=proxy,proto3" json:"proxy,omitempty"`                       // 可选，连接服务器使用的代理，"direct" 表示直连
+	HostId        string                 `protobuf:"bytes,7,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"`       // 可选，主机清单中的服务器，指定后无需携带 server/user/auth（需携带Token）
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CommonUploadRequest) Reset() {
 	*x = CommonUploadRequest{}
-	mi := &file_pb_filetransfer_proto_msgTypes[0]
+	mi := &file_filetransfer_proto_msgTypes[0]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -46,7 +104,7 @@ func (x *CommonUploadRequest) String() string {
 func (*CommonUploadRequest) ProtoMessage() {}
 
 func (x *CommonUploadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_filetransfer_proto_msgTypes[0]
+	mi := &file_filetransfer_proto_msgTypes[0]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -59,7 +117,7 @@ func (x *CommonUploadRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CommonUploadRequest.ProtoReflect.Descriptor instead.
 func (*CommonUploadRequest) Descriptor() ([]byte, []int) {
-	return file_pb_filetransfer_proto_rawDescGZIP(), []int{0}
+	return file_filetransfer_proto_rawDescGZIP(), []int{0}
 }
 
 func (x *CommonUploadRequest) GetServer() string {
@@ -97,6 +155,20 @@ func (x *CommonUploadRequest) GetFileData() []byte {
 	return nil
 }
 
+func (x *CommonUploadRequest) GetProxy() string {
+	if x != nil {
+		return x.Proxy
+	}
+	return ""
+}
+
+func (x *CommonUploadRequest) GetHostId() string {
+	if x != nil {
+		return x.HostId
+	}
+	return ""
+}
+
 type CommonUploadResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
@@ -106,7 +178,7 @@ type CommonUploadResponse struct {
 
 func (x *CommonUploadResponse) Reset() {
 	*x = CommonUploadResponse{}
-	mi := &file_pb_filetransfer_proto_msgTypes[1]
+	mi := &file_filetransfer_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -118,7 +190,7 @@ func (x *CommonUploadResponse) String() string {
 func (*CommonUploadResponse) ProtoMessage() {}
 
 func (x *CommonUploadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_filetransfer_proto_msgTypes[1]
+	mi := &file_filetransfer_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -131,7 +203,7 @@ func (x *CommonUploadResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CommonUploadResponse.ProtoReflect.Descriptor instead.
 func (*CommonUploadResponse) Descriptor() ([]byte, []int) {
-	return file_pb_filetransfer_proto_rawDescGZIP(), []int{1}
+	return file_filetransfer_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *CommonUploadResponse) GetMessage() string {
@@ -141,31 +213,36 @@ func (x *CommonUploadResponse) GetMessage() string {
 	return ""
 }
 
-type CommonDownloadRequest struct {
+type UploadMetadata struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
 	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
 	User          string                 `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
 	Auth          string                 `protobuf:"bytes,4,opt,name=auth,proto3" json:"auth,omitempty"`
+	Proxy         string                 `protobuf:"bytes,5,opt,name=proxy,proto3" json:"proxy,omitempty"`                 // 可选，连接服务器使用的代理，"direct" 表示直连
+	HostId        string                 `protobuf:"bytes,6,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"` // 可选，主机清单中的服务器，指定后无需携带 server/user/auth
+	Mode          uint32                 `protobuf:"varint,7,opt,name=mode,proto3" json:"mode,omitempty"`                  // 可选，文件权限（如 0644），为 0 时使用 0644
+	Size          int64                  `protobuf:"varint,8,opt,name=size,proto3" json:"size,omitempty"`                  // 可选，文件大小，大于 0 时用于访问策略和配额检查，并校验实际收到的字节数
+	Sha256        string                 `protobuf:"bytes,9,opt,name=sha256,proto3" json:"sha256,omitempty"`               // 可选，文件内容的 SHA-256（十六进制），上传完成后校验
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CommonDownloadRequest) Reset() {
-	*x = CommonDownloadRequest{}
-	mi := &file_pb_filetransfer_proto_msgTypes[2]
+func (x *UploadMetadata) Reset() {
+	*x = UploadMetadata{}
+	mi := &file_filetransfer_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CommonDownloadRequest) String() string {
+func (x *UploadMetadata) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CommonDownloadRequest) ProtoMessage() {}
+func (*UploadMetadata) ProtoMessage() {}
 
-func (x *CommonDownloadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_filetransfer_proto_msgTypes[2]
+func (x *UploadMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -176,61 +253,100 @@ func (x *CommonDownloadRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CommonDownloadRequest.ProtoReflect.Descriptor instead.
-func (*CommonDownloadRequest) Descriptor() ([]byte, []int) {
-	return file_pb_filetransfer_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use UploadMetadata.ProtoReflect.Descriptor instead.
+func (*UploadMetadata) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *CommonDownloadRequest) GetServer() string {
+func (x *UploadMetadata) GetServer() string {
 	if x != nil {
 		return x.Server
 	}
 	return ""
 }
 
-func (x *CommonDownloadRequest) GetPath() string {
+func (x *UploadMetadata) GetPath() string {
 	if x != nil {
 		return x.Path
 	}
 	return ""
 }
 
-func (x *CommonDownloadRequest) GetUser() string {
+func (x *UploadMetadata) GetUser() string {
 	if x != nil {
 		return x.User
 	}
 	return ""
 }
 
-func (x *CommonDownloadRequest) GetAuth() string {
+func (x *UploadMetadata) GetAuth() string {
 	if x != nil {
 		return x.Auth
 	}
 	return ""
 }
 
-type FileChunk struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+func (x *UploadMetadata) GetProxy() string {
+	if x != nil {
+		return x.Proxy
+	}
+	return ""
+}
+
+func (x *UploadMetadata) GetHostId() string {
+	if x != nil {
+		return x.HostId
+	}
+	return ""
+}
+
+func (x *UploadMetadata) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *UploadMetadata) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *UploadMetadata) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type UploadStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*UploadStreamRequest_Metadata
+	//	*UploadStreamRequest_Chunk
+	Payload       isUploadStreamRequest_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FileChunk) Reset() {
-	*x = FileChunk{}
-	mi := &file_pb_filetransfer_proto_msgTypes[3]
+func (x *UploadStreamRequest) Reset() {
+	*x = UploadStreamRequest{}
+	mi := &file_filetransfer_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FileChunk) String() string {
+func (x *UploadStreamRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FileChunk) ProtoMessage() {}
+func (*UploadStreamRequest) ProtoMessage() {}
 
-func (x *FileChunk) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_filetransfer_proto_msgTypes[3]
+func (x *UploadStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -241,47 +357,76 @@ func (x *FileChunk) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
-func (*FileChunk) Descriptor() ([]byte, []int) {
-	return file_pb_filetransfer_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use UploadStreamRequest.ProtoReflect.Descriptor instead.
+func (*UploadStreamRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *FileChunk) GetContent() []byte {
+func (x *UploadStreamRequest) GetPayload() isUploadStreamRequest_Payload {
 	if x != nil {
-		return x.Content
+		return x.Payload
 	}
 	return nil
 }
 
-type TransferBetweenRequest struct {
+func (x *UploadStreamRequest) GetMetadata() *UploadMetadata {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadStreamRequest_Metadata); ok {
+			return x.Metadata
+		}
+	}
+	return nil
+}
+
+func (x *UploadStreamRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadStreamRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isUploadStreamRequest_Payload interface {
+	isUploadStreamRequest_Payload()
+}
+
+type UploadStreamRequest_Metadata struct {
+	Metadata *UploadMetadata `protobuf:"bytes,1,opt,name=metadata,proto3,oneof"` // 第一条消息
+}
+
+type UploadStreamRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"` // 之后的消息，按顺序拼接为文件内容
+}
+
+func (*UploadStreamRequest_Metadata) isUploadStreamRequest_Payload() {}
+
+func (*UploadStreamRequest_Chunk) isUploadStreamRequest_Payload() {}
+
+type UploadStreamResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SourceServer  string                 `protobuf:"bytes,1,opt,name=source_server,json=sourceServer,proto3" json:"source_server,omitempty"`
-	TargetServer  string                 `protobuf:"bytes,2,opt,name=target_server,json=targetServer,proto3" json:"target_server,omitempty"`
-	SourcePath    string                 `protobuf:"bytes,3,opt,name=source_path,json=sourcePath,proto3" json:"source_path,omitempty"`
-	TargetPath    string                 `protobuf:"bytes,4,opt,name=target_path,json=targetPath,proto3" json:"target_path,omitempty"`
-	SourceUser    string                 `protobuf:"bytes,5,opt,name=source_user,json=sourceUser,proto3" json:"source_user,omitempty"`
-	TargetUser    string                 `protobuf:"bytes,6,opt,name=target_user,json=targetUser,proto3" json:"target_user,omitempty"`
-	SourceAuth    string                 `protobuf:"bytes,7,opt,name=source_auth,json=sourceAuth,proto3" json:"source_auth,omitempty"`
-	TargetAuth    string                 `protobuf:"bytes,8,opt,name=target_auth,json=targetAuth,proto3" json:"target_auth,omitempty"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`    // 实际写入的字节数
+	Sha256        string                 `protobuf:"bytes,3,opt,name=sha256,proto3" json:"sha256,omitempty"` // 实际写入内容的 SHA-256（十六进制）
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TransferBetweenRequest) Reset() {
-	*x = TransferBetweenRequest{}
-	mi := &file_pb_filetransfer_proto_msgTypes[4]
+func (x *UploadStreamResponse) Reset() {
+	*x = UploadStreamResponse{}
+	mi := &file_filetransfer_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TransferBetweenRequest) String() string {
+func (x *UploadStreamResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TransferBetweenRequest) ProtoMessage() {}
+func (*UploadStreamResponse) ProtoMessage() {}
 
-func (x *TransferBetweenRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_filetransfer_proto_msgTypes[4]
+func (x *UploadStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -292,89 +437,158 @@ func (x *TransferBetweenRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TransferBetweenRequest.ProtoReflect.Descriptor instead.
-func (*TransferBetweenRequest) Descriptor() ([]byte, []int) {
-	return file_pb_filetransfer_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use UploadStreamResponse.ProtoReflect.Descriptor instead.
+func (*UploadStreamResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *TransferBetweenRequest) GetSourceServer() string {
+func (x *UploadStreamResponse) GetMessage() string {
 	if x != nil {
-		return x.SourceServer
+		return x.Message
 	}
 	return ""
 }
 
-func (x *TransferBetweenRequest) GetTargetServer() string {
+func (x *UploadStreamResponse) GetSize() int64 {
 	if x != nil {
-		return x.TargetServer
+		return x.Size
+	}
+	return 0
+}
+
+func (x *UploadStreamResponse) GetSha256() string {
+	if x != nil {
+		return x.Sha256
 	}
 	return ""
 }
 
-func (x *TransferBetweenRequest) GetSourcePath() string {
+type CommonDownloadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	User          string                 `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	Auth          string                 `protobuf:"bytes,4,opt,name=auth,proto3" json:"auth,omitempty"`
+	Proxy         string                 `protobuf:"bytes,5,opt,name=proxy,proto3" json:"proxy,omitempty"`                 // 可选，连接服务器使用的代理，"direct" 表示直连
+	HostId        string                 `protobuf:"bytes,6,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"` // 可选，主机清单中的服务器，指定后无需携带 server/user/auth（需携带Token）
+	Offset        int64                  `protobuf:"varint,7,opt,name=offset,proto3" json:"offset,omitempty"`              // 可选，从该偏移开始下载，用于断点续传
+	Length        int64                  `protobuf:"varint,8,opt,name=length,proto3" json:"length,omitempty"`              // 可选，最多下载的字节数，为 0 时下载到文件末尾
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommonDownloadRequest) Reset() {
+	*x = CommonDownloadRequest{}
+	mi := &file_filetransfer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommonDownloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommonDownloadRequest) ProtoMessage() {}
+
+func (x *CommonDownloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[5]
 	if x != nil {
-		return x.SourcePath
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommonDownloadRequest.ProtoReflect.Descriptor instead.
+func (*CommonDownloadRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CommonDownloadRequest) GetServer() string {
+	if x != nil {
+		return x.Server
 	}
 	return ""
 }
 
-func (x *TransferBetweenRequest) GetTargetPath() string {
+func (x *CommonDownloadRequest) GetPath() string {
 	if x != nil {
-		return x.TargetPath
+		return x.Path
 	}
 	return ""
 }
 
-func (x *TransferBetweenRequest) GetSourceUser() string {
+func (x *CommonDownloadRequest) GetUser() string {
 	if x != nil {
-		return x.SourceUser
+		return x.User
 	}
 	return ""
 }
 
-func (x *TransferBetweenRequest) GetTargetUser() string {
+func (x *CommonDownloadRequest) GetAuth() string {
 	if x != nil {
-		return x.TargetUser
+		return x.Auth
 	}
 	return ""
 }
 
-func (x *TransferBetweenRequest) GetSourceAuth() string {
+func (x *CommonDownloadRequest) GetProxy() string {
 	if x != nil {
-		return x.SourceAuth
+		return x.Proxy
 	}
 	return ""
 }
 
-func (x *TransferBetweenRequest) GetTargetAuth() string {
+func (x *CommonDownloadRequest) GetHostId() string {
 	if x != nil {
-		return x.TargetAuth
+		return x.HostId
 	}
 	return ""
 }
 
-type TransferResponse struct {
+func (x *CommonDownloadRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *CommonDownloadRequest) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+type FileHeader struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Size          int64                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`     // 文件总大小
+	Mode          uint32                 `protobuf:"varint,2,opt,name=mode,proto3" json:"mode,omitempty"`     // 文件权限
+	Mtime         int64                  `protobuf:"varint,3,opt,name=mtime,proto3" json:"mtime,omitempty"`   // 最后修改时间（Unix 秒）
+	Offset        int64                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"` // 本次下载的起始偏移
+	Length        int64                  `protobuf:"varint,5,opt,name=length,proto3" json:"length,omitempty"` // 本次下载将发送的字节数
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TransferResponse) Reset() {
-	*x = TransferResponse{}
-	mi := &file_pb_filetransfer_proto_msgTypes[5]
+func (x *FileHeader) Reset() {
+	*x = FileHeader{}
+	mi := &file_filetransfer_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TransferResponse) String() string {
+func (x *FileHeader) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TransferResponse) ProtoMessage() {}
+func (*FileHeader) ProtoMessage() {}
 
-func (x *TransferResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_filetransfer_proto_msgTypes[5]
+func (x *FileHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -385,115 +599,2336 @@ func (x *TransferResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TransferResponse.ProtoReflect.Descriptor instead.
-func (*TransferResponse) Descriptor() ([]byte, []int) {
-	return file_pb_filetransfer_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use FileHeader.ProtoReflect.Descriptor instead.
+func (*FileHeader) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *TransferResponse) GetMessage() string {
+func (x *FileHeader) GetSize() int64 {
 	if x != nil {
-		return x.Message
+		return x.Size
 	}
-	return ""
+	return 0
 }
 
-var File_pb_filetransfer_proto protoreflect.FileDescriptor
+func (x *FileHeader) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
 
-const file_pb_filetransfer_proto_rawDesc = "" +
-	"\n" +
-	"\x15pb/filetransfer.proto\x12\ffiletransfer\"\x86\x01\n" +
-	"\x13CommonUploadRequest\x12\x16\n" +
-	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
-	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
-	"\x04user\x18\x03 \x01(\tR\x04user\x12\x12\n" +
-	"\x04auth\x18\x04 \x01(\tR\x04auth\x12\x1b\n" +
-	"\tfile_data\x18\x05 \x01(\fR\bfileData\"0\n" +
-	"\x14CommonUploadResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\"k\n" +
-	"\x15CommonDownloadRequest\x12\x16\n" +
-	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
-	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
-	"\x04user\x18\x03 \x01(\tR\x04user\x12\x12\n" +
-	"\x04auth\x18\x04 \x01(\tR\x04auth\"%\n" +
-	"\tFileChunk\x12\x18\n" +
-	"\acontent\x18\x01 \x01(\fR\acontent\"\xa8\x02\n" +
-	"\x16TransferBetweenRequest\x12#\n" +
-	"\rsource_server\x18\x01 \x01(\tR\fsourceServer\x12#\n" +
-	"\rtarget_server\x18\x02 \x01(\tR\ftargetServer\x12\x1f\n" +
-	"\vsource_path\x18\x03 \x01(\tR\n" +
-	"sourcePath\x12\x1f\n" +
-	"\vtarget_path\x18\x04 \x01(\tR\n" +
-	"targetPath\x12\x1f\n" +
-	"\vsource_user\x18\x05 \x01(\tR\n" +
-	"sourceUser\x12\x1f\n" +
-	"\vtarget_user\x18\x06 \x01(\tR\n" +
-	"targetUser\x12\x1f\n" +
-	"\vsource_auth\x18\a \x01(\tR\n" +
-	"sourceAuth\x12\x1f\n" +
-	"\vtarget_auth\x18\b \x01(\tR\n" +
-	"targetAuth\",\n" +
-	"\x10TransferResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage2\xa1\x02\n" +
-	"\x13FileTransferService\x12U\n" +
-	"\fCommonUpload\x12!.filetransfer.CommonUploadRequest\x1a\".filetransfer.CommonUploadResponse\x12P\n" +
-	"\x0eCommonDownload\x12#.filetransfer.CommonDownloadRequest\x1a\x17.filetransfer.FileChunk0\x01\x12a\n" +
-	"\x19TransferBetweenTwoServers\x12$.filetransfer.TransferBetweenRequest\x1a\x1e.filetransfer.TransferResponseB\x12Z\x10file-transfer/pbb\x06proto3"
+func (x *FileHeader) GetMtime() int64 {
+	if x != nil {
+		return x.Mtime
+	}
+	return 0
+}
 
-var (
-	file_pb_filetransfer_proto_rawDescOnce sync.Once
-	file_pb_filetransfer_proto_rawDescData []byte
-)
+func (x *FileHeader) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
 
-func file_pb_filetransfer_proto_rawDescGZIP() []byte {
-	file_pb_filetransfer_proto_rawDescOnce.Do(func() {
-		file_pb_filetransfer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pb_filetransfer_proto_rawDesc), len(file_pb_filetransfer_proto_rawDesc)))
-	})
-	return file_pb_filetransfer_proto_rawDescData
-}
-
-var file_pb_filetransfer_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
-var file_pb_filetransfer_proto_goTypes = []any{
-	(*CommonUploadRequest)(nil),    // 0: filetransfer.CommonUploadRequest
-	(*CommonUploadResponse)(nil),   // 1: filetransfer.CommonUploadResponse
-	(*CommonDownloadRequest)(nil),  // 2: filetransfer.CommonDownloadRequest
-	(*FileChunk)(nil),              // 3: filetransfer.FileChunk
-	(*TransferBetweenRequest)(nil), // 4: filetransfer.TransferBetweenRequest
-	(*TransferResponse)(nil),       // 5: filetransfer.TransferResponse
-}
-var file_pb_filetransfer_proto_depIdxs = []int32{
-	0, // 0: filetransfer.FileTransferService.CommonUpload:input_type -> filetransfer.CommonUploadRequest
-	2, // 1: filetransfer.FileTransferService.CommonDownload:input_type -> filetransfer.CommonDownloadRequest
-	4, // 2: filetransfer.FileTransferService.TransferBetweenTwoServers:input_type -> filetransfer.TransferBetweenRequest
-	1, // 3: filetransfer.FileTransferService.CommonUpload:output_type -> filetransfer.CommonUploadResponse
-	3, // 4: filetransfer.FileTransferService.CommonDownload:output_type -> filetransfer.FileChunk
-	5, // 5: filetransfer.FileTransferService.TransferBetweenTwoServers:output_type -> filetransfer.TransferResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
-}
-
-func init() { file_pb_filetransfer_proto_init() }
-func file_pb_filetransfer_proto_init() {
-	if File_pb_filetransfer_proto != nil {
-		return
+func (x *FileHeader) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+type FileChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Header        *FileHeader            `protobuf:"bytes,2,opt,name=header,proto3" json:"header,omitempty"` // 只在第一条消息中携带，此时 content 为空
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileChunk) Reset() {
+	*x = FileChunk{}
+	mi := &file_filetransfer_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChunk) ProtoMessage() {}
+
+func (x *FileChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
+func (*FileChunk) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FileChunk) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *FileChunk) GetHeader() *FileHeader {
+	if x != nil {
+		return x.Header
+	}
+	return nil
+}
+
+type TransferBetweenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SourceServer  string                 `protobuf:"bytes,1,opt,name=source_server,json=sourceServer,proto3" json:"source_server,omitempty"`
+	TargetServer  string                 `protobuf:"bytes,2,opt,name=target_server,json=targetServer,proto3" json:"target_server,omitempty"`
+	SourcePath    string                 `protobuf:"bytes,3,opt,name=source_path,json=sourcePath,proto3" json:"source_path,omitempty"`
+	TargetPath    string                 `protobuf:"bytes,4,opt,name=target_path,json=targetPath,proto3" json:"target_path,omitempty"`
+	SourceUser    string                 `protobuf:"bytes,5,opt,name=source_user,json=sourceUser,proto3" json:"source_user,omitempty"`
+	TargetUser    string                 `protobuf:"bytes,6,opt,name=target_user,json=targetUser,proto3" json:"target_user,omitempty"`
+	SourceAuth    string                 `protobuf:"bytes,7,opt,name=source_auth,json=sourceAuth,proto3" json:"source_auth,omitempty"`
+	TargetAuth    string                 `protobuf:"bytes,8,opt,name=target_auth,json=targetAuth,proto3" json:"target_auth,omitempty"`
+	SourceProxy   string                 `protobuf:"bytes,9,opt,name=source_proxy,json=sourceProxy,proto3" json:"source_proxy,omitempty"`       // 可选，连接源服务器使用的代理
+	TargetProxy   string                 `protobuf:"bytes,10,opt,name=target_proxy,json=targetProxy,proto3" json:"target_proxy,omitempty"`      // 可选，连接目标服务器使用的代理
+	SourceHostId  string                 `protobuf:"bytes,11,opt,name=source_host_id,json=sourceHostId,proto3" json:"source_host_id,omitempty"` // 可选，主机清单中的源服务器（需携带Token）
+	TargetHostId  string                 `protobuf:"bytes,12,opt,name=target_host_id,json=targetHostId,proto3" json:"target_host_id,omitempty"` // 可选，主机清单中的目标服务器（需携带Token）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferBetweenRequest) Reset() {
+	*x = TransferBetweenRequest{}
+	mi := &file_filetransfer_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferBetweenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferBetweenRequest) ProtoMessage() {}
+
+func (x *TransferBetweenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferBetweenRequest.ProtoReflect.Descriptor instead.
+func (*TransferBetweenRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TransferBetweenRequest) GetSourceServer() string {
+	if x != nil {
+		return x.SourceServer
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetTargetServer() string {
+	if x != nil {
+		return x.TargetServer
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetSourcePath() string {
+	if x != nil {
+		return x.SourcePath
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetTargetPath() string {
+	if x != nil {
+		return x.TargetPath
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetSourceUser() string {
+	if x != nil {
+		return x.SourceUser
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetTargetUser() string {
+	if x != nil {
+		return x.TargetUser
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetSourceAuth() string {
+	if x != nil {
+		return x.SourceAuth
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetTargetAuth() string {
+	if x != nil {
+		return x.TargetAuth
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetSourceProxy() string {
+	if x != nil {
+		return x.SourceProxy
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetTargetProxy() string {
+	if x != nil {
+		return x.TargetProxy
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetSourceHostId() string {
+	if x != nil {
+		return x.SourceHostId
+	}
+	return ""
+}
+
+func (x *TransferBetweenRequest) GetTargetHostId() string {
+	if x != nil {
+		return x.TargetHostId
+	}
+	return ""
+}
+
+type TransferResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferResponse) Reset() {
+	*x = TransferResponse{}
+	mi := &file_filetransfer_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferResponse) ProtoMessage() {}
+
+func (x *TransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferResponse.ProtoReflect.Descriptor instead.
+func (*TransferResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TransferResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type TransferEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          TransferEvent_Type     `protobuf:"varint,1,opt,name=type,proto3,enum=filetransfer.TransferEvent_Type" json:"type,omitempty"`
+	TaskId        string                 `protobuf:"bytes,2,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Bytes         int64                  `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`         // 已传输的字节数
+	Total         int64                  `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`         // 源文件大小
+	Rate          float64                `protobuf:"fixed64,5,opt,name=rate,proto3" json:"rate,omitempty"`          // 传输速率（字节/秒），完成时为平均速率
+	Timestamp     int64                  `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // 事件时间（Unix 毫秒）
+	Error         *status.Status         `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`          // 失败原因，code 为 gRPC 状态码（如 NotFound、PermissionDenied、Unavailable）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferEvent) Reset() {
+	*x = TransferEvent{}
+	mi := &file_filetransfer_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferEvent) ProtoMessage() {}
+
+func (x *TransferEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferEvent.ProtoReflect.Descriptor instead.
+func (*TransferEvent) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TransferEvent) GetType() TransferEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return TransferEvent_TYPE_UNSPECIFIED
+}
+
+func (x *TransferEvent) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *TransferEvent) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *TransferEvent) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *TransferEvent) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *TransferEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *TransferEvent) GetError() *status.Status {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+// RemoteHost 要操作的服务器，指定 host_id 时从主机清单读取地址和凭据
+type RemoteHost struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Auth          string                 `protobuf:"bytes,3,opt,name=auth,proto3" json:"auth,omitempty"`
+	Proxy         string                 `protobuf:"bytes,4,opt,name=proxy,proto3" json:"proxy,omitempty"`
+	HostId        string                 `protobuf:"bytes,5,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoteHost) Reset() {
+	*x = RemoteHost{}
+	mi := &file_filetransfer_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoteHost) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteHost) ProtoMessage() {}
+
+func (x *RemoteHost) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteHost.ProtoReflect.Descriptor instead.
+func (*RemoteHost) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RemoteHost) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *RemoteHost) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *RemoteHost) GetAuth() string {
+	if x != nil {
+		return x.Auth
+	}
+	return ""
+}
+
+func (x *RemoteHost) GetProxy() string {
+	if x != nil {
+		return x.Proxy
+	}
+	return ""
+}
+
+func (x *RemoteHost) GetHostId() string {
+	if x != nil {
+		return x.HostId
+	}
+	return ""
+}
+
+type RemoteFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Mode          string                 `protobuf:"bytes,4,opt,name=mode,proto3" json:"mode,omitempty"`  // 如 -rw-r--r--
+	Perm          uint32                 `protobuf:"varint,5,opt,name=perm,proto3" json:"perm,omitempty"` // 权限位，如 0644
+	IsDir         bool                   `protobuf:"varint,6,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	IsLink        bool                   `protobuf:"varint,7,opt,name=is_link,json=isLink,proto3" json:"is_link,omitempty"`
+	Uid           uint32                 `protobuf:"varint,8,opt,name=uid,proto3" json:"uid,omitempty"`
+	Gid           uint32                 `protobuf:"varint,9,opt,name=gid,proto3" json:"gid,omitempty"`
+	Mtime         int64                  `protobuf:"varint,10,opt,name=mtime,proto3" json:"mtime,omitempty"` // 最后修改时间（Unix 秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoteFile) Reset() {
+	*x = RemoteFile{}
+	mi := &file_filetransfer_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoteFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteFile) ProtoMessage() {}
+
+func (x *RemoteFile) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteFile.ProtoReflect.Descriptor instead.
+func (*RemoteFile) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RemoteFile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RemoteFile) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RemoteFile) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *RemoteFile) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *RemoteFile) GetPerm() uint32 {
+	if x != nil {
+		return x.Perm
+	}
+	return 0
+}
+
+func (x *RemoteFile) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *RemoteFile) GetIsLink() bool {
+	if x != nil {
+		return x.IsLink
+	}
+	return false
+}
+
+func (x *RemoteFile) GetUid() uint32 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *RemoteFile) GetGid() uint32 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+func (x *RemoteFile) GetMtime() int64 {
+	if x != nil {
+		return x.Mtime
+	}
+	return 0
+}
+
+type ListDirRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          *RemoteHost            `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`                // 默认 100，最大 1000
+	SortBy        string                 `protobuf:"bytes,5,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"` // name / size / mtime，默认 name
+	Desc          bool                   `protobuf:"varint,6,opt,name=desc,proto3" json:"desc,omitempty"`
+	DirsFirst     bool                   `protobuf:"varint,7,opt,name=dirs_first,json=dirsFirst,proto3" json:"dirs_first,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDirRequest) Reset() {
+	*x = ListDirRequest{}
+	mi := &file_filetransfer_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDirRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDirRequest) ProtoMessage() {}
+
+func (x *ListDirRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDirRequest.ProtoReflect.Descriptor instead.
+func (*ListDirRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListDirRequest) GetHost() *RemoteHost {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *ListDirRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ListDirRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListDirRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListDirRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListDirRequest) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+func (x *ListDirRequest) GetDirsFirst() bool {
+	if x != nil {
+		return x.DirsFirst
+	}
+	return false
+}
+
+type ListDirResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Entries       []*RemoteFile          `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"` // 目录中的条目总数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDirResponse) Reset() {
+	*x = ListDirResponse{}
+	mi := &file_filetransfer_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDirResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDirResponse) ProtoMessage() {}
+
+func (x *ListDirResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDirResponse.ProtoReflect.Descriptor instead.
+func (*ListDirResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListDirResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ListDirResponse) GetEntries() []*RemoteFile {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *ListDirResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type StatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          *RemoteHost            `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatRequest) Reset() {
+	*x = StatRequest{}
+	mi := &file_filetransfer_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatRequest) ProtoMessage() {}
+
+func (x *StatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatRequest.ProtoReflect.Descriptor instead.
+func (*StatRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StatRequest) GetHost() *RemoteHost {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *StatRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type StatResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          *RemoteFile            `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatResponse) Reset() {
+	*x = StatResponse{}
+	mi := &file_filetransfer_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatResponse) ProtoMessage() {}
+
+func (x *StatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatResponse.ProtoReflect.Descriptor instead.
+func (*StatResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StatResponse) GetFile() *RemoteFile {
+	if x != nil {
+		return x.File
+	}
+	return nil
+}
+
+type MkdirRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          *RemoteHost            `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Parents       bool                   `protobuf:"varint,3,opt,name=parents,proto3" json:"parents,omitempty"` // 同时创建不存在的上级目录
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MkdirRequest) Reset() {
+	*x = MkdirRequest{}
+	mi := &file_filetransfer_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MkdirRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MkdirRequest) ProtoMessage() {}
+
+func (x *MkdirRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MkdirRequest.ProtoReflect.Descriptor instead.
+func (*MkdirRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *MkdirRequest) GetHost() *RemoteHost {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *MkdirRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *MkdirRequest) GetParents() bool {
+	if x != nil {
+		return x.Parents
+	}
+	return false
+}
+
+type RemoveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          *RemoteHost            `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Recursive     bool                   `protobuf:"varint,3,opt,name=recursive,proto3" json:"recursive,omitempty"` // 删除整个目录
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveRequest) Reset() {
+	*x = RemoveRequest{}
+	mi := &file_filetransfer_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveRequest) ProtoMessage() {}
+
+func (x *RemoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveRequest.ProtoReflect.Descriptor instead.
+func (*RemoveRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RemoveRequest) GetHost() *RemoteHost {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *RemoveRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RemoveRequest) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+type RenameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          *RemoteHost            `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	NewPath       string                 `protobuf:"bytes,3,opt,name=new_path,json=newPath,proto3" json:"new_path,omitempty"`
+	Overwrite     bool                   `protobuf:"varint,4,opt,name=overwrite,proto3" json:"overwrite,omitempty"` // 覆盖已存在的目标
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenameRequest) Reset() {
+	*x = RenameRequest{}
+	mi := &file_filetransfer_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameRequest) ProtoMessage() {}
+
+func (x *RenameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameRequest.ProtoReflect.Descriptor instead.
+func (*RenameRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RenameRequest) GetHost() *RemoteHost {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *RenameRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RenameRequest) GetNewPath() string {
+	if x != nil {
+		return x.NewPath
+	}
+	return ""
+}
+
+func (x *RenameRequest) GetOverwrite() bool {
+	if x != nil {
+		return x.Overwrite
+	}
+	return false
+}
+
+type ChmodRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          *RemoteHost            `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Mode          uint32                 `protobuf:"varint,3,opt,name=mode,proto3" json:"mode,omitempty"` // 权限位，如 0644
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChmodRequest) Reset() {
+	*x = ChmodRequest{}
+	mi := &file_filetransfer_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChmodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChmodRequest) ProtoMessage() {}
+
+func (x *ChmodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChmodRequest.ProtoReflect.Descriptor instead.
+func (*ChmodRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ChmodRequest) GetHost() *RemoteHost {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *ChmodRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ChmodRequest) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+type SymlinkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          *RemoteHost            `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`     // 要创建的链接
+	Target        string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"` // 链接指向的路径
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SymlinkRequest) Reset() {
+	*x = SymlinkRequest{}
+	mi := &file_filetransfer_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SymlinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SymlinkRequest) ProtoMessage() {}
+
+func (x *SymlinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SymlinkRequest.ProtoReflect.Descriptor instead.
+func (*SymlinkRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SymlinkRequest) GetHost() *RemoteHost {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *SymlinkRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SymlinkRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type ReadlinkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          *RemoteHost            `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadlinkRequest) Reset() {
+	*x = ReadlinkRequest{}
+	mi := &file_filetransfer_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadlinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadlinkRequest) ProtoMessage() {}
+
+func (x *ReadlinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadlinkRequest.ProtoReflect.Descriptor instead.
+func (*ReadlinkRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ReadlinkRequest) GetHost() *RemoteHost {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *ReadlinkRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ReadlinkResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadlinkResponse) Reset() {
+	*x = ReadlinkResponse{}
+	mi := &file_filetransfer_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadlinkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadlinkResponse) ProtoMessage() {}
+
+func (x *ReadlinkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadlinkResponse.ProtoReflect.Descriptor instead.
+func (*ReadlinkResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ReadlinkResponse) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type FileOpResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"` // 规范化后的路径
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileOpResponse) Reset() {
+	*x = FileOpResponse{}
+	mi := &file_filetransfer_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileOpResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileOpResponse) ProtoMessage() {}
+
+func (x *FileOpResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileOpResponse.ProtoReflect.Descriptor instead.
+func (*FileOpResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *FileOpResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *FileOpResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ListPoolConnectionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPoolConnectionsRequest) Reset() {
+	*x = ListPoolConnectionsRequest{}
+	mi := &file_filetransfer_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPoolConnectionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPoolConnectionsRequest) ProtoMessage() {}
+
+func (x *ListPoolConnectionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPoolConnectionsRequest.ProtoReflect.Descriptor instead.
+func (*ListPoolConnectionsRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{25}
+}
+
+type PoolConnection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`         // 建立时间（Unix 秒）
+	LastUsedAt    int64                  `protobuf:"varint,4,opt,name=last_used_at,json=lastUsedAt,proto3" json:"last_used_at,omitempty"`    // 最近使用时间（Unix 秒）
+	LastAliveAt   int64                  `protobuf:"varint,5,opt,name=last_alive_at,json=lastAliveAt,proto3" json:"last_alive_at,omitempty"` // 最近一次确认存活的时间（Unix 秒）
+	AgeSeconds    int64                  `protobuf:"varint,6,opt,name=age_seconds,json=ageSeconds,proto3" json:"age_seconds,omitempty"`      // 连接已存在的秒数
+	InUse         int32                  `protobuf:"varint,7,opt,name=in_use,json=inUse,proto3" json:"in_use,omitempty"`                     // 正在使用该连接的任务数
+	SftpActive    bool                   `protobuf:"varint,8,opt,name=sftp_active,json=sftpActive,proto3" json:"sftp_active,omitempty"`      // 是否已有共享的SFTP会话
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PoolConnection) Reset() {
+	*x = PoolConnection{}
+	mi := &file_filetransfer_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PoolConnection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoolConnection) ProtoMessage() {}
+
+func (x *PoolConnection) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoolConnection.ProtoReflect.Descriptor instead.
+func (*PoolConnection) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PoolConnection) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *PoolConnection) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *PoolConnection) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *PoolConnection) GetLastUsedAt() int64 {
+	if x != nil {
+		return x.LastUsedAt
+	}
+	return 0
+}
+
+func (x *PoolConnection) GetLastAliveAt() int64 {
+	if x != nil {
+		return x.LastAliveAt
+	}
+	return 0
+}
+
+func (x *PoolConnection) GetAgeSeconds() int64 {
+	if x != nil {
+		return x.AgeSeconds
+	}
+	return 0
+}
+
+func (x *PoolConnection) GetInUse() int32 {
+	if x != nil {
+		return x.InUse
+	}
+	return 0
+}
+
+func (x *PoolConnection) GetSftpActive() bool {
+	if x != nil {
+		return x.SftpActive
+	}
+	return false
+}
+
+type BreakerState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`                           // closed / open / half-open
+	Failures      int32                  `protobuf:"varint,3,opt,name=failures,proto3" json:"failures,omitempty"`                    // 连续拨号失败次数
+	OpenUntil     int64                  `protobuf:"varint,4,opt,name=open_until,json=openUntil,proto3" json:"open_until,omitempty"` // 熔断截止时间（Unix 秒）
+	LastError     string                 `protobuf:"bytes,5,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	LastFailure   int64                  `protobuf:"varint,6,opt,name=last_failure,json=lastFailure,proto3" json:"last_failure,omitempty"` // 最近一次失败时间（Unix 秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BreakerState) Reset() {
+	*x = BreakerState{}
+	mi := &file_filetransfer_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BreakerState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BreakerState) ProtoMessage() {}
+
+func (x *BreakerState) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BreakerState.ProtoReflect.Descriptor instead.
+func (*BreakerState) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *BreakerState) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *BreakerState) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *BreakerState) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *BreakerState) GetOpenUntil() int64 {
+	if x != nil {
+		return x.OpenUntil
+	}
+	return 0
+}
+
+func (x *BreakerState) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *BreakerState) GetLastFailure() int64 {
+	if x != nil {
+		return x.LastFailure
+	}
+	return 0
+}
+
+type ListPoolConnectionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Connections   []*PoolConnection      `protobuf:"bytes,1,rep,name=connections,proto3" json:"connections,omitempty"`
+	Capacity      int32                  `protobuf:"varint,2,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	Breakers      []*BreakerState        `protobuf:"bytes,3,rep,name=breakers,proto3" json:"breakers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPoolConnectionsResponse) Reset() {
+	*x = ListPoolConnectionsResponse{}
+	mi := &file_filetransfer_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPoolConnectionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPoolConnectionsResponse) ProtoMessage() {}
+
+func (x *ListPoolConnectionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPoolConnectionsResponse.ProtoReflect.Descriptor instead.
+func (*ListPoolConnectionsResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListPoolConnectionsResponse) GetConnections() []*PoolConnection {
+	if x != nil {
+		return x.Connections
+	}
+	return nil
+}
+
+func (x *ListPoolConnectionsResponse) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *ListPoolConnectionsResponse) GetBreakers() []*BreakerState {
+	if x != nil {
+		return x.Breakers
+	}
+	return nil
+}
+
+type EvictPoolConnectionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"` // 要驱逐的服务器
+	All           bool                   `protobuf:"varint,2,opt,name=all,proto3" json:"all,omitempty"`      // 为 true 时驱逐全部连接，忽略 server
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvictPoolConnectionsRequest) Reset() {
+	*x = EvictPoolConnectionsRequest{}
+	mi := &file_filetransfer_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvictPoolConnectionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvictPoolConnectionsRequest) ProtoMessage() {}
+
+func (x *EvictPoolConnectionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvictPoolConnectionsRequest.ProtoReflect.Descriptor instead.
+func (*EvictPoolConnectionsRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *EvictPoolConnectionsRequest) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *EvictPoolConnectionsRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+type EvictPoolConnectionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Evicted       int32                  `protobuf:"varint,2,opt,name=evicted,proto3" json:"evicted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvictPoolConnectionsResponse) Reset() {
+	*x = EvictPoolConnectionsResponse{}
+	mi := &file_filetransfer_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvictPoolConnectionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvictPoolConnectionsResponse) ProtoMessage() {}
+
+func (x *EvictPoolConnectionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvictPoolConnectionsResponse.ProtoReflect.Descriptor instead.
+func (*EvictPoolConnectionsResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *EvictPoolConnectionsResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *EvictPoolConnectionsResponse) GetEvicted() int32 {
+	if x != nil {
+		return x.Evicted
+	}
+	return 0
+}
+
+type WarmupHost struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Auth          string                 `protobuf:"bytes,3,opt,name=auth,proto3" json:"auth,omitempty"`
+	Proxy         string                 `protobuf:"bytes,4,opt,name=proxy,proto3" json:"proxy,omitempty"`
+	HostId        string                 `protobuf:"bytes,5,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WarmupHost) Reset() {
+	*x = WarmupHost{}
+	mi := &file_filetransfer_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WarmupHost) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WarmupHost) ProtoMessage() {}
+
+func (x *WarmupHost) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WarmupHost.ProtoReflect.Descriptor instead.
+func (*WarmupHost) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *WarmupHost) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *WarmupHost) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *WarmupHost) GetAuth() string {
+	if x != nil {
+		return x.Auth
+	}
+	return ""
+}
+
+func (x *WarmupHost) GetProxy() string {
+	if x != nil {
+		return x.Proxy
+	}
+	return ""
+}
+
+func (x *WarmupHost) GetHostId() string {
+	if x != nil {
+		return x.HostId
+	}
+	return ""
+}
+
+type WarmupPoolRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hosts         []*WarmupHost          `protobuf:"bytes,1,rep,name=hosts,proto3" json:"hosts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WarmupPoolRequest) Reset() {
+	*x = WarmupPoolRequest{}
+	mi := &file_filetransfer_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WarmupPoolRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WarmupPoolRequest) ProtoMessage() {}
+
+func (x *WarmupPoolRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WarmupPoolRequest.ProtoReflect.Descriptor instead.
+func (*WarmupPoolRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *WarmupPoolRequest) GetHosts() []*WarmupHost {
+	if x != nil {
+		return x.Hosts
+	}
+	return nil
+}
+
+type WarmupResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WarmupResult) Reset() {
+	*x = WarmupResult{}
+	mi := &file_filetransfer_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WarmupResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WarmupResult) ProtoMessage() {}
+
+func (x *WarmupResult) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WarmupResult.ProtoReflect.Descriptor instead.
+func (*WarmupResult) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *WarmupResult) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *WarmupResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *WarmupResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type WarmupPoolResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*WarmupResult        `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WarmupPoolResponse) Reset() {
+	*x = WarmupPoolResponse{}
+	mi := &file_filetransfer_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WarmupPoolResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WarmupPoolResponse) ProtoMessage() {}
+
+func (x *WarmupPoolResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WarmupPoolResponse.ProtoReflect.Descriptor instead.
+func (*WarmupPoolResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *WarmupPoolResponse) GetResults() []*WarmupResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type ResetBreakerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetBreakerRequest) Reset() {
+	*x = ResetBreakerRequest{}
+	mi := &file_filetransfer_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetBreakerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetBreakerRequest) ProtoMessage() {}
+
+func (x *ResetBreakerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetBreakerRequest.ProtoReflect.Descriptor instead.
+func (*ResetBreakerRequest) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ResetBreakerRequest) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+type ResetBreakerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Cleared       bool                   `protobuf:"varint,2,opt,name=cleared,proto3" json:"cleared,omitempty"` // 该服务器此前是否存在熔断记录
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetBreakerResponse) Reset() {
+	*x = ResetBreakerResponse{}
+	mi := &file_filetransfer_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetBreakerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetBreakerResponse) ProtoMessage() {}
+
+func (x *ResetBreakerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_filetransfer_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetBreakerResponse.ProtoReflect.Descriptor instead.
+func (*ResetBreakerResponse) Descriptor() ([]byte, []int) {
+	return file_filetransfer_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ResetBreakerResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ResetBreakerResponse) GetCleared() bool {
+	if x != nil {
+		return x.Cleared
+	}
+	return false
+}
+
+var File_filetransfer_proto protoreflect.FileDescriptor
+
+const file_filetransfer_proto_rawDesc = "" +
+	"\n" +
+	"\x12filetransfer.proto\x12\ffiletransfer\x1a\x17google/rpc/status.proto\"\xb5\x01\n" +
+	"\x13CommonUploadRequest\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04user\x18\x03 \x01(\tR\x04user\x12\x12\n" +
+	"\x04auth\x18\x04 \x01(\tR\x04auth\x12\x1b\n" +
+	"\tfile_data\x18\x05 \x01(\fR\bfileData\x12\x14\n" +
+	"\x05proxy\x18\x06 \x01(\tR\x05proxy\x12\x17\n" +
+	"\ahost_id\x18\a \x01(\tR\x06hostId\"0\n" +
+	"\x14CommonUploadResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\xd3\x01\n" +
+	"\x0eUploadMetadata\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04user\x18\x03 \x01(\tR\x04user\x12\x12\n" +
+	"\x04auth\x18\x04 \x01(\tR\x04auth\x12\x14\n" +
+	"\x05proxy\x18\x05 \x01(\tR\x05proxy\x12\x17\n" +
+	"\ahost_id\x18\x06 \x01(\tR\x06hostId\x12\x12\n" +
+	"\x04mode\x18\a \x01(\rR\x04mode\x12\x12\n" +
+	"\x04size\x18\b \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\t \x01(\tR\x06sha256\"t\n" +
+	"\x13UploadStreamRequest\x12:\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x1c.filetransfer.UploadMetadataH\x00R\bmetadata\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload\"\\\n" +
+	"\x14UploadStreamResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x03 \x01(\tR\x06sha256\"\xca\x01\n" +
+	"\x15CommonDownloadRequest\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04user\x18\x03 \x01(\tR\x04user\x12\x12\n" +
+	"\x04auth\x18\x04 \x01(\tR\x04auth\x12\x14\n" +
+	"\x05proxy\x18\x05 \x01(\tR\x05proxy\x12\x17\n" +
+	"\ahost_id\x18\x06 \x01(\tR\x06hostId\x12\x16\n" +
+	"\x06offset\x18\a \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06length\x18\b \x01(\x03R\x06length\"z\n" +
+	"\n" +
+	"FileHeader\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\rR\x04mode\x12\x14\n" +
+	"\x05mtime\x18\x03 \x01(\x03R\x05mtime\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06length\x18\x05 \x01(\x03R\x06length\"W\n" +
+	"\tFileChunk\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\fR\acontent\x120\n" +
+	"\x06header\x18\x02 \x01(\v2\x18.filetransfer.FileHeaderR\x06header\"\xba\x03\n" +
+	"\x16TransferBetweenRequest\x12#\n" +
+	"\rsource_server\x18\x01 \x01(\tR\fsourceServer\x12#\n" +
+	"\rtarget_server\x18\x02 \x01(\tR\ftargetServer\x12\x1f\n" +
+	"\vsource_path\x18\x03 \x01(\tR\n" +
+	"sourcePath\x12\x1f\n" +
+	"\vtarget_path\x18\x04 \x01(\tR\n" +
+	"targetPath\x12\x1f\n" +
+	"\vsource_user\x18\x05 \x01(\tR\n" +
+	"sourceUser\x12\x1f\n" +
+	"\vtarget_user\x18\x06 \x01(\tR\n" +
+	"targetUser\x12\x1f\n" +
+	"\vsource_auth\x18\a \x01(\tR\n" +
+	"sourceAuth\x12\x1f\n" +
+	"\vtarget_auth\x18\b \x01(\tR\n" +
+	"targetAuth\x12!\n" +
+	"\fsource_proxy\x18\t \x01(\tR\vsourceProxy\x12!\n" +
+	"\ftarget_proxy\x18\n" +
+	" \x01(\tR\vtargetProxy\x12$\n" +
+	"\x0esource_host_id\x18\v \x01(\tR\fsourceHostId\x12$\n" +
+	"\x0etarget_host_id\x18\f \x01(\tR\ftargetHostId\",\n" +
+	"\x10TransferResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\xba\x02\n" +
+	"\rTransferEvent\x124\n" +
+	"\x04type\x18\x01 \x01(\x0e2 .filetransfer.TransferEvent.TypeR\x04type\x12\x17\n" +
+	"\atask_id\x18\x02 \x01(\tR\x06taskId\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\x03R\x05bytes\x12\x14\n" +
+	"\x05total\x18\x04 \x01(\x03R\x05total\x12\x12\n" +
+	"\x04rate\x18\x05 \x01(\x01R\x04rate\x12\x1c\n" +
+	"\ttimestamp\x18\x06 \x01(\x03R\ttimestamp\x12(\n" +
+	"\x05error\x18\a \x01(\v2\x12.google.rpc.StatusR\x05error\"R\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aCREATED\x10\x01\x12\f\n" +
+	"\bPROGRESS\x10\x02\x12\r\n" +
+	"\tCOMPLETED\x10\x03\x12\n" +
+	"\n" +
+	"\x06FAILED\x10\x04\"{\n" +
+	"\n" +
+	"RemoteHost\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\x12\x12\n" +
+	"\x04auth\x18\x03 \x01(\tR\x04auth\x12\x14\n" +
+	"\x05proxy\x18\x04 \x01(\tR\x05proxy\x12\x17\n" +
+	"\ahost_id\x18\x05 \x01(\tR\x06hostId\"\xda\x01\n" +
+	"\n" +
+	"RemoteFile\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\tR\x04mode\x12\x12\n" +
+	"\x04perm\x18\x05 \x01(\rR\x04perm\x12\x15\n" +
+	"\x06is_dir\x18\x06 \x01(\bR\x05isDir\x12\x17\n" +
+	"\ais_link\x18\a \x01(\bR\x06isLink\x12\x10\n" +
+	"\x03uid\x18\b \x01(\rR\x03uid\x12\x10\n" +
+	"\x03gid\x18\t \x01(\rR\x03gid\x12\x14\n" +
+	"\x05mtime\x18\n" +
+	" \x01(\x03R\x05mtime\"\xcc\x01\n" +
+	"\x0eListDirRequest\x12,\n" +
+	"\x04host\x18\x01 \x01(\v2\x18.filetransfer.RemoteHostR\x04host\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x17\n" +
+	"\asort_by\x18\x05 \x01(\tR\x06sortBy\x12\x12\n" +
+	"\x04desc\x18\x06 \x01(\bR\x04desc\x12\x1d\n" +
+	"\n" +
+	"dirs_first\x18\a \x01(\bR\tdirsFirst\"o\n" +
+	"\x0fListDirResponse\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x122\n" +
+	"\aentries\x18\x02 \x03(\v2\x18.filetransfer.RemoteFileR\aentries\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\"O\n" +
+	"\vStatRequest\x12,\n" +
+	"\x04host\x18\x01 \x01(\v2\x18.filetransfer.RemoteHostR\x04host\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"<\n" +
+	"\fStatResponse\x12,\n" +
+	"\x04file\x18\x01 \x01(\v2\x18.filetransfer.RemoteFileR\x04file\"j\n" +
+	"\fMkdirRequest\x12,\n" +
+	"\x04host\x18\x01 \x01(\v2\x18.filetransfer.RemoteHostR\x04host\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x18\n" +
+	"\aparents\x18\x03 \x01(\bR\aparents\"o\n" +
+	"\rRemoveRequest\x12,\n" +
+	"\x04host\x18\x01 \x01(\v2\x18.filetransfer.RemoteHostR\x04host\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x1c\n" +
+	"\trecursive\x18\x03 \x01(\bR\trecursive\"\x8a\x01\n" +
+	"\rRenameRequest\x12,\n" +
+	"\x04host\x18\x01 \x01(\v2\x18.filetransfer.RemoteHostR\x04host\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x19\n" +
+	"\bnew_path\x18\x03 \x01(\tR\anewPath\x12\x1c\n" +
+	"\toverwrite\x18\x04 \x01(\bR\toverwrite\"d\n" +
+	"\fChmodRequest\x12,\n" +
+	"\x04host\x18\x01 \x01(\v2\x18.filetransfer.RemoteHostR\x04host\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\rR\x04mode\"j\n" +
+	"\x0eSymlinkRequest\x12,\n" +
+	"\x04host\x18\x01 \x01(\v2\x18.filetransfer.RemoteHostR\x04host\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\"S\n" +
+	"\x0fReadlinkRequest\x12,\n" +
+	"\x04host\x18\x01 \x01(\v2\x18.filetransfer.RemoteHostR\x04host\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"*\n" +
+	"\x10ReadlinkResponse\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\">\n" +
+	"\x0eFileOpResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"\x1c\n" +
+	"\x1aListPoolConnectionsRequest\"\xfa\x01\n" +
+	"\x0ePoolConnection\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\x12 \n" +
+	"\flast_used_at\x18\x04 \x01(\x03R\n" +
+	"lastUsedAt\x12\"\n" +
+	"\rlast_alive_at\x18\x05 \x01(\x03R\vlastAliveAt\x12\x1f\n" +
+	"\vage_seconds\x18\x06 \x01(\x03R\n" +
+	"ageSeconds\x12\x15\n" +
+	"\x06in_use\x18\a \x01(\x05R\x05inUse\x12\x1f\n" +
+	"\vsftp_active\x18\b \x01(\bR\n" +
+	"sftpActive\"\xb9\x01\n" +
+	"\fBreakerState\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x1a\n" +
+	"\bfailures\x18\x03 \x01(\x05R\bfailures\x12\x1d\n" +
+	"\n" +
+	"open_until\x18\x04 \x01(\x03R\topenUntil\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x05 \x01(\tR\tlastError\x12!\n" +
+	"\flast_failure\x18\x06 \x01(\x03R\vlastFailure\"\xb1\x01\n" +
+	"\x1bListPoolConnectionsResponse\x12>\n" +
+	"\vconnections\x18\x01 \x03(\v2\x1c.filetransfer.PoolConnectionR\vconnections\x12\x1a\n" +
+	"\bcapacity\x18\x02 \x01(\x05R\bcapacity\x126\n" +
+	"\bbreakers\x18\x03 \x03(\v2\x1a.filetransfer.BreakerStateR\bbreakers\"G\n" +
+	"\x1bEvictPoolConnectionsRequest\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x10\n" +
+	"\x03all\x18\x02 \x01(\bR\x03all\"R\n" +
+	"\x1cEvictPoolConnectionsResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
+	"\aevicted\x18\x02 \x01(\x05R\aevicted\"{\n" +
+	"\n" +
+	"WarmupHost\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\x12\x12\n" +
+	"\x04auth\x18\x03 \x01(\tR\x04auth\x12\x14\n" +
+	"\x05proxy\x18\x04 \x01(\tR\x05proxy\x12\x17\n" +
+	"\ahost_id\x18\x05 \x01(\tR\x06hostId\"C\n" +
+	"\x11WarmupPoolRequest\x12.\n" +
+	"\x05hosts\x18\x01 \x03(\v2\x18.filetransfer.WarmupHostR\x05hosts\"Z\n" +
+	"\fWarmupResult\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"J\n" +
+	"\x12WarmupPoolResponse\x124\n" +
+	"\aresults\x18\x01 \x03(\v2\x1a.filetransfer.WarmupResultR\aresults\"-\n" +
+	"\x13ResetBreakerRequest\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\"J\n" +
+	"\x14ResetBreakerResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
+	"\acleared\x18\x02 \x01(\bR\acleared2\x83\v\n" +
+	"\x13FileTransferService\x12U\n" +
+	"\fCommonUpload\x12!.filetransfer.CommonUploadRequest\x1a\".filetransfer.CommonUploadResponse\x12W\n" +
+	"\fUploadStream\x12!.filetransfer.UploadStreamRequest\x1a\".filetransfer.UploadStreamResponse(\x01\x12P\n" +
+	"\x0eCommonDownload\x12#.filetransfer.CommonDownloadRequest\x1a\x17.filetransfer.FileChunk0\x01\x12a\n" +
+	"\x19TransferBetweenTwoServers\x12$.filetransfer.TransferBetweenRequest\x1a\x1e.filetransfer.TransferResponse\x12[\n" +
+	"\x14TransferWithProgress\x12$.filetransfer.TransferBetweenRequest\x1a\x1b.filetransfer.TransferEvent0\x01\x12F\n" +
+	"\aListDir\x12\x1c.filetransfer.ListDirRequest\x1a\x1d.filetransfer.ListDirResponse\x12=\n" +
+	"\x04Stat\x12\x19.filetransfer.StatRequest\x1a\x1a.filetransfer.StatResponse\x12A\n" +
+	"\x05Mkdir\x12\x1a.filetransfer.MkdirRequest\x1a\x1c.filetransfer.FileOpResponse\x12C\n" +
+	"\x06Remove\x12\x1b.filetransfer.RemoveRequest\x1a\x1c.filetransfer.FileOpResponse\x12C\n" +
+	"\x06Rename\x12\x1b.filetransfer.RenameRequest\x1a\x1c.filetransfer.FileOpResponse\x12A\n" +
+	"\x05Chmod\x12\x1a.filetransfer.ChmodRequest\x1a\x1c.filetransfer.FileOpResponse\x12E\n" +
+	"\aSymlink\x12\x1c.filetransfer.SymlinkRequest\x1a\x1c.filetransfer.FileOpResponse\x12I\n" +
+	"\bReadlink\x12\x1d.filetransfer.ReadlinkRequest\x1a\x1e.filetransfer.ReadlinkResponse\x12j\n" +
+	"\x13ListPoolConnections\x12(.filetransfer.ListPoolConnectionsRequest\x1a).filetransfer.ListPoolConnectionsResponse\x12m\n" +
+	"\x14EvictPoolConnections\x12).filetransfer.EvictPoolConnectionsRequest\x1a*.filetransfer.EvictPoolConnectionsResponse\x12O\n" +
+	"\n" +
+	"WarmupPool\x12\x1f.filetransfer.WarmupPoolRequest\x1a .filetransfer.WarmupPoolResponse\x12U\n" +
+	"\fResetBreaker\x12!.filetransfer.ResetBreakerRequest\x1a\".filetransfer.ResetBreakerResponseB)Z'file-transfer/proto/file-transfer;protob\x06proto3"
+
+var (
+	file_filetransfer_proto_rawDescOnce sync.Once
+	file_filetransfer_proto_rawDescData []byte
+)
+
+func file_filetransfer_proto_rawDescGZIP() []byte {
+	file_filetransfer_proto_rawDescOnce.Do(func() {
+		file_filetransfer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_filetransfer_proto_rawDesc), len(file_filetransfer_proto_rawDesc)))
+	})
+	return file_filetransfer_proto_rawDescData
+}
+
+var file_filetransfer_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_filetransfer_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
+var file_filetransfer_proto_goTypes = []any{
+	(TransferEvent_Type)(0),              // 0: filetransfer.TransferEvent.Type
+	(*CommonUploadRequest)(nil),          // 1: filetransfer.CommonUploadRequest
+	(*CommonUploadResponse)(nil),         // 2: filetransfer.CommonUploadResponse
+	(*UploadMetadata)(nil),               // 3: filetransfer.UploadMetadata
+	(*UploadStreamRequest)(nil),          // 4: filetransfer.UploadStreamRequest
+	(*UploadStreamResponse)(nil),         // 5: filetransfer.UploadStreamResponse
+	(*CommonDownloadRequest)(nil),        // 6: filetransfer.CommonDownloadRequest
+	(*FileHeader)(nil),                   // 7: filetransfer.FileHeader
+	(*FileChunk)(nil),                    // 8: filetransfer.FileChunk
+	(*TransferBetweenRequest)(nil),       // 9: filetransfer.TransferBetweenRequest
+	(*TransferResponse)(nil),             // 10: filetransfer.TransferResponse
+	(*TransferEvent)(nil),                // 11: filetransfer.TransferEvent
+	(*RemoteHost)(nil),                   // 12: filetransfer.RemoteHost
+	(*RemoteFile)(nil),                   // 13: filetransfer.RemoteFile
+	(*ListDirRequest)(nil),               // 14: filetransfer.ListDirRequest
+	(*ListDirResponse)(nil),              // 15: filetransfer.ListDirResponse
+	(*StatRequest)(nil),                  // 16: filetransfer.StatRequest
+	(*StatResponse)(nil),                 // 17: filetransfer.StatResponse
+	(*MkdirRequest)(nil),                 // 18: filetransfer.MkdirRequest
+	(*RemoveRequest)(nil),                // 19: filetransfer.RemoveRequest
+	(*RenameRequest)(nil),                // 20: filetransfer.RenameRequest
+	(*ChmodRequest)(nil),                 // 21: filetransfer.ChmodRequest
+	(*SymlinkRequest)(nil),               // 22: filetransfer.SymlinkRequest
+	(*ReadlinkRequest)(nil),              // 23: filetransfer.ReadlinkRequest
+	(*ReadlinkResponse)(nil),             // 24: filetransfer.ReadlinkResponse
+	(*FileOpResponse)(nil),               // 25: filetransfer.FileOpResponse
+	(*ListPoolConnectionsRequest)(nil),   // 26: filetransfer.ListPoolConnectionsRequest
+	(*PoolConnection)(nil),               // 27: filetransfer.PoolConnection
+	(*BreakerState)(nil),                 // 28: filetransfer.BreakerState
+	(*ListPoolConnectionsResponse)(nil),  // 29: filetransfer.ListPoolConnectionsResponse
+	(*EvictPoolConnectionsRequest)(nil),  // 30: filetransfer.EvictPoolConnectionsRequest
+	(*EvictPoolConnectionsResponse)(nil), // 31: filetransfer.EvictPoolConnectionsResponse
+	(*WarmupHost)(nil),                   // 32: filetransfer.WarmupHost
+	(*WarmupPoolRequest)(nil),            // 33: filetransfer.WarmupPoolRequest
+	(*WarmupResult)(nil),                 // 34: filetransfer.WarmupResult
+	(*WarmupPoolResponse)(nil),           // 35: filetransfer.WarmupPoolResponse
+	(*ResetBreakerRequest)(nil),          // 36: filetransfer.ResetBreakerRequest
+	(*ResetBreakerResponse)(nil),         // 37: filetransfer.ResetBreakerResponse
+	(*status.Status)(nil),                // 38: google.rpc.Status
+}
+var file_filetransfer_proto_depIdxs = []int32{
+	3,  // 0: filetransfer.UploadStreamRequest.metadata:type_name -> filetransfer.UploadMetadata
+	7,  // 1: filetransfer.FileChunk.header:type_name -> filetransfer.FileHeader
+	0,  // 2: filetransfer.TransferEvent.type:type_name -> filetransfer.TransferEvent.Type
+	38, // 3: filetransfer.TransferEvent.error:type_name -> google.rpc.Status
+	12, // 4: filetransfer.ListDirRequest.host:type_name -> filetransfer.RemoteHost
+	13, // 5: filetransfer.ListDirResponse.entries:type_name -> filetransfer.RemoteFile
+	12, // 6: filetransfer.StatRequest.host:type_name -> filetransfer.RemoteHost
+	13, // 7: filetransfer.StatResponse.file:type_name -> filetransfer.RemoteFile
+	12, // 8: filetransfer.MkdirRequest.host:type_name -> filetransfer.RemoteHost
+	12, // 9: filetransfer.RemoveRequest.host:type_name -> filetransfer.RemoteHost
+	12, // 10: filetransfer.RenameRequest.host:type_name -> filetransfer.RemoteHost
+	12, // 11: filetransfer.ChmodRequest.host:type_name -> filetransfer.RemoteHost
+	12, // 12: filetransfer.SymlinkRequest.host:type_name -> filetransfer.RemoteHost
+	12, // 13: filetransfer.ReadlinkRequest.host:type_name -> filetransfer.RemoteHost
+	27, // 14: filetransfer.ListPoolConnectionsResponse.connections:type_name -> filetransfer.PoolConnection
+	28, // 15: filetransfer.ListPoolConnectionsResponse.breakers:type_name -> filetransfer.BreakerState
+	32, // 16: filetransfer.WarmupPoolRequest.hosts:type_name -> filetransfer.WarmupHost
+	34, // 17: filetransfer.WarmupPoolResponse.results:type_name -> filetransfer.WarmupResult
+	1,  // 18: filetransfer.FileTransferService.CommonUpload:input_type -> filetransfer.CommonUploadRequest
+	4,  // 19: filetransfer.FileTransferService.UploadStream:input_type -> filetransfer.UploadStreamRequest
+	6,  // 20: filetransfer.FileTransferService.CommonDownload:input_type -> filetransfer.CommonDownloadRequest
+	9,  // 21: filetransfer.FileTransferService.TransferBetweenTwoServers:input_type -> filetransfer.TransferBetweenRequest
+	9,  // 22: filetransfer.FileTransferService.TransferWithProgress:input_type -> filetransfer.TransferBetweenRequest
+	14, // 23: filetransfer.FileTransferService.ListDir:input_type -> filetransfer.ListDirRequest
+	16, // 24: filetransfer.FileTransferService.Stat:input_type -> filetransfer.StatRequest
+	18, // 25: filetransfer.FileTransferService.Mkdir:input_type -> filetransfer.MkdirRequest
+	19, // 26: filetransfer.FileTransferService.Remove:input_type -> filetransfer.RemoveRequest
+	20, // 27: filetransfer.FileTransferService.Rename:input_type -> filetransfer.RenameRequest
+	21, // 28: filetransfer.FileTransferService.Chmod:input_type -> filetransfer.ChmodRequest
+	22, // 29: filetransfer.FileTransferService.Symlink:input_type -> filetransfer.SymlinkRequest
+	23, // 30: filetransfer.FileTransferService.Readlink:input_type -> filetransfer.ReadlinkRequest
+	26, // 31: filetransfer.FileTransferService.ListPoolConnections:input_type -> filetransfer.ListPoolConnectionsRequest
+	30, // 32: filetransfer.FileTransferService.EvictPoolConnections:input_type -> filetransfer.EvictPoolConnectionsRequest
+	33, // 33: filetransfer.FileTransferService.WarmupPool:input_type -> filetransfer.WarmupPoolRequest
+	36, // 34: filetransfer.FileTransferService.ResetBreaker:input_type -> filetransfer.ResetBreakerRequest
+	2,  // 35: filetransfer.FileTransferService.CommonUpload:output_type -> filetransfer.CommonUploadResponse
+	5,  // 36: filetransfer.FileTransferService.UploadStream:output_type -> filetransfer.UploadStreamResponse
+	8,  // 37: filetransfer.FileTransferService.CommonDownload:output_type -> filetransfer.FileChunk
+	10, // 38: filetransfer.FileTransferService.TransferBetweenTwoServers:output_type -> filetransfer.TransferResponse
+	11, // 39: filetransfer.FileTransferService.TransferWithProgress:output_type -> filetransfer.TransferEvent
+	15, // 40: filetransfer.FileTransferService.ListDir:output_type -> filetransfer.ListDirResponse
+	17, // 41: filetransfer.FileTransferService.Stat:output_type -> filetransfer.StatResponse
+	25, // 42: filetransfer.FileTransferService.Mkdir:output_type -> filetransfer.FileOpResponse
+	25, // 43: filetransfer.FileTransferService.Remove:output_type -> filetransfer.FileOpResponse
+	25, // 44: filetransfer.FileTransferService.Rename:output_type -> filetransfer.FileOpResponse
+	25, // 45: filetransfer.FileTransferService.Chmod:output_type -> filetransfer.FileOpResponse
+	25, // 46: filetransfer.FileTransferService.Symlink:output_type -> filetransfer.FileOpResponse
+	24, // 47: filetransfer.FileTransferService.Readlink:output_type -> filetransfer.ReadlinkResponse
+	29, // 48: filetransfer.FileTransferService.ListPoolConnections:output_type -> filetransfer.ListPoolConnectionsResponse
+	31, // 49: filetransfer.FileTransferService.EvictPoolConnections:output_type -> filetransfer.EvictPoolConnectionsResponse
+	35, // 50: filetransfer.FileTransferService.WarmupPool:output_type -> filetransfer.WarmupPoolResponse
+	37, // 51: filetransfer.FileTransferService.ResetBreaker:output_type -> filetransfer.ResetBreakerResponse
+	35, // [35:52] is the sub-list for method output_type
+	18, // [18:35] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_filetransfer_proto_init() }
+func file_filetransfer_proto_init() {
+	if File_filetransfer_proto != nil {
+		return
+	}
+	file_filetransfer_proto_msgTypes[3].OneofWrappers = []any{
+		(*UploadStreamRequest_Metadata)(nil),
+		(*UploadStreamRequest_Chunk)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_filetransfer_proto_rawDesc), len(file_pb_filetransfer_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_filetransfer_proto_rawDesc), len(file_filetransfer_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
-		GoTypes:           file_pb_filetransfer_proto_goTypes,
-		DependencyIndexes: file_pb_filetransfer_proto_depIdxs,
-		MessageInfos:      file_pb_filetransfer_proto_msgTypes,
+		GoTypes:           file_filetransfer_proto_goTypes,
+		DependencyIndexes: file_filetransfer_proto_depIdxs,
+		EnumInfos:         file_filetransfer_proto_enumTypes,
+		MessageInfos:      file_filetransfer_proto_msgTypes,
 	}.Build()
-	File_pb_filetransfer_proto = out.File
-	file_pb_filetransfer_proto_goTypes = nil
-	file_pb_filetransfer_proto_depIdxs = nil
+	File_filetransfer_proto = out.File
+	file_filetransfer_proto_goTypes = nil
+	file_filetransfer_proto_depIdxs = nil
 }
@@ -2,7 +2,7 @@
 // versions:
 // - protoc-gen-go-grpc v1.5.1
 // - protoc             v3.19.4
-// source: proto/filetransfer.proto
+// source: filetransfer.proto
 
 package proto
 
@@ -20,8 +20,22 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	FileTransferService_CommonUpload_FullMethodName              = "/filetransfer.FileTransferService/CommonUpload"
+	FileTransferService_UploadStream_FullMethodName              = "/filetransfer.FileTransferService/UploadStream"
 	FileTransferService_CommonDownload_FullMethodName            = "/filetransfer.FileTransferService/CommonDownload"
 	FileTransferService_TransferBetweenTwoServers_FullMethodName = "/filetransfer.FileTransferService/TransferBetweenTwoServers"
+	FileTransferService_TransferWithProgress_FullMethodName      = "/filetransfer.FileTransferService/TransferWithProgress"
+	FileTransferService_ListDir_FullMethodName                   = "/filetransfer.FileTransferService/ListDir"
+	FileTransferService_Stat_FullMethodName                      = "/filetransfer.FileTransferService/Stat"
+	FileTransferService_Mkdir_FullMethodName                     = "/filetransfer.FileTransferService/Mkdir"
+	FileTransferService_Remove_FullMethodName                    = "/filetransfer.FileTransferService/Remove"
+	FileTransferService_Rename_FullMethodName                    = "/filetransfer.FileTransferService/Rename"
+	FileTransferService_Chmod_FullMethodName                     = "/filetransfer.FileTransferService/Chmod"
+	FileTransferService_Symlink_FullMethodName                   = "/filetransfer.FileTransferService/Symlink"
+	FileTransferService_Readlink_FullMethodName                  = "/filetransfer.FileTransferService/Readlink"
+	FileTransferService_ListPoolConnections_FullMethodName       = "/filetransfer.FileTransferService/ListPoolConnections"
+	FileTransferService_EvictPoolConnections_FullMethodName      = "/filetransfer.FileTransferService/EvictPoolConnections"
+	FileTransferService_WarmupPool_FullMethodName                = "/filetransfer.FileTransferService/WarmupPool"
+	FileTransferService_ResetBreaker_FullMethodName              = "/filetransfer.FileTransferService/ResetBreaker"
 )
 
 // FileTransferServiceClient is the client API for FileTransferService service.
@@ -30,10 +44,31 @@ const (
 type FileTransferServiceClient interface {
 	// 客户端上传文件到指定服务器
 	CommonUpload(ctx context.Context, in *CommonUploadRequest, opts ...grpc.CallOption) (*CommonUploadResponse, error)
-	// 客户端从指定服务器下载文件（返回流）
+	// 客户端以流的方式上传文件：第一条消息携带元数据，之后每条消息携带一段文件内容
+	UploadStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadStreamRequest, UploadStreamResponse], error)
+	// 客户端从指定服务器下载文件（返回流），第一条消息为文件信息，之后为文件内容
 	CommonDownload(ctx context.Context, in *CommonDownloadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error)
 	// 两个服务器之间传输文件
 	TransferBetweenTwoServers(ctx context.Context, in *TransferBetweenRequest, opts ...grpc.CallOption) (*TransferResponse, error)
+	// 两个服务器之间传输文件，传输过程中持续返回任务创建、进度及完成或失败事件
+	TransferWithProgress(ctx context.Context, in *TransferBetweenRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferEvent], error)
+	// 远程文件管理：列目录（分页、排序）、查看信息、创建目录、删除、重命名、修改权限、符号链接
+	ListDir(ctx context.Context, in *ListDirRequest, opts ...grpc.CallOption) (*ListDirResponse, error)
+	Stat(ctx context.Context, in *StatRequest, opts ...grpc.CallOption) (*StatResponse, error)
+	Mkdir(ctx context.Context, in *MkdirRequest, opts ...grpc.CallOption) (*FileOpResponse, error)
+	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*FileOpResponse, error)
+	Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*FileOpResponse, error)
+	Chmod(ctx context.Context, in *ChmodRequest, opts ...grpc.CallOption) (*FileOpResponse, error)
+	Symlink(ctx context.Context, in *SymlinkRequest, opts ...grpc.CallOption) (*FileOpResponse, error)
+	Readlink(ctx context.Context, in *ReadlinkRequest, opts ...grpc.CallOption) (*ReadlinkResponse, error)
+	// 管理接口：查看连接池中的连接（仅管理员）
+	ListPoolConnections(ctx context.Context, in *ListPoolConnectionsRequest, opts ...grpc.CallOption) (*ListPoolConnectionsResponse, error)
+	// 管理接口：强制驱逐指定服务器或全部连接（仅管理员）
+	EvictPoolConnections(ctx context.Context, in *EvictPoolConnectionsRequest, opts ...grpc.CallOption) (*EvictPoolConnectionsResponse, error)
+	// 管理接口：为一组服务器预先建立连接（仅管理员）
+	WarmupPool(ctx context.Context, in *WarmupPoolRequest, opts ...grpc.CallOption) (*WarmupPoolResponse, error)
+	// 管理接口：手动关闭指定服务器的熔断器（仅管理员）
+	ResetBreaker(ctx context.Context, in *ResetBreakerRequest, opts ...grpc.CallOption) (*ResetBreakerResponse, error)
 }
 
 type fileTransferServiceClient struct {
@@ -54,9 +89,22 @@ func (c *fileTransferServiceClient) CommonUpload(ctx context.Context, in *Common
 	return out, nil
 }
 
+func (c *fileTransferServiceClient) UploadStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadStreamRequest, UploadStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FileTransferService_ServiceDesc.Streams[0], FileTransferService_UploadStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadStreamRequest, UploadStreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransferService_UploadStreamClient = grpc.ClientStreamingClient[UploadStreamRequest, UploadStreamResponse]
+
 func (c *fileTransferServiceClient) CommonDownload(ctx context.Context, in *CommonDownloadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &FileTransferService_ServiceDesc.Streams[0], FileTransferService_CommonDownload_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &FileTransferService_ServiceDesc.Streams[1], FileTransferService_CommonDownload_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -83,16 +131,176 @@ func (c *fileTransferServiceClient) TransferBetweenTwoServers(ctx context.Contex
 	return out, nil
 }
 
+func (c *fileTransferServiceClient) TransferWithProgress(ctx context.Context, in *TransferBetweenRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FileTransferService_ServiceDesc.Streams[2], FileTransferService_TransferWithProgress_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TransferBetweenRequest, TransferEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransferService_TransferWithProgressClient = grpc.ServerStreamingClient[TransferEvent]
+
+func (c *fileTransferServiceClient) ListDir(ctx context.Context, in *ListDirRequest, opts ...grpc.CallOption) (*ListDirResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDirResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_ListDir_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) Stat(ctx context.Context, in *StatRequest, opts ...grpc.CallOption) (*StatResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_Stat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) Mkdir(ctx context.Context, in *MkdirRequest, opts ...grpc.CallOption) (*FileOpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileOpResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_Mkdir_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*FileOpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileOpResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_Remove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*FileOpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileOpResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_Rename_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) Chmod(ctx context.Context, in *ChmodRequest, opts ...grpc.CallOption) (*FileOpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileOpResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_Chmod_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) Symlink(ctx context.Context, in *SymlinkRequest, opts ...grpc.CallOption) (*FileOpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileOpResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_Symlink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) Readlink(ctx context.Context, in *ReadlinkRequest, opts ...grpc.CallOption) (*ReadlinkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReadlinkResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_Readlink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) ListPoolConnections(ctx context.Context, in *ListPoolConnectionsRequest, opts ...grpc.CallOption) (*ListPoolConnectionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPoolConnectionsResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_ListPoolConnections_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) EvictPoolConnections(ctx context.Context, in *EvictPoolConnectionsRequest, opts ...grpc.CallOption) (*EvictPoolConnectionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EvictPoolConnectionsResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_EvictPoolConnections_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) WarmupPool(ctx context.Context, in *WarmupPoolRequest, opts ...grpc.CallOption) (*WarmupPoolResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WarmupPoolResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_WarmupPool_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) ResetBreaker(ctx context.Context, in *ResetBreakerRequest, opts ...grpc.CallOption) (*ResetBreakerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetBreakerResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_ResetBreaker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FileTransferServiceServer is the server API for FileTransferService service.
 // All implementations must embed UnimplementedFileTransferServiceServer
 // for forward compatibility.
 type FileTransferServiceServer interface {
 	// 客户端上传文件到指定服务器
 	CommonUpload(context.Context, *CommonUploadRequest) (*CommonUploadResponse, error)
-	// 客户端从指定服务器下载文件（返回流）
+	// 客户端以流的方式上传文件：第一条消息携带元数据，之后每条消息携带一段文件内容
+	UploadStream(grpc.ClientStreamingServer[UploadStreamRequest, UploadStreamResponse]) error
+	// 客户端从指定服务器下载文件（返回流），第一条消息为文件信息，之后为文件内容
 	CommonDownload(*CommonDownloadRequest, grpc.ServerStreamingServer[FileChunk]) error
 	// 两个服务器之间传输文件
 	TransferBetweenTwoServers(context.Context, *TransferBetweenRequest) (*TransferResponse, error)
+	// 两个服务器之间传输文件，传输过程中持续返回任务创建、进度及完成或失败事件
+	TransferWithProgress(*TransferBetweenRequest, grpc.ServerStreamingServer[TransferEvent]) error
+	// 远程文件管理：列目录（分页、排序）、查看信息、创建目录、删除、重命名、修改权限、符号链接
+	ListDir(context.Context, *ListDirRequest) (*ListDirResponse, error)
+	Stat(context.Context, *StatRequest) (*StatResponse, error)
+	Mkdir(context.Context, *MkdirRequest) (*FileOpResponse, error)
+	Remove(context.Context, *RemoveRequest) (*FileOpResponse, error)
+	Rename(context.Context, *RenameRequest) (*FileOpResponse, error)
+	Chmod(context.Context, *ChmodRequest) (*FileOpResponse, error)
+	Symlink(context.Context, *SymlinkRequest) (*FileOpResponse, error)
+	Readlink(context.Context, *ReadlinkRequest) (*ReadlinkResponse, error)
+	// 管理接口：查看连接池中的连接（仅管理员）
+	ListPoolConnections(context.Context, *ListPoolConnectionsRequest) (*ListPoolConnectionsResponse, error)
+	// 管理接口：强制驱逐指定服务器或全部连接（仅管理员）
+	EvictPoolConnections(context.Context, *EvictPoolConnectionsRequest) (*EvictPoolConnectionsResponse, error)
+	// 管理接口：为一组服务器预先建立连接（仅管理员）
+	WarmupPool(context.Context, *WarmupPoolRequest) (*WarmupPoolResponse, error)
+	// 管理接口：手动关闭指定服务器的熔断器（仅管理员）
+	ResetBreaker(context.Context, *ResetBreakerRequest) (*ResetBreakerResponse, error)
 	mustEmbedUnimplementedFileTransferServiceServer()
 }
 
@@ -106,12 +314,54 @@ type UnimplementedFileTransferServiceServer struct{}
 func (UnimplementedFileTransferServiceServer) CommonUpload(context.Context, *CommonUploadRequest) (*CommonUploadResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CommonUpload not implemented")
 }
+func (UnimplementedFileTransferServiceServer) UploadStream(grpc.ClientStreamingServer[UploadStreamRequest, UploadStreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadStream not implemented")
+}
 func (UnimplementedFileTransferServiceServer) CommonDownload(*CommonDownloadRequest, grpc.ServerStreamingServer[FileChunk]) error {
 	return status.Errorf(codes.Unimplemented, "method CommonDownload not implemented")
 }
 func (UnimplementedFileTransferServiceServer) TransferBetweenTwoServers(context.Context, *TransferBetweenRequest) (*TransferResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method TransferBetweenTwoServers not implemented")
 }
+func (UnimplementedFileTransferServiceServer) TransferWithProgress(*TransferBetweenRequest, grpc.ServerStreamingServer[TransferEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method TransferWithProgress not implemented")
+}
+func (UnimplementedFileTransferServiceServer) ListDir(context.Context, *ListDirRequest) (*ListDirResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDir not implemented")
+}
+func (UnimplementedFileTransferServiceServer) Stat(context.Context, *StatRequest) (*StatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stat not implemented")
+}
+func (UnimplementedFileTransferServiceServer) Mkdir(context.Context, *MkdirRequest) (*FileOpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Mkdir not implemented")
+}
+func (UnimplementedFileTransferServiceServer) Remove(context.Context, *RemoveRequest) (*FileOpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Remove not implemented")
+}
+func (UnimplementedFileTransferServiceServer) Rename(context.Context, *RenameRequest) (*FileOpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rename not implemented")
+}
+func (UnimplementedFileTransferServiceServer) Chmod(context.Context, *ChmodRequest) (*FileOpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Chmod not implemented")
+}
+func (UnimplementedFileTransferServiceServer) Symlink(context.Context, *SymlinkRequest) (*FileOpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Symlink not implemented")
+}
+func (UnimplementedFileTransferServiceServer) Readlink(context.Context, *ReadlinkRequest) (*ReadlinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Readlink not implemented")
+}
+func (UnimplementedFileTransferServiceServer) ListPoolConnections(context.Context, *ListPoolConnectionsRequest) (*ListPoolConnectionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPoolConnections not implemented")
+}
+func (UnimplementedFileTransferServiceServer) EvictPoolConnections(context.Context, *EvictPoolConnectionsRequest) (*EvictPoolConnectionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvictPoolConnections not implemented")
+}
+func (UnimplementedFileTransferServiceServer) WarmupPool(context.Context, *WarmupPoolRequest) (*WarmupPoolResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WarmupPool not implemented")
+}
+func (UnimplementedFileTransferServiceServer) ResetBreaker(context.Context, *ResetBreakerRequest) (*ResetBreakerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetBreaker not implemented")
+}
 func (UnimplementedFileTransferServiceServer) mustEmbedUnimplementedFileTransferServiceServer() {}
 func (UnimplementedFileTransferServiceServer) testEmbeddedByValue()                             {}
 
@@ -151,6 +401,13 @@ func _FileTransferService_CommonUpload_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FileTransferService_UploadStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FileTransferServiceServer).UploadStream(&grpc.GenericServerStream[UploadStreamRequest, UploadStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransferService_UploadStreamServer = grpc.ClientStreamingServer[UploadStreamRequest, UploadStreamResponse]
+
 func _FileTransferService_CommonDownload_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(CommonDownloadRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -180,6 +437,233 @@ func _FileTransferService_TransferBetweenTwoServers_Handler(srv interface{}, ctx
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FileTransferService_TransferWithProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TransferBetweenRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FileTransferServiceServer).TransferWithProgress(m, &grpc.GenericServerStream[TransferBetweenRequest, TransferEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransferService_TransferWithProgressServer = grpc.ServerStreamingServer[TransferEvent]
+
+func _FileTransferService_ListDir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDirRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).ListDir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_ListDir_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).ListDir(ctx, req.(*ListDirRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_Stat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).Stat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_Stat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).Stat(ctx, req.(*StatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_Mkdir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MkdirRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).Mkdir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_Mkdir_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).Mkdir(ctx, req.(*MkdirRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).Remove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_Remove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).Remove(ctx, req.(*RemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_Rename_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).Rename(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_Rename_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).Rename(ctx, req.(*RenameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_Chmod_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChmodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).Chmod(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_Chmod_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).Chmod(ctx, req.(*ChmodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_Symlink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SymlinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).Symlink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_Symlink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).Symlink(ctx, req.(*SymlinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_Readlink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadlinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).Readlink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_Readlink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).Readlink(ctx, req.(*ReadlinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_ListPoolConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPoolConnectionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).ListPoolConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_ListPoolConnections_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).ListPoolConnections(ctx, req.(*ListPoolConnectionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_EvictPoolConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvictPoolConnectionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).EvictPoolConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_EvictPoolConnections_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).EvictPoolConnections(ctx, req.(*EvictPoolConnectionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_WarmupPool_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WarmupPoolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).WarmupPool(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_WarmupPool_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).WarmupPool(ctx, req.(*WarmupPoolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_ResetBreaker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetBreakerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).ResetBreaker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_ResetBreaker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).ResetBreaker(ctx, req.(*ResetBreakerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // FileTransferService_ServiceDesc is the grpc.ServiceDesc for FileTransferService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -195,13 +679,71 @@ var FileTransferService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "TransferBetweenTwoServers",
 			Handler:    _FileTransferService_TransferBetweenTwoServers_Handler,
 		},
+		{
+			MethodName: "ListDir",
+			Handler:    _FileTransferService_ListDir_Handler,
+		},
+		{
+			MethodName: "Stat",
+			Handler:    _FileTransferService_Stat_Handler,
+		},
+		{
+			MethodName: "Mkdir",
+			Handler:    _FileTransferService_Mkdir_Handler,
+		},
+		{
+			MethodName: "Remove",
+			Handler:    _FileTransferService_Remove_Handler,
+		},
+		{
+			MethodName: "Rename",
+			Handler:    _FileTransferService_Rename_Handler,
+		},
+		{
+			MethodName: "Chmod",
+			Handler:    _FileTransferService_Chmod_Handler,
+		},
+		{
+			MethodName: "Symlink",
+			Handler:    _FileTransferService_Symlink_Handler,
+		},
+		{
+			MethodName: "Readlink",
+			Handler:    _FileTransferService_Readlink_Handler,
+		},
+		{
+			MethodName: "ListPoolConnections",
+			Handler:    _FileTransferService_ListPoolConnections_Handler,
+		},
+		{
+			MethodName: "EvictPoolConnections",
+			Handler:    _FileTransferService_EvictPoolConnections_Handler,
+		},
+		{
+			MethodName: "WarmupPool",
+			Handler:    _FileTransferService_WarmupPool_Handler,
+		},
+		{
+			MethodName: "ResetBreaker",
+			Handler:    _FileTransferService_ResetBreaker_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadStream",
+			Handler:       _FileTransferService_UploadStream_Handler,
+			ClientStreams: true,
+		},
 		{
 			StreamName:    "CommonDownload",
 			Handler:       _FileTransferService_CommonDownload_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "TransferWithProgress",
+			Handler:       _FileTransferService_TransferWithProgress_Handler,
+			ServerStreams: true,
+		},
 	},
-	Metadata: "proto/filetransfer.proto",
+	Metadata: "filetransfer.proto",
 }
@@ -0,0 +1,48 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"file-transfer/usersvc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 查看当前用户及其所在公司的用量和配额
+func GetUsage(c *gin.Context) {
+	if Default == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "未启用配额"})
+		return
+	}
+	username := c.GetString("username")
+
+	companyID, err := CompanyOf(c.Request.Context(), username)
+	if err != nil {
+		logx.Errorf("查询用户 %s 所在公司失败: %v", username, err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, usersvc.ErrUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"message": fmt.Sprintf("查询用户信息失败: %v", err)})
+		return
+	}
+
+	response := gin.H{"user": Default.UserUsage(username)}
+	if companyID != 0 {
+		response["company"] = Default.CompanyUsage(companyID)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// 查看所有用户和公司的用量（管理员）
+func ListUsage(c *gin.Context) {
+	if Default == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "未启用配额"})
+		return
+	}
+	users, companies := Default.Snapshot()
+	c.JSON(http.StatusOK, gin.H{"users": users, "companies": companies})
+}
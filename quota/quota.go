@@ -0,0 +1,303 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"file-transfer/config"
+	"file-transfer/middlewire"
+	g "file-transfer/transfer/global"
+	"file-transfer/usersvc"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// counter 一个用户或公司的用量，日、月切换时清零；running 只记在内存中
+type counter struct {
+	Day        string `json:"day"`
+	DayBytes   int64  `json:"day_bytes"`
+	Month      string `json:"month"`
+	MonthBytes int64  `json:"month_bytes"`
+	running    int
+}
+
+// roll 进入新的一天或一个月时清零对应的用量
+func (c *counter) roll(now time.Time) {
+	if day := now.Format("2006-01-02"); c.Day != day {
+		c.Day = day
+		c.DayBytes = 0
+	}
+	if month := now.Format("2006-01"); c.Month != month {
+		c.Month = month
+		c.MonthBytes = 0
+	}
+}
+
+// check 判断再开始一个预计传输 size 字节的任务是否超出配额，size 未知时为 -1
+func (c *counter) check(subject string, limits config.QuotaLimits, size int64) error {
+	if limits.MaxConcurrent > 0 && c.running >= limits.MaxConcurrent {
+		return fmt.Errorf("%w: %s 同时进行的任务数已达上限 %d", g.ErrQuotaExceeded, subject, limits.MaxConcurrent)
+	}
+	size = max(size, 0)
+	if limits.DailyBytes > 0 && (c.DayBytes >= limits.DailyBytes || c.DayBytes+size > limits.DailyBytes) {
+		return fmt.Errorf("%w: %s 今日已传输 %d 字节，再传输 %d 字节将超出上限 %d 字节", g.ErrQuotaExceeded, subject, c.DayBytes, size, limits.DailyBytes)
+	}
+	if limits.MonthlyBytes > 0 && (c.MonthBytes >= limits.MonthlyBytes || c.MonthBytes+size > limits.MonthlyBytes) {
+		return fmt.Errorf("%w: %s 本月已传输 %d 字节，再传输 %d 字节将超出上限 %d 字节", g.ErrQuotaExceeded, subject, c.MonthBytes, size, limits.MonthlyBytes)
+	}
+	return nil
+}
+
+// usageFile 用量文件的内容
+type usageFile struct {
+	Users     map[string]*counter `json:"users"`
+	Companies map[int32]*counter  `json:"companies"`
+}
+
+// Usage 一个用户或公司当前的用量及配额，供查询接口返回
+type Usage struct {
+	Username     string             `json:"username,omitempty"`
+	CompanyID    int32              `json:"company_id,omitempty"`
+	DailyBytes   int64              `json:"daily_bytes"`
+	MonthlyBytes int64              `json:"monthly_bytes"`
+	Running      int                `json:"running"`
+	Limits       config.QuotaLimits `json:"limits"`
+}
+
+// Tracker 统计每个用户和公司的传输字节数及进行中的任务数，并按配置限制
+type Tracker struct {
+	cfg config.QuotaConfig
+
+	mu        sync.Mutex
+	users     map[string]*counter
+	companies map[int32]*counter
+}
+
+var Default *Tracker // 全局配额统计，未启用配额时为 nil
+
+// Open 加载用量文件，文件不存在时从零开始统计
+func Open(cfg config.QuotaConfig) (*Tracker, error) {
+	t := &Tracker{
+		cfg:       cfg,
+		users:     make(map[string]*counter),
+		companies: make(map[int32]*counter),
+	}
+	if cfg.Path == "" {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(cfg.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取用量文件失败: %v", err)
+	}
+
+	var f usageFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析用量文件失败: %v", err)
+	}
+	for name, c := range f.Users {
+		t.users[name] = c
+	}
+	for id, c := range f.Companies {
+		t.companies[id] = c
+	}
+	return t, nil
+}
+
+// save 将用量写入文件，先写临时文件再重命名；调用方需持有锁
+func (t *Tracker) save() error {
+	if t.cfg.Path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(usageFile{Users: t.users, Companies: t.companies}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.cfg.Path), 0700); err != nil {
+		return err
+	}
+	tmp := t.cfg.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.cfg.Path)
+}
+
+// UserLimits 返回用户的配额：单独配置的非零项覆盖默认配额，其余项沿用默认配额
+func (t *Tracker) UserLimits(username string) config.QuotaLimits {
+	return mergeLimits(t.cfg.User, t.cfg.Users[username])
+}
+
+// CompanyLimits 返回公司的配额：单独配置的非零项覆盖默认配额，其余项沿用默认配额
+func (t *Tracker) CompanyLimits(companyID int32) config.QuotaLimits {
+	return mergeLimits(t.cfg.Company, t.cfg.Companies[companyID])
+}
+
+// mergeLimits 将覆盖配置合并到默认配额上：0 表示沿用默认值，负数表示不限制
+func mergeLimits(base, override config.QuotaLimits) config.QuotaLimits {
+	merge := func(b, o int64) int64 {
+		switch {
+		case o > 0:
+			return o
+		case o < 0:
+			return 0
+		}
+		return b
+	}
+	return config.QuotaLimits{
+		DailyBytes:    merge(base.DailyBytes, override.DailyBytes),
+		MonthlyBytes:  merge(base.MonthlyBytes, override.MonthlyBytes),
+		MaxConcurrent: int(merge(int64(base.MaxConcurrent), int64(override.MaxConcurrent))),
+	}
+}
+
+// userCounter 返回用户的用量，不存在则创建；调用方需持有锁
+func (t *Tracker) userCounter(username string, now time.Time) *counter {
+	c, exists := t.users[username]
+	if !exists {
+		c = &counter{}
+		t.users[username] = c
+	}
+	c.roll(now)
+	return c
+}
+
+// companyCounter 返回公司的用量，company_id 为 0 时返回 nil；调用方需持有锁
+func (t *Tracker) companyCounter(companyID int32, now time.Time) *counter {
+	if companyID == 0 {
+		return nil
+	}
+	c, exists := t.companies[companyID]
+	if !exists {
+		c = &counter{}
+		t.companies[companyID] = c
+	}
+	c.roll(now)
+	return c
+}
+
+// CompanyOf 从用户服务查询用户所在的公司，未接入用户服务或用户不存在时返回 0
+func CompanyOf(ctx context.Context, username string) (int32, error) {
+	if usersvc.Default == nil {
+		return 0, nil
+	}
+	u, err := usersvc.Default.GetUser(ctx, username)
+	if err != nil || u == nil {
+		return 0, err
+	}
+	return u.CompanyId, nil
+}
+
+// Meter 实现 global.TransferMeter：检查调用方及其公司的配额并占用一个任务名额，
+// 传输结束时释放名额并累计实际传输的字节数
+func (t *Tracker) Meter(ctx context.Context, size int64) (func(int64), error) {
+	id, ok := middlewire.IdentityFrom(ctx)
+	if !ok || id.Username == "" {
+		return func(int64) {}, nil
+	}
+	companyID, err := CompanyOf(ctx, id.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	user := t.userCounter(id.Username, now)
+	company := t.companyCounter(companyID, now)
+	if err := user.check("用户 "+id.Username, t.UserLimits(id.Username), size); err != nil {
+		t.mu.Unlock()
+		return nil, err
+	}
+	if company != nil {
+		if err := company.check(fmt.Sprintf("公司 %d", companyID), t.CompanyLimits(companyID), size); err != nil {
+			t.mu.Unlock()
+			return nil, err
+		}
+		company.running++
+	}
+	user.running++
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func(n int64) {
+		once.Do(func() { t.finish(id.Username, companyID, n) })
+	}, nil
+}
+
+// finish 释放任务名额并累计用量；跨越零点的任务计入结束时的日期
+func (t *Tracker) finish(username string, companyID int32, n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	n = max(n, 0)
+	counters := []*counter{t.userCounter(username, now)}
+	if company := t.companyCounter(companyID, now); company != nil {
+		counters = append(counters, company)
+	}
+	for _, c := range counters {
+		if c.running > 0 {
+			c.running--
+		}
+		c.DayBytes += n
+		c.MonthBytes += n
+	}
+	if n > 0 {
+		if err := t.save(); err != nil {
+			logx.Errorf("保存用量失败: %v", err)
+		}
+	}
+}
+
+// UserUsage 返回用户当前的用量
+func (t *Tracker) UserUsage(username string) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.userCounter(username, time.Now())
+	return Usage{Username: username, DailyBytes: c.DayBytes, MonthlyBytes: c.MonthBytes, Running: c.running, Limits: t.UserLimits(username)}
+}
+
+// CompanyUsage 返回公司当前的用量
+func (t *Tracker) CompanyUsage(companyID int32) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.companyCounter(companyID, time.Now())
+	if c == nil {
+		return Usage{Limits: t.CompanyLimits(companyID)}
+	}
+	return Usage{CompanyID: companyID, DailyBytes: c.DayBytes, MonthlyBytes: c.MonthBytes, Running: c.running, Limits: t.CompanyLimits(companyID)}
+}
+
+// Snapshot 返回所有用户和公司的用量，按用户名和公司ID排序
+func (t *Tracker) Snapshot() ([]Usage, []Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	users := make([]Usage, 0, len(t.users))
+	for name := range t.users {
+		c := t.userCounter(name, now)
+		users = append(users, Usage{Username: name, DailyBytes: c.DayBytes, MonthlyBytes: c.MonthBytes, Running: c.running, Limits: t.UserLimits(name)})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+
+	companies := make([]Usage, 0, len(t.companies))
+	for id := range t.companies {
+		c := t.companyCounter(id, now)
+		companies = append(companies, Usage{CompanyID: id, DailyBytes: c.DayBytes, MonthlyBytes: c.MonthBytes, Running: c.running, Limits: t.CompanyLimits(id)})
+	}
+	sort.Slice(companies, func(i, j int) bool { return companies[i].CompanyID < companies[j].CompanyID })
+	return users, companies
+}
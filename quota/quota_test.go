@@ -0,0 +1,195 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-transfer/config"
+	"file-transfer/middlewire"
+	g "file-transfer/transfer/global"
+	"file-transfer/usersvc"
+	"file-transfer/usersvc/fake"
+)
+
+// useUserService 启动假用户服务：alice 和 bob 属于公司 7，carol 未加入公司
+func useUserService(t *testing.T) {
+	t.Helper()
+	svc := fake.NewUserService()
+	svc.AddUser("alice", 2, 7)
+	svc.AddUser("bob", 2, 7)
+	svc.AddUser("carol", 2, 0)
+	addr, stop, err := svc.Serve("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动假用户服务失败: %v", err)
+	}
+	t.Cleanup(stop)
+
+	old := usersvc.Default
+	if usersvc.Default, err = usersvc.NewClient(config.UserServiceConfig{Addr: addr}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { usersvc.Default = old })
+}
+
+// as 返回以指定用户身份调用的 ctx
+func as(username string) context.Context {
+	return middlewire.WithIdentity(context.Background(), middlewire.Identity{Username: username})
+}
+
+// mustMeter 开始一次计量，失败时终止测试
+func mustMeter(t *testing.T, tr *Tracker, username string, size int64) func(int64) {
+	t.Helper()
+	done, err := tr.Meter(as(username), size)
+	if err != nil {
+		t.Fatalf("%s 开始 %d 字节的传输失败: %v", username, size, err)
+	}
+	return done
+}
+
+func TestMergeLimits(t *testing.T) {
+	base := config.QuotaLimits{DailyBytes: 100, MonthlyBytes: 1000, MaxConcurrent: 2}
+	tests := []struct {
+		name     string
+		override config.QuotaLimits
+		want     config.QuotaLimits
+	}{
+		{"未覆盖", config.QuotaLimits{}, base},
+		{"只覆盖部分项", config.QuotaLimits{DailyBytes: 500}, config.QuotaLimits{DailyBytes: 500, MonthlyBytes: 1000, MaxConcurrent: 2}},
+		{"负数表示不限制", config.QuotaLimits{MonthlyBytes: -1, MaxConcurrent: -1}, config.QuotaLimits{DailyBytes: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeLimits(base, tt.override); got != tt.want {
+				t.Errorf("mergeLimits = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeterBytes(t *testing.T) {
+	useUserService(t)
+	tr, err := Open(config.QuotaConfig{
+		User:  config.QuotaLimits{DailyBytes: 100},
+		Users: map[string]config.QuotaLimits{"carol": {DailyBytes: -1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mustMeter(t, tr, "alice", 60)(60)
+	tests := []struct {
+		name    string
+		user    string
+		size    int64
+		wantErr bool
+	}{
+		{"加上预计字节数超出上限", "alice", 50, true},
+		{"加上预计字节数恰好达到上限", "alice", 40, false},
+		{"大小未知时按 0 预检", "alice", -1, false},
+		{"其他用户单独统计", "bob", 100, false},
+		{"单独配置为不限制", "carol", 1 << 40, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, err := tr.Meter(as(tt.user), tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Meter err = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, g.ErrQuotaExceeded) {
+					t.Errorf("err = %v, want ErrQuotaExceeded", err)
+				}
+				return
+			}
+			done(0)
+		})
+	}
+
+	// 用量达到上限后，大小未知的传输同样被拒绝
+	mustMeter(t, tr, "alice", 40)(40)
+	if _, err := tr.Meter(as("alice"), -1); !errors.Is(err, g.ErrQuotaExceeded) {
+		t.Errorf("用量已满时 err = %v, want ErrQuotaExceeded", err)
+	}
+	if u := tr.UserUsage("alice"); u.DailyBytes != 100 || u.MonthlyBytes != 100 || u.Running != 0 {
+		t.Errorf("UserUsage = %+v", u)
+	}
+}
+
+func TestMeterConcurrent(t *testing.T) {
+	useUserService(t)
+	tr, err := Open(config.QuotaConfig{
+		User:    config.QuotaLimits{MaxConcurrent: 1},
+		Company: config.QuotaLimits{MaxConcurrent: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := mustMeter(t, tr, "alice", 10)
+	if _, err := tr.Meter(as("alice"), 10); !errors.Is(err, g.ErrQuotaExceeded) {
+		t.Errorf("用户的任务数已满时 err = %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := tr.Meter(as("bob"), 10); !errors.Is(err, g.ErrQuotaExceeded) {
+		t.Errorf("同一公司的任务数已满时 err = %v, want ErrQuotaExceeded", err)
+	}
+	mustMeter(t, tr, "carol", 10)(0) // 未加入公司的用户不受公司配额限制
+
+	done(10)
+	done(10) // 重复调用只计一次
+	if u := tr.CompanyUsage(7); u.Running != 0 || u.DailyBytes != 10 {
+		t.Errorf("CompanyUsage = %+v", u)
+	}
+	mustMeter(t, tr, "bob", 10)(0)
+
+	if done, err := tr.Meter(context.Background(), 1<<40); err != nil {
+		t.Errorf("没有调用方身份时不做限制: %v", err)
+	} else {
+		done(1 << 40)
+	}
+}
+
+func TestUsagePersisted(t *testing.T) {
+	useUserService(t)
+	cfg := config.QuotaConfig{Path: filepath.Join(t.TempDir(), "usage.json"), User: config.QuotaLimits{DailyBytes: 100}}
+	tr, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustMeter(t, tr, "alice", 70)(70)
+
+	reopened, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("重新加载用量失败: %v", err)
+	}
+	if u := reopened.UserUsage("alice"); u.DailyBytes != 70 {
+		t.Errorf("重启后的用量 = %d, want 70", u.DailyBytes)
+	}
+	if _, err := reopened.Meter(as("alice"), 40); !errors.Is(err, g.ErrQuotaExceeded) {
+		t.Errorf("重启后应继续累计: err = %v", err)
+	}
+}
+
+func TestCounterRoll(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 1, 0, time.Local)
+	tests := []struct {
+		name      string
+		c         counter
+		wantDay   int64
+		wantMonth int64
+	}{
+		{"同一天", counter{Day: "2024-03-01", DayBytes: 5, Month: "2024-03", MonthBytes: 50}, 5, 50},
+		{"新的一天", counter{Day: "2024-02-29", DayBytes: 5, Month: "2024-03", MonthBytes: 50}, 0, 50},
+		{"新的一个月", counter{Day: "2024-02-29", DayBytes: 5, Month: "2024-02", MonthBytes: 50}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.c.roll(now)
+			if tt.c.DayBytes != tt.wantDay || tt.c.MonthBytes != tt.wantMonth {
+				t.Errorf("roll 后 = %d/%d, want %d/%d", tt.c.DayBytes, tt.c.MonthBytes, tt.wantDay, tt.wantMonth)
+			}
+		})
+	}
+}
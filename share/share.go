@@ -0,0 +1,415 @@
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"file-transfer/config"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrLinkNotFound     = errors.New("分享链接不存在")
+	ErrLinkExpired      = errors.New("分享链接已过期")
+	ErrLinkRevoked      = errors.New("分享链接已撤销")
+	ErrLinkExhausted    = errors.New("分享链接的下载次数已用完")
+	ErrPasswordRequired = errors.New("该分享链接需要密码")
+	ErrWrongPassword    = errors.New("分享链接的密码错误")
+	ErrTooManyAttempts  = errors.New("密码错误次数过多")
+)
+
+const defaultRetention = 7 * 24 * time.Hour
+
+// 同一链接连续输错密码 maxPasswordFailures 次后锁定 passwordLockout，锁定期间不再校验密码
+const (
+	maxPasswordFailures = 5
+	passwordLockout     = 15 * time.Minute
+)
+
+// Link 分享链接的元信息，不包含密码，可以安全地返回给调用方
+type Link struct {
+	ID           string     `json:"id"`
+	Owner        string     `json:"owner"`         // 创建者，访问时以该用户的身份下载，服务器归属、访问策略和配额均按该用户计算
+	HostID       string     `json:"host_id"`       // 主机清单中的服务器
+	Path         string     `json:"path"`          // 远程文件路径
+	Protected    bool       `json:"protected"`     // 是否需要密码
+	MaxDownloads int        `json:"max_downloads"` // 下载次数上限，0 表示不限制
+	Downloads    int        `json:"downloads"`     // 已下载次数
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastAccessAt *time.Time `json:"last_access_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// record 持久化的分享链接，密码只保存 bcrypt 摘要；密码错误次数只记在内存中
+type record struct {
+	Link
+	PasswordHash string `json:"password_hash,omitempty"`
+
+	failures    int       // 连续输错密码的次数，包括正在校验的次数
+	lockedUntil time.Time // 锁定截止时间
+}
+
+// Store 分享链接存储，保存在本地 JSON 文件中；链接令牌由签名密钥签名，不保存在文件中
+type Store struct {
+	mu     sync.Mutex
+	path   string
+	secret []byte
+	links  map[string]*record
+}
+
+var Default *Store // 全局分享链接存储
+
+// Open 加载分享链接文件，文件不存在时创建空存储。
+// 未配置签名密钥时随机生成，重启后之前生成的链接全部失效
+func Open(cfg config.ShareConfig) (*Store, error) {
+	secret := cfg.Secret
+	if cfg.SecretEnv != "" {
+		if v := os.Getenv(cfg.SecretEnv); v != "" {
+			secret = v
+		}
+	}
+	s := &Store{path: cfg.Path, secret: []byte(secret), links: make(map[string]*record)}
+	if secret == "" {
+		b, err := randomBytes(32)
+		if err != nil {
+			return nil, err
+		}
+		s.secret = b
+		logx.Infof("未配置分享链接签名密钥，使用随机密钥，重启后已有的分享链接将失效")
+	}
+
+	data, err := os.ReadFile(cfg.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取分享链接文件失败: %v", err)
+	}
+
+	var records []*record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("解析分享链接文件失败: %v", err)
+	}
+	for _, r := range records {
+		s.links[r.ID] = r
+	}
+	return s, nil
+}
+
+// save 将分享链接写入文件，先写临时文件再重命名；调用方需持有锁
+func (s *Store) save() error {
+	records := make([]*record, 0, len(s.links))
+	for _, r := range s.links {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		logx.Errorf("保存分享链接失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+// sign 计算链接ID和过期时间的签名
+func (s *Store) sign(id string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id + "." + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Token 返回分享链接的令牌，形如 <ID>.<过期时间戳>.<签名>；令牌由签名密钥计算得出，可以重复获取
+func (s *Store) Token(l Link) string {
+	expires := l.ExpiresAt.Unix()
+	return l.ID + "." + strconv.FormatInt(expires, 10) + "." + s.sign(l.ID, expires)
+}
+
+// Create 创建分享链接，返回元信息和链接令牌；password 为空表示不需要密码
+func (s *Store) Create(l Link, password string) (Link, string, error) {
+	if l.Owner == "" || l.HostID == "" || l.Path == "" {
+		return Link{}, "", errors.New("必须指定所属用户、主机ID和文件路径")
+	}
+	if l.MaxDownloads < 0 {
+		return Link{}, "", errors.New("下载次数上限不能为负数")
+	}
+	if !l.ExpiresAt.After(time.Now()) {
+		return Link{}, "", errors.New("过期时间必须晚于当前时间")
+	}
+
+	idBytes, err := randomBytes(12)
+	if err != nil {
+		return Link{}, "", err
+	}
+	r := &record{Link: l}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return Link{}, "", fmt.Errorf("生成密码摘要失败: %v", err)
+		}
+		r.PasswordHash = string(hash)
+	}
+	r.ID = hex.EncodeToString(idBytes)
+	r.Protected = password != ""
+	r.Downloads = 0
+	r.ExpiresAt = l.ExpiresAt.Truncate(time.Second) // 令牌中的过期时间精确到秒
+	r.CreatedAt = time.Now()
+	r.LastAccessAt = nil
+	r.RevokedAt = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.links[r.ID] = r
+	if err := s.save(); err != nil {
+		delete(s.links, r.ID)
+		return Link{}, "", err
+	}
+	return r.Link, s.Token(r.Link), nil
+}
+
+// Get 返回分享链接的元信息
+func (s *Store) Get(id string) (Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.links[id]
+	if !exists {
+		return Link{}, ErrLinkNotFound
+	}
+	return r.Link, nil
+}
+
+// List 返回用户创建的分享链接（含已过期和已撤销的），owner 为空时返回全部
+func (s *Store) List(owner string) []Link {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	links := make([]Link, 0, len(s.links))
+	for _, r := range s.links {
+		if owner == "" || r.Owner == owner {
+			links = append(links, r.Link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].CreatedAt.Before(links[j].CreatedAt) })
+	return links
+}
+
+// Revoke 撤销分享链接，撤销后立即失效；记录保留到清理时间以便审计
+func (s *Store) Revoke(id string) (Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.links[id]
+	if !exists {
+		return Link{}, ErrLinkNotFound
+	}
+	if r.RevokedAt != nil {
+		return r.Link, nil
+	}
+	now := time.Now()
+	r.RevokedAt = &now
+	if err := s.save(); err != nil {
+		r.RevokedAt = nil
+		return Link{}, err
+	}
+	return r.Link, nil
+}
+
+// Resolve 校验链接令牌和密码，返回链接的元信息；不消耗下载次数。
+// 签名不正确的令牌不查询存储，一律视为不存在；连续输错密码过多时暂时锁定该链接
+func (s *Store) Resolve(token, password string) (Link, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Link{}, ErrLinkNotFound
+	}
+	id := parts[0]
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || !hmac.Equal([]byte(parts[2]), []byte(s.sign(id, expires))) {
+		return Link{}, ErrLinkNotFound
+	}
+
+	s.mu.Lock()
+	r, exists := s.links[id]
+	if !exists || r.ExpiresAt.Unix() != expires {
+		s.mu.Unlock()
+		return Link{}, ErrLinkNotFound
+	}
+	if err := r.usable(); err != nil {
+		s.mu.Unlock()
+		return r.Link, err
+	}
+	now := time.Now()
+	link, hash := r.Link, r.PasswordHash
+	if hash == "" {
+		r.LastAccessAt = &now // 只记在内存中，随下一次写入一起保存
+		s.mu.Unlock()
+		return link, nil
+	}
+	if password == "" {
+		s.mu.Unlock()
+		return link, ErrPasswordRequired
+	}
+	if now.Before(r.lockedUntil) {
+		s.mu.Unlock()
+		return link, fmt.Errorf("%w，请在 %s 后重试", ErrTooManyAttempts, r.lockedUntil.Sub(now).Round(time.Second))
+	}
+	// 校验前先按失败计数，并发的猜测合计不会超过上限
+	if r.failures >= maxPasswordFailures {
+		s.mu.Unlock()
+		return link, fmt.Errorf("%w，请稍后重试", ErrTooManyAttempts)
+	}
+	r.failures++
+	s.mu.Unlock()
+
+	// 校验密码较慢，不持有锁
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	s.recordAttempt(id, err == nil)
+	if err != nil {
+		return link, ErrWrongPassword
+	}
+	return link, nil
+}
+
+// recordAttempt 记录一次密码校验的结果（校验前已计入失败次数）：成功时清零错误次数并记录访问时间，
+// 连续失败达到上限时锁定链接
+func (s *Store) recordAttempt(id string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.links[id]
+	if !exists {
+		return
+	}
+	now := time.Now()
+	if ok {
+		r.failures = 0
+		r.LastAccessAt = &now
+		return
+	}
+	if r.failures >= maxPasswordFailures && !now.Before(r.lockedUntil) {
+		r.failures = 0
+		r.lockedUntil = now.Add(passwordLockout)
+		logx.Infof("分享链接 %s 连续输错密码 %d 次，锁定至 %s", id, maxPasswordFailures, r.lockedUntil.Format(time.DateTime))
+	}
+}
+
+// usable 判断链接当前是否可以下载；调用方需持有锁
+func (r *record) usable() error {
+	switch {
+	case r.RevokedAt != nil:
+		return ErrLinkRevoked
+	case !time.Now().Before(r.ExpiresAt):
+		return ErrLinkExpired
+	case r.MaxDownloads > 0 && r.Downloads >= r.MaxDownloads:
+		return ErrLinkExhausted
+	}
+	return nil
+}
+
+// Claim 占用一次下载次数，次数用完或链接已失效时返回错误
+func (s *Store) Claim(id string) (Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.links[id]
+	if !exists {
+		return Link{}, ErrLinkNotFound
+	}
+	if err := r.usable(); err != nil {
+		return r.Link, err
+	}
+	r.Downloads++
+	if err := s.save(); err != nil {
+		r.Downloads--
+		return Link{}, err
+	}
+	return r.Link, nil
+}
+
+// Release 归还 Claim 占用的下载次数，用于文件未能发送的情况
+func (s *Store) Release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.links[id]
+	if !exists || r.Downloads == 0 {
+		return
+	}
+	r.Downloads--
+	if err := s.save(); err != nil {
+		logx.Errorf("归还分享链接 %s 的下载次数失败: %v", id, err)
+	}
+}
+
+// Prune 删除过期或撤销超过 retention 的链接
+func (s *Store) Prune(retention time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline := time.Now().Add(-retention)
+	removed := 0
+	for id, r := range s.links {
+		if r.ExpiresAt.Before(deadline) || r.RevokedAt != nil && r.RevokedAt.Before(deadline) {
+			delete(s.links, id)
+			removed++
+		}
+	}
+	if removed > 0 {
+		if err := s.save(); err != nil {
+			logx.Errorf("清理分享链接失败: %v", err)
+		}
+	}
+	return removed
+}
+
+// Cleanup 定期清理过期或撤销超过 retention 的链接，retention 为 0 时默认保留 7 天
+func (s *Store) Cleanup(retention time.Duration, stop <-chan struct{}) {
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if n := s.Prune(retention); n > 0 {
+				logx.Infof("已清理 %d 个失效的分享链接", n)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package share
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"file-transfer/config"
+)
+
+// newTestStore 创建保存在临时目录中、使用固定签名密钥的分享链接存储
+func newTestStore(t *testing.T, secret string) *Store {
+	t.Helper()
+	s, err := Open(config.ShareConfig{Path: filepath.Join(t.TempDir(), "shares.json"), Secret: secret})
+	if err != nil {
+		t.Fatalf("打开分享链接存储失败: %v", err)
+	}
+	return s
+}
+
+// create 创建指向 /data/a.txt 的链接
+func create(t *testing.T, s *Store, ttl time.Duration, maxDownloads int, password string) (Link, string) {
+	t.Helper()
+	link, token, err := s.Create(Link{Owner: "alice", HostID: "h1", Path: "/data/a.txt", MaxDownloads: maxDownloads, ExpiresAt: time.Now().Add(ttl)}, password)
+	if err != nil {
+		t.Fatalf("创建分享链接失败: %v", err)
+	}
+	return link, token
+}
+
+func TestTokenSignature(t *testing.T) {
+	s := newTestStore(t, "secret-1")
+	link, token := create(t, s, time.Hour, 0, "")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != link.ID || parts[1] != strconv.FormatInt(link.ExpiresAt.Unix(), 10) {
+		t.Fatalf("令牌格式错误: %s", token)
+	}
+	if again := s.Token(link); again != token {
+		t.Errorf("同一链接的令牌应保持不变: %s != %s", again, token)
+	}
+
+	other := newTestStore(t, "secret-2")
+	otherLink, otherToken := create(t, other, time.Hour, 0, "")
+	flipped := []byte(parts[2])
+	flipped[0] ^= 1
+	later := strconv.FormatInt(link.ExpiresAt.Add(time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"正确的令牌", token, nil},
+		{"签名被篡改", parts[0] + "." + parts[1] + "." + string(flipped), ErrLinkNotFound},
+		{"延长过期时间", parts[0] + "." + later + "." + parts[2], ErrLinkNotFound},
+		{"延长过期时间并用其他密钥签名", parts[0] + "." + later + "." + other.sign(parts[0], link.ExpiresAt.Add(time.Hour).Unix()), ErrLinkNotFound},
+		{"其他存储签发的令牌", otherToken, ErrLinkNotFound},
+		{"用本存储的密钥为其他存储的链接签名", s.Token(otherLink), ErrLinkNotFound},
+		{"缺少签名", parts[0] + "." + parts[1], ErrLinkNotFound},
+		{"多余的部分", token + ".x", ErrLinkNotFound},
+		{"过期时间不是数字", parts[0] + ".soon." + parts[2], ErrLinkNotFound},
+		{"空令牌", "", ErrLinkNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Resolve(tt.token, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.ID != link.ID {
+				t.Errorf("Resolve ID = %s, want %s", got.ID, link.ID)
+			}
+		})
+	}
+}
+
+func TestSecretSurvivesReopen(t *testing.T) {
+	t.Setenv("SHARE_TEST_SECRET", "env-secret")
+	path := filepath.Join(t.TempDir(), "shares.json")
+	cfg := config.ShareConfig{Path: path, Secret: "ignored", SecretEnv: "SHARE_TEST_SECRET"}
+	s, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, token := create(t, s, time.Hour, 0, "")
+
+	tests := []struct {
+		name    string
+		cfg     config.ShareConfig
+		wantErr error
+	}{
+		{"相同的签名密钥", cfg, nil},
+		{"环境变量优先于 Secret", config.ShareConfig{Path: path, Secret: "env-secret"}, nil},
+		{"签名密钥不同", config.ShareConfig{Path: path, Secret: "ignored"}, ErrLinkNotFound},
+		{"未配置签名密钥时随机生成", config.ShareConfig{Path: path}, ErrLinkNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reopened, err := Open(tt.cfg)
+			if err != nil {
+				t.Fatalf("重新打开失败: %v", err)
+			}
+			got, err := reopened.Resolve(token, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.ID != link.ID {
+				t.Errorf("Resolve ID = %s, want %s", got.ID, link.ID)
+			}
+		})
+	}
+}
+
+func TestResolveState(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		max      int
+		password string
+		prepare  func(s *Store, l Link) // 访问前修改链接状态
+		input    string                 // 访问时提供的密码
+		wantErr  error
+	}{
+		{name: "有效的链接", ttl: time.Hour},
+		{name: "已过期", ttl: time.Hour, prepare: func(s *Store, l Link) { s.links[l.ID].ExpiresAt = time.Now().Add(-time.Second) }, wantErr: ErrLinkExpired},
+		{name: "恰好到期", ttl: time.Hour, prepare: func(s *Store, l Link) { s.links[l.ID].ExpiresAt = time.Now() }, wantErr: ErrLinkExpired},
+		{name: "已撤销", ttl: time.Hour, prepare: func(s *Store, l Link) { s.Revoke(l.ID) }, wantErr: ErrLinkRevoked},
+		{name: "下载次数已用完", ttl: time.Hour, max: 1, prepare: func(s *Store, l Link) { s.Claim(l.ID) }, wantErr: ErrLinkExhausted},
+		{name: "归还后可以继续下载", ttl: time.Hour, max: 1, prepare: func(s *Store, l Link) { s.Claim(l.ID); s.Release(l.ID) }},
+		{name: "链接记录已删除", ttl: time.Hour, prepare: func(s *Store, l Link) { delete(s.links, l.ID) }, wantErr: ErrLinkNotFound},
+		{name: "缺少密码", ttl: time.Hour, password: "pw", wantErr: ErrPasswordRequired},
+		{name: "密码错误", ttl: time.Hour, password: "pw", input: "PW", wantErr: ErrWrongPassword},
+		{name: "密码正确", ttl: time.Hour, password: "pw", input: "pw"},
+		{name: "不需要密码时忽略提供的密码", ttl: time.Hour, input: "anything"},
+		{name: "过期优先于密码校验", ttl: time.Hour, password: "pw", input: "wrong", prepare: func(s *Store, l Link) { s.links[l.ID].ExpiresAt = time.Now().Add(-time.Second) }, wantErr: ErrLinkExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "secret")
+			link, token := create(t, s, tt.ttl, tt.max, tt.password)
+			if tt.prepare != nil {
+				// 令牌中的过期时间必须与存储一致，修改过期时间后重新生成令牌
+				r := s.links[link.ID]
+				expires := r.ExpiresAt
+				tt.prepare(s, link)
+				if r.ExpiresAt != expires {
+					r.ExpiresAt = r.ExpiresAt.Truncate(time.Second)
+					token = s.Token(r.Link)
+				}
+			}
+			_, err := s.Resolve(token, tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Resolve err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	s := newTestStore(t, "secret")
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name    string
+		link    Link
+		wantErr bool
+	}{
+		{"有效的链接", Link{Owner: "alice", HostID: "h1", Path: "/a", ExpiresAt: future}, false},
+		{"缺少所属用户", Link{HostID: "h1", Path: "/a", ExpiresAt: future}, true},
+		{"缺少主机ID", Link{Owner: "alice", Path: "/a", ExpiresAt: future}, true},
+		{"缺少路径", Link{Owner: "alice", HostID: "h1", ExpiresAt: future}, true},
+		{"下载次数上限为负数", Link{Owner: "alice", HostID: "h1", Path: "/a", MaxDownloads: -1, ExpiresAt: future}, true},
+		{"过期时间早于当前时间", Link{Owner: "alice", HostID: "h1", Path: "/a", ExpiresAt: time.Now().Add(-time.Second)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := s.Create(tt.link, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create err = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordLockout(t *testing.T) {
+	s := newTestStore(t, "secret")
+	link, token := create(t, s, time.Hour, 0, "pw")
+
+	for i := 1; i < maxPasswordFailures; i++ {
+		if _, err := s.Resolve(token, "wrong"); !errors.Is(err, ErrWrongPassword) {
+			t.Fatalf("第 %d 次输错: err = %v, want ErrWrongPassword", i, err)
+		}
+	}
+	// 锁定前输对密码会清零错误次数
+	if _, err := s.Resolve(token, "pw"); err != nil {
+		t.Fatalf("锁定前输对密码: %v", err)
+	}
+	for i := 1; i <= maxPasswordFailures; i++ {
+		if _, err := s.Resolve(token, "wrong"); !errors.Is(err, ErrWrongPassword) {
+			t.Fatalf("第 %d 次输错: err = %v, want ErrWrongPassword", i, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{"锁定期间正确的密码同样被拒绝", "pw", ErrTooManyAttempts},
+		{"锁定期间错误的密码", "wrong", ErrTooManyAttempts},
+		{"锁定期间不提供密码", "", ErrPasswordRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Resolve(token, tt.password); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Resolve err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 锁定只影响该链接，到期后恢复
+	_, otherToken := create(t, s, time.Hour, 0, "pw")
+	if _, err := s.Resolve(otherToken, "pw"); err != nil {
+		t.Errorf("其他链接不应被锁定: %v", err)
+	}
+	s.links[link.ID].lockedUntil = time.Now().Add(-time.Second)
+	if _, err := s.Resolve(token, "pw"); err != nil {
+		t.Errorf("锁定到期后应恢复: %v", err)
+	}
+}
+
+func TestConcurrentPasswordGuesses(t *testing.T) {
+	s := newTestStore(t, "secret")
+	_, token := create(t, s, time.Hour, 0, "pw")
+
+	var mu sync.Mutex
+	counts := make(map[error]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 4*maxPasswordFailures; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Resolve(token, "wrong")
+			switch {
+			case errors.Is(err, ErrWrongPassword):
+				err = ErrWrongPassword
+			case errors.Is(err, ErrTooManyAttempts):
+				err = ErrTooManyAttempts
+			}
+			mu.Lock()
+			counts[err]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if counts[ErrWrongPassword] > maxPasswordFailures {
+		t.Errorf("并发猜测时校验了 %d 次密码，上限 %d 次", counts[ErrWrongPassword], maxPasswordFailures)
+	}
+	if counts[ErrWrongPassword]+counts[ErrTooManyAttempts] != 4*maxPasswordFailures {
+		t.Errorf("意外的结果: %v", counts)
+	}
+	if _, err := s.Resolve(token, "pw"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("猜测次数用完后应锁定: err = %v", err)
+	}
+}
+
+func TestLastAccessAt(t *testing.T) {
+	s := newTestStore(t, "secret")
+	tests := []struct {
+		name       string
+		password   string
+		input      string
+		wantAccess bool
+	}{
+		{"不需要密码", "", "", true},
+		{"缺少密码", "pw", "", false},
+		{"密码错误", "pw", "wrong", false},
+		{"密码正确", "pw", "pw", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, token := create(t, s, time.Hour, 0, tt.password)
+			s.Resolve(token, tt.input)
+			got, err := s.Get(link.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got.LastAccessAt != nil) != tt.wantAccess {
+				t.Errorf("LastAccessAt = %v, want 记录 %t", got.LastAccessAt, tt.wantAccess)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s := newTestStore(t, "secret")
+	active, _ := create(t, s, time.Hour, 0, "")
+	expiredLong, _ := create(t, s, time.Hour, 0, "")
+	expiredRecent, _ := create(t, s, time.Hour, 0, "")
+	revokedLong, _ := create(t, s, time.Hour, 0, "")
+	s.links[expiredLong.ID].ExpiresAt = time.Now().Add(-48 * time.Hour)
+	s.links[expiredRecent.ID].ExpiresAt = time.Now().Add(-time.Hour)
+	old := time.Now().Add(-48 * time.Hour)
+	s.links[revokedLong.ID].RevokedAt = &old
+
+	if n := s.Prune(24 * time.Hour); n != 2 {
+		t.Errorf("Prune = %d, want 2", n)
+	}
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{active.ID, true},
+		{expiredLong.ID, false},
+		{expiredRecent.ID, true},
+		{revokedLong.ID, false},
+	}
+	for _, tt := range tests {
+		if _, err := s.Get(tt.id); (err == nil) != tt.want {
+			t.Errorf("Get(%s) err = %v, want exists %t", tt.id, err, tt.want)
+		}
+	}
+}
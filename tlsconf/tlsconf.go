@@ -0,0 +1,168 @@
+package tlsconf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"file-transfer/config"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const defaultReloadInterval = time.Minute
+
+// reloader 持有当前的证书和客户端CA，握手时按间隔检查文件修改时间，有变化则重新加载
+type reloader struct {
+	cfg      config.TLSServerConfig
+	interval time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	checkedAt time.Time
+}
+
+// NewServerConfig 按配置创建服务端 TLS 配置，证书轮换后无需重启
+func NewServerConfig(cfg config.TLSServerConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("未配置证书或私钥")
+	}
+	if cfg.RequireClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.New("要求客户端证书时必须配置 ClientCAFile")
+	}
+
+	r := &reloader{cfg: cfg, interval: cfg.ReloadInterval}
+	if r.interval <= 0 {
+		r.interval = defaultReloadInterval
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	clientAuth := tls.NoClientCert
+	switch {
+	case cfg.RequireClientCert:
+		clientAuth = tls.RequireAndVerifyClientCert
+	case cfg.ClientCAFile != "":
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, clientCAs := r.current()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientAuth:   clientAuth,
+				ClientCAs:    clientCAs,
+				NextProtos:   []string{"h2", "http/1.1"},
+			}, nil
+		},
+	}, nil
+}
+
+// current 返回当前的证书和CA，必要时重新加载；加载失败时继续使用旧的证书
+func (r *reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) >= r.interval {
+		r.checkedAt = time.Now()
+		if r.changed() {
+			if err := r.loadLocked(); err != nil {
+				logx.Errorf("重新加载证书失败，继续使用旧证书: %v", err)
+			} else {
+				logx.Infof("证书已重新加载: %s", r.cfg.CertFile)
+			}
+		}
+	}
+	return r.cert, r.clientCAs
+}
+
+func (r *reloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// changed 判断证书相关文件的修改时间是否变化
+func (r *reloader) changed() bool {
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTimes[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *reloader) load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.loadLocked()
+}
+
+func (r *reloader) loadLocked() error {
+	// 先记录修改时间再读取，读取期间文件再次更新时下次检查仍能发现
+	modTimes := make(map[string]time.Time)
+	for _, f := range r.files() {
+		if info, err := os.Stat(f); err == nil {
+			modTimes[f] = info.ModTime()
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %v", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("读取客户端CA失败: %v", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return errors.New("客户端CA中没有有效的证书")
+		}
+	}
+
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	return nil
+}
+
+// UserFromCert 将已校验的客户端证书映射为用户名：依次查找 CN、DNS、Email、URI 是否在 CertUsers 中，
+// 都不在时若开启 CommonNameAsUser 则使用 CN
+func UserFromCert(cfg config.TLSServerConfig, cert *x509.Certificate) (string, bool) {
+	names := []string{cert.Subject.CommonName}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+
+	for _, name := range names {
+		if user, exists := cfg.CertUsers[name]; exists && name != "" {
+			return user, true
+		}
+	}
+	if cfg.CommonNameAsUser && cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName, true
+	}
+	return "", false
+}
@@ -0,0 +1,248 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"file-transfer/config"
+	"file-transfer/logs"
+	g "file-transfer/transfer/global"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// Fetch 从 HTTP(S) 地址获取文件的配置
+var Fetch config.FetchConfig
+
+const (
+	defaultFetchTimeout = 30 * time.Minute
+	maxFetchRedirects   = 10
+)
+
+// 获取方式
+const (
+	FetchStream = "stream" // 由本服务下载，边下载边写入目标服务器
+	FetchRemote = "remote" // 在目标服务器上用 curl/wget 下载
+)
+
+type FetchRequest struct {
+	CommonTransRequest                   // 目标服务器及文件路径
+	URL                string            `json:"url" binding:"required"` // 源地址，只支持 http 和 https
+	Headers            map[string]string `json:"headers"`                // 可选，请求头
+	SourceUser         string            `json:"source_user"`            // 可选，源地址的 HTTP Basic 认证
+	SourcePassword     string            `json:"source_password"`
+	SHA256             string            `json:"sha256"`          // 可选，校验和，不一致时不写入目标文件
+	Mode               string            `json:"mode"`            // 可选，八进制权限，默认 0644
+	Method             string            `json:"method"`          // stream（默认）/ remote（需要目标服务器上有 curl）
+	FollowRedirect     bool              `json:"follow_redirect"` // 是否跟随重定向，只支持 stream 方式
+}
+
+// fetchHostAllowed 判断源主机是否在允许的范围内，未配置允许的主机时一律拒绝
+func fetchHostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range Fetch.AllowedHosts {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchIPAllowed 判断源地址解析出的IP是否允许访问，拒绝回环、私有、链路本地、组播和未指定地址，
+// 防止通过允许的主机名（或其重定向、DNS 记录）访问内网服务
+func fetchIPAllowed(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// fetchDialControl 在建立连接前检查 DNS 解析后的实际地址，每次连接（包括重定向后的连接）都会检查
+func fetchDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !fetchIPAllowed(ip) {
+		return fmt.Errorf("%w: 不允许访问内网地址 %s", g.ErrAccessDenied, host)
+	}
+	return nil
+}
+
+// checkFetchHost 解析源主机并检查所有地址，用于在目标服务器上下载的方式（无法控制其连接），
+// 返回 curl --resolve 格式的 host:port:ip，目标服务器上的 curl 只连接这里检查过的地址。
+// 目标服务器所在的网络与本服务不同，能访问到的内网地址无法在这里检查，这种方式主要依靠 AllowedHosts 限制源地址
+func checkFetchHost(ctx context.Context, u *url.URL) (string, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return "", fmt.Errorf("%w: 解析源主机失败: %v", g.ErrFetchFailed, err)
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("%w: 源主机 %s 没有地址", g.ErrFetchFailed, u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !fetchIPAllowed(addr.IP) {
+			return "", fmt.Errorf("%w: 不允许访问内网地址 %s", g.ErrAccessDenied, addr.IP)
+		}
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	ip := addrs[0].IP.String()
+	if addrs[0].IP.To4() == nil {
+		ip = "[" + ip + "]"
+	}
+	return u.Hostname() + ":" + port + ":" + ip, nil
+}
+
+// fetchTransport 获取源文件共用的连接：不使用代理，连接前检查实际地址，空闲连接到期后关闭
+var fetchTransport = &http.Transport{
+	DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: fetchDialControl}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          16,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// fetchClient 返回获取源文件使用的 HTTP 客户端，重定向的目标同样需要在允许的范围内
+func fetchClient(follow bool) *http.Client {
+	return &http.Client{
+		Transport: fetchTransport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !follow {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("%w: 重定向次数过多", g.ErrFetchFailed)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" || !fetchHostAllowed(req.URL.Hostname()) {
+				return fmt.Errorf("%w: 不允许重定向到 %s", g.ErrAccessDenied, req.URL.Host)
+			}
+			return nil
+		},
+	}
+}
+
+// 获取文件失败时的HTTP状态码：源地址或其重定向不允许访问返回403，源地址请求失败返回502，
+// 超时返回504，校验和不一致返回422
+func fetchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, g.ErrAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, g.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, g.ErrChecksumMismatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, g.ErrFetchFailed):
+		return http.StatusBadGateway
+	}
+	return accessErrorStatus(err, http.StatusInternalServerError)
+}
+
+// 从 HTTP(S) 地址获取文件并写入目标服务器
+func FetchURL(c *gin.Context) {
+	username := c.GetString("username")
+
+	var request FetchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		logs.Sugar.Errorw("获取文件", "username", username, "detail", "解析请求失败，请检查请求格式是否正确")
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+	if request.Method == "" {
+		request.Method = FetchStream
+	}
+	if request.Method != FetchStream && request.Method != FetchRemote {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "method 只能为 stream 或 remote"})
+		return
+	}
+	// 目标服务器上的 curl/wget 跟随重定向时无法逐个检查重定向的目标
+	if request.Method == FetchRemote && request.FollowRedirect {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "remote 方式不支持跟随重定向，请使用 stream 方式"})
+		return
+	}
+	mode := os.FileMode(0644)
+	if request.Mode != "" {
+		perm, err := strconv.ParseUint(request.Mode, 8, 32)
+		if err != nil || perm > 0o777 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("无效的权限: %s", request.Mode)})
+			return
+		}
+		mode = os.FileMode(perm)
+	}
+
+	src := g.URLSource{
+		URL:      request.URL,
+		Headers:  request.Headers,
+		Username: request.SourceUser,
+		Password: request.SourcePassword,
+		SHA256:   request.SHA256,
+	}
+	u, err := src.Validate()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if !fetchHostAllowed(u.Hostname()) {
+		logs.Sugar.Errorw("获取文件", "username", username, "detail", "源地址不在允许的范围内："+u.Host)
+		c.JSON(http.StatusForbidden, gin.H{"message": fmt.Sprintf("源地址 %s 不在允许的范围内", u.Host)})
+		return
+	}
+
+	target, ok := fileTarget(c, "获取文件", request.CommonTransRequest)
+	if !ok {
+		return
+	}
+
+	timeout := Fetch.Timeout
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	var result g.FetchResult
+	if request.Method == FetchRemote {
+		if src.Resolve, err = checkFetchHost(ctx, u); err == nil {
+			result, err = g.FTS.CreateRemoteFetchTask(ctx, src, mode, target.Server, request.Path)
+		}
+	} else {
+		result, err = g.FTS.CreateURLUploadTask(ctx, fetchClient(request.FollowRedirect), src, mode, target.Server, request.Path)
+	}
+	source := redactURL(u)
+	if err != nil {
+		logx.Errorf("获取文件失败: %v", err)
+		logs.Sugar.Errorw("获取文件", "username", username, "detail", fmt.Sprintf("获取文件失败，源地址：%s，服务器：%s，原因：%v", source, target.Hostname(), err))
+		c.JSON(fetchErrorStatus(err), gin.H{"message": fmt.Sprintf("获取文件失败: %v", err), "task_id": result.TaskID})
+		return
+	}
+
+	logs.Sugar.Infow("获取文件", "username", username, "detail", fmt.Sprintf("获取文件成功，任务ID：%s，源地址：%s，服务器：%s，路径：%s，大小：%d", result.TaskID, source, target.Hostname(), request.Path, result.Size))
+	c.JSON(http.StatusOK, gin.H{"message": "获取文件完成", "task_id": result.TaskID, "size": result.Size, "sha256": result.SHA256, "method": request.Method, "tool": result.Tool})
+}
+
+// redactURL 去掉地址中的用户信息和查询参数（可能带有令牌），用于记录日志
+func redactURL(u *url.URL) string {
+	r := *u
+	r.User = nil
+	r.RawQuery = ""
+	r.Fragment = ""
+	return r.String()
+}
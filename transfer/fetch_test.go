@@ -0,0 +1,148 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"file-transfer/config"
+	g "file-transfer/transfer/global"
+)
+
+// useFetch 设置获取文件的配置，测试结束后恢复
+func useFetch(t *testing.T, cfg config.FetchConfig) {
+	t.Helper()
+	old := Fetch
+	Fetch = cfg
+	t.Cleanup(func() { Fetch = old })
+}
+
+func TestFetchHostAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		host    string
+		want    bool
+	}{
+		{"未配置时拒绝", nil, "example.com", false},
+		{"完全匹配", []string{"downloads.example.com"}, "downloads.example.com", true},
+		{"不区分大小写", []string{"Downloads.example.com"}, "DOWNLOADS.example.com", true},
+		{"通配子域名", []string{"*.example.com"}, "a.example.com", true},
+		{"通配不匹配上级域名", []string{"*.example.com"}, "example.com", false},
+		{"通配不跨越多级", []string{"*.example.com"}, "evil.com.example.com.attacker", false},
+		{"后缀相同的其他域名", []string{"example.com"}, "badexample.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFetch(t, config.FetchConfig{AllowedHosts: tt.allowed})
+			if got := fetchHostAllowed(tt.host); got != tt.want {
+				t.Errorf("fetchHostAllowed(%q) = %t, want %t", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchIPAllowed(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := fetchIPAllowed(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("fetchIPAllowed(%s) = %t, want %t", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckFetchHost(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr error
+	}{
+		{"https://8.8.8.8/a.tar.gz", "8.8.8.8:443:8.8.8.8", nil},
+		{"http://8.8.8.8/a.tar.gz", "8.8.8.8:80:8.8.8.8", nil},
+		{"http://8.8.8.8:8080/a", "8.8.8.8:8080:8.8.8.8", nil},
+		{"https://[2001:4860:4860::8888]:8443/a", "2001:4860:4860::8888:8443:[2001:4860:4860::8888]", nil},
+		{"http://127.0.0.1/a", "", g.ErrAccessDenied},
+		{"http://169.254.169.254/latest/meta-data/", "", g.ErrAccessDenied},
+		{"http://[::1]:8080/a", "", g.ErrAccessDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := checkFetchHost(context.Background(), u)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkFetchHost err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("checkFetchHost = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchClient(t *testing.T) {
+	useFetch(t, config.FetchConfig{AllowedHosts: []string{"*.example.com"}})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// 连接前检查实际地址，允许的主机名解析到内网地址时同样拒绝
+	if _, err := fetchClient(true).Get(srv.URL); !errors.Is(err, g.ErrAccessDenied) {
+		t.Errorf("访问回环地址 err = %v, want ErrAccessDenied", err)
+	}
+	if fetchErrorStatus(g.ErrAccessDenied) != http.StatusForbidden {
+		t.Error("不允许访问的地址应返回 403")
+	}
+
+	// 所有请求共用同一个连接池，避免每次获取都留下空闲连接
+	if fetchClient(true).Transport != fetchClient(false).Transport {
+		t.Error("每次获取不应创建新的 Transport")
+	}
+
+	tests := []struct {
+		name    string
+		follow  bool
+		target  string
+		via     int
+		wantErr error
+	}{
+		{"不跟随重定向", false, "https://a.example.com/x", 1, http.ErrUseLastResponse},
+		{"重定向到允许的主机", true, "https://a.example.com/x", 1, nil},
+		{"重定向到其他主机", true, "https://evil.com/x", 1, g.ErrAccessDenied},
+		{"重定向到其他协议", true, "ftp://a.example.com/x", 1, g.ErrAccessDenied},
+		{"重定向次数过多", true, "https://a.example.com/x", maxFetchRedirects, g.ErrFetchFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = fetchClient(tt.follow).CheckRedirect(req, make([]*http.Request, tt.via))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckRedirect err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"file-transfer/logs"
+	g "file-transfer/transfer/global"
+	trans "file-transfer/transfer/trans-init"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ListDirRequest struct {
+	CommonTransRequest
+	Offset    int    `json:"offset" form:"offset"`
+	Limit     int    `json:"limit" form:"limit"`           // 默认 100，最大 1000
+	SortBy    string `json:"sort_by" form:"sort_by"`       // name / size / mtime，默认 name
+	Order     string `json:"order" form:"order"`           // asc / desc，默认 asc
+	DirsFirst bool   `json:"dirs_first" form:"dirs_first"` // 目录排在文件前面
+}
+
+type MkdirRequest struct {
+	CommonTransRequest
+	Parents bool `json:"parents" form:"parents"` // 同时创建不存在的上级目录
+}
+
+type RemoveRequest struct {
+	CommonTransRequest
+	Recursive bool `json:"recursive" form:"recursive"` // 删除整个目录
+}
+
+type RenameRequest struct {
+	CommonTransRequest
+	NewPath   string `json:"new_path" form:"new_path" binding:"required"`
+	Overwrite bool   `json:"overwrite" form:"overwrite"` // 覆盖已存在的目标
+}
+
+type ChmodRequest struct {
+	CommonTransRequest
+	Mode string `json:"mode" form:"mode" binding:"required"` // 八进制权限，如 0644
+}
+
+type SymlinkRequest struct {
+	CommonTransRequest        // Path 为要创建的链接
+	Target             string `json:"target" form:"target" binding:"required"` // 链接指向的路径
+}
+
+// fileTarget 解析请求中的服务器、校验归属并确保连接可用；失败时已写入响应
+func fileTarget(c *gin.Context, operation string, request CommonTransRequest) (trans.SSHTarget, bool) {
+	username := c.GetString("username")
+
+	target, err := ResolveTarget(request.HostID, request.Server, request.User, request.Auth, request.Proxy)
+	if err != nil {
+		logx.Errorf("解析服务器失败: %v", err)
+		c.JSON(resolveErrorStatus(err), gin.H{"message": fmt.Sprintf("解析服务器失败: %v", err)})
+		return trans.SSHTarget{}, false
+	}
+
+	flag, err := CheckServerBelongs(c.Request.Context(), username, target.Hostname())
+	if err != nil {
+		logx.Errorf("查询服务器与用户（所在公司）的关系失败: %v", err)
+		c.JSON(belongsErrorStatus(err), gin.H{"message": fmt.Sprintf("查询服务器与用户（所在公司）的关系失败: %v", err)})
+		return trans.SSHTarget{}, false
+	}
+	if !flag {
+		logx.Error("该服务器不属于用户（所在公司）")
+		logs.Sugar.Errorw(operation, "username", username, "detail", "该服务器不属于用户（所在公司）")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "该服务器不属于用户（所在公司）"})
+		return trans.SSHTarget{}, false
+	}
+
+	if _, err := trans.EnsureConnection(g.Pool, target); err != nil {
+		logx.Errorf("创建与目标服务器的连接失败: %v", err)
+		c.JSON(connectErrorStatus(err), gin.H{"message": fmt.Sprintf("创建与目标服务器的连接失败: %v", err)})
+		return trans.SSHTarget{}, false
+	}
+	return target, true
+}
+
+// 文件管理操作失败时的HTTP状态码：文件不存在返回404，已存在返回409，远程权限不足返回403
+func fileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, g.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, os.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, os.ErrExist):
+		return http.StatusConflict
+	case errors.Is(err, os.ErrPermission):
+		return http.StatusForbidden
+	}
+	return accessErrorStatus(err, http.StatusBadRequest)
+}
+
+// bindFileRequest 解析请求，失败时已写入响应
+func bindFileRequest(c *gin.Context, request any) bool {
+	if err := c.ShouldBind(request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return false
+	}
+	return true
+}
+
+// 列出远程目录
+func ListDir(c *gin.Context) {
+	var request ListDirRequest
+	if !bindFileRequest(c, &request) {
+		return
+	}
+	if request.Order != "" && request.Order != "asc" && request.Order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "order 只能为 asc 或 desc"})
+		return
+	}
+	target, ok := fileTarget(c, "查看目录", request.CommonTransRequest)
+	if !ok {
+		return
+	}
+
+	result, err := g.FTS.ListDir(c.Request.Context(), target.Server, request.Path, g.ListOptions{
+		Offset:    request.Offset,
+		Limit:     request.Limit,
+		SortBy:    request.SortBy,
+		Desc:      request.Order == "desc",
+		DirsFirst: request.DirsFirst,
+	})
+	if err != nil {
+		logx.Errorf("读取目录失败: %v", err)
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("读取目录失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// 查看远程文件信息
+func StatFile(c *gin.Context) {
+	var request CommonTransRequest
+	if !bindFileRequest(c, &request) {
+		return
+	}
+	target, ok := fileTarget(c, "查看文件信息", request)
+	if !ok {
+		return
+	}
+
+	info, err := g.FTS.Stat(c.Request.Context(), target.Server, request.Path)
+	if err != nil {
+		logx.Errorf("获取文件信息失败: %v", err)
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("获取文件信息失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"file": info})
+}
+
+// 创建远程目录
+func MakeDir(c *gin.Context) {
+	username := c.GetString("username")
+	var request MkdirRequest
+	if !bindFileRequest(c, &request) {
+		return
+	}
+	target, ok := fileTarget(c, "创建目录", request.CommonTransRequest)
+	if !ok {
+		return
+	}
+
+	dir, err := g.FTS.Mkdir(c.Request.Context(), target.Server, request.Path, request.Parents)
+	if err != nil {
+		logx.Errorf("创建目录失败: %v", err)
+		logs.Sugar.Errorw("创建目录", "username", username, "detail", "创建目录失败："+err.Error())
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("创建目录失败: %v", err)})
+		return
+	}
+	logs.Sugar.Infow("创建目录", "username", username, "detail", "服务器："+target.Hostname()+"，路径："+dir)
+	c.JSON(http.StatusOK, gin.H{"message": "创建目录成功", "path": dir})
+}
+
+// 删除远程文件或目录
+func RemoveFile(c *gin.Context) {
+	username := c.GetString("username")
+	var request RemoveRequest
+	if !bindFileRequest(c, &request) {
+		return
+	}
+	target, ok := fileTarget(c, "删除文件", request.CommonTransRequest)
+	if !ok {
+		return
+	}
+
+	removed, err := g.FTS.Remove(c.Request.Context(), target.Server, request.Path, request.Recursive)
+	if err != nil {
+		logx.Errorf("删除失败: %v", err)
+		logs.Sugar.Errorw("删除文件", "username", username, "detail", "删除失败："+err.Error())
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("删除失败: %v", err)})
+		return
+	}
+	logs.Sugar.Infow("删除文件", "username", username, "detail", "服务器："+target.Hostname()+"，路径："+removed)
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功", "path": removed})
+}
+
+// 重命名或移动远程文件
+func RenameFile(c *gin.Context) {
+	username := c.GetString("username")
+	var request RenameRequest
+	if !bindFileRequest(c, &request) {
+		return
+	}
+	target, ok := fileTarget(c, "重命名文件", request.CommonTransRequest)
+	if !ok {
+		return
+	}
+
+	from, to, err := g.FTS.Rename(c.Request.Context(), target.Server, request.Path, request.NewPath, request.Overwrite)
+	if err != nil {
+		logx.Errorf("重命名失败: %v", err)
+		logs.Sugar.Errorw("重命名文件", "username", username, "detail", "重命名失败："+err.Error())
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("重命名失败: %v", err)})
+		return
+	}
+	logs.Sugar.Infow("重命名文件", "username", username, "detail", "服务器："+target.Hostname()+"，"+from+" -> "+to)
+	c.JSON(http.StatusOK, gin.H{"message": "重命名成功", "path": to})
+}
+
+// 修改远程文件权限
+func ChmodFile(c *gin.Context) {
+	username := c.GetString("username")
+	var request ChmodRequest
+	if !bindFileRequest(c, &request) {
+		return
+	}
+	mode, err := strconv.ParseUint(request.Mode, 8, 32)
+	if err != nil || mode > 0o777 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("无效的权限: %s", request.Mode)})
+		return
+	}
+	target, ok := fileTarget(c, "修改文件权限", request.CommonTransRequest)
+	if !ok {
+		return
+	}
+
+	changed, err := g.FTS.Chmod(c.Request.Context(), target.Server, request.Path, os.FileMode(mode))
+	if err != nil {
+		logx.Errorf("修改权限失败: %v", err)
+		logs.Sugar.Errorw("修改文件权限", "username", username, "detail", "修改权限失败："+err.Error())
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("修改权限失败: %v", err)})
+		return
+	}
+	logs.Sugar.Infow("修改文件权限", "username", username, "detail", fmt.Sprintf("服务器：%s，路径：%s，权限：%#o", target.Hostname(), changed, mode))
+	c.JSON(http.StatusOK, gin.H{"message": "修改权限成功", "path": changed})
+}
+
+// 创建符号链接
+func SymlinkFile(c *gin.Context) {
+	username := c.GetString("username")
+	var request SymlinkRequest
+	if !bindFileRequest(c, &request) {
+		return
+	}
+	target, ok := fileTarget(c, "创建符号链接", request.CommonTransRequest)
+	if !ok {
+		return
+	}
+
+	link, err := g.FTS.Symlink(c.Request.Context(), target.Server, request.Target, request.Path)
+	if err != nil {
+		logx.Errorf("创建符号链接失败: %v", err)
+		logs.Sugar.Errorw("创建符号链接", "username", username, "detail", "创建符号链接失败："+err.Error())
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("创建符号链接失败: %v", err)})
+		return
+	}
+	logs.Sugar.Infow("创建符号链接", "username", username, "detail", "服务器："+target.Hostname()+"，"+link+" -> "+request.Target)
+	c.JSON(http.StatusOK, gin.H{"message": "创建符号链接成功", "path": link})
+}
+
+// 读取符号链接指向的路径
+func ReadLink(c *gin.Context) {
+	var request CommonTransRequest
+	if !bindFileRequest(c, &request) {
+		return
+	}
+	target, ok := fileTarget(c, "读取符号链接", request)
+	if !ok {
+		return
+	}
+
+	dest, err := g.FTS.Readlink(c.Request.Context(), target.Server, request.Path)
+	if err != nil {
+		logx.Errorf("读取符号链接失败: %v", err)
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("读取符号链接失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"target": dest})
+}
@@ -0,0 +1,135 @@
+package global
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pkg/sftp"
+)
+
+// 访问类型
+const (
+	AccessRead  = "read"  // 读取（下载、作为传输源）
+	AccessWrite = "write" // 写入（上传、作为传输目标）
+)
+
+var (
+	ErrAccessDenied = errors.New("无权访问该路径")
+	ErrFileTooLarge = errors.New("文件大小超出限制")
+)
+
+// AccessRequest 一次远程文件访问，Path 已经过规范化
+type AccessRequest struct {
+	Server string // 主机（地址，非默认端口时带端口），不含凭据指纹
+	Path   string
+	Access string // read / write
+	Size   int64  // 写入的字节数，未知时为 -1
+}
+
+// AccessGrant 访问检查的结果
+type AccessGrant struct {
+	MaxSize int64 // 允许写入的最大字节数，0 表示不限制
+}
+
+// AccessGuard 在打开或创建远程文件前调用，返回错误时拒绝访问；为 nil 时不做限制
+type AccessGuard func(ctx context.Context, req AccessRequest) (AccessGrant, error)
+
+// resolvePath 将远程路径规范化为绝对路径：相对路径以登录目录为基准，
+// 清除 . 和 ..，并由服务端解析符号链接，防止借助 ../ 或链接绕过访问策略
+func resolvePath(sftpClient *sftp.Client, p string) (string, error) {
+	return resolve(sftpClient, p, true)
+}
+
+// resolveEntryPath 与 resolvePath 相同，但不解析最后一级的符号链接，用于操作链接本身（删除、重命名、读取链接等）
+func resolveEntryPath(sftpClient *sftp.Client, p string) (string, error) {
+	return resolve(sftpClient, p, false)
+}
+
+func resolve(sftpClient *sftp.Client, p string, followLast bool) (string, error) {
+	if p == "" || strings.ContainsRune(p, 0) {
+		return "", fmt.Errorf("无效的路径: %q", p)
+	}
+	if !path.IsAbs(p) {
+		wd, err := sftpClient.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("获取登录目录失败: %v", err)
+		}
+		p = path.Join(wd, p)
+	}
+	p = path.Clean(p)
+
+	// 文件已存在时解析整个路径，否则只解析所在目录，文件名保持不变
+	if followLast || p == "/" {
+		if real, err := sftpClient.RealPath(p); err == nil && path.IsAbs(real) {
+			if _, err := sftpClient.Lstat(p); err == nil {
+				return path.Clean(real), nil
+			}
+		}
+	}
+	dir, name := path.Split(p)
+	if real, err := sftpClient.RealPath(dir); err == nil && path.IsAbs(real) {
+		return path.Join(real, name), nil
+	}
+	return p, nil
+}
+
+// authorize 规范化路径并执行访问检查，返回实际应访问的路径
+func (fts *FileTransferServiceImpl) authorize(ctx context.Context, sftpClient *sftp.Client, server, p, access string, size int64) (string, AccessGrant, error) {
+	resolved, err := resolvePath(sftpClient, p)
+	if err != nil {
+		return "", AccessGrant{}, err
+	}
+	grant, err := fts.check(ctx, server, resolved, access, size)
+	return resolved, grant, err
+}
+
+// authorizeEntry 与 authorize 相同，但不解析最后一级的符号链接
+func (fts *FileTransferServiceImpl) authorizeEntry(ctx context.Context, sftpClient *sftp.Client, server, p, access string) (string, error) {
+	resolved, err := resolveEntryPath(sftpClient, p)
+	if err != nil {
+		return "", err
+	}
+	_, err = fts.check(ctx, server, resolved, access, -1)
+	return resolved, err
+}
+
+// check 对已规范化的路径执行访问检查
+func (fts *FileTransferServiceImpl) check(ctx context.Context, server, resolved, access string, size int64) (AccessGrant, error) {
+	if fts.Guard == nil {
+		return AccessGrant{}, nil
+	}
+
+	grant, err := fts.Guard(ctx, AccessRequest{Server: HostOf(server), Path: resolved, Access: access, Size: size})
+	if err != nil {
+		return AccessGrant{}, err
+	}
+	if grant.MaxSize > 0 && size > grant.MaxSize {
+		return AccessGrant{}, fmt.Errorf("%w: %d 字节，上限 %d 字节", ErrFileTooLarge, size, grant.MaxSize)
+	}
+	return grant, nil
+}
+
+// limitWriter 写入超过上限时返回 ErrFileTooLarge，用于大小未知的写入
+type limitWriter struct {
+	w       io.Writer
+	remain  int64
+	limited bool
+}
+
+func newLimitWriter(w io.Writer, grant AccessGrant) io.Writer {
+	return &limitWriter{w: w, remain: grant.MaxSize, limited: grant.MaxSize > 0}
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if l.limited {
+		if int64(len(p)) > l.remain {
+			return 0, ErrFileTooLarge
+		}
+		l.remain -= int64(len(p))
+	}
+	return l.w.Write(p)
+}
@@ -0,0 +1,168 @@
+package global
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+)
+
+// ErrHostUnavailable 主机处于熔断状态，在退避时间内不再发起拨号
+var ErrHostUnavailable = errors.New("主机不可用")
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"    // 正常拨号
+	BreakerOpen     = "open"      // 熔断中，直接拒绝
+	BreakerHalfOpen = "half-open" // 退避结束，允许一次试探拨号
+)
+
+// HostUnavailableError 携带熔断详情的错误，可通过 errors.Is(err, ErrHostUnavailable) 判断
+type HostUnavailableError struct {
+	Server     string
+	RetryAfter time.Duration
+	LastError  string
+}
+
+func (e *HostUnavailableError) Error() string {
+	return fmt.Sprintf("主机 %s 不可用，%s 后重试（最近一次错误：%s）",
+		e.Server, e.RetryAfter.Round(time.Second), e.LastError)
+}
+
+func (e *HostUnavailableError) Unwrap() error {
+	return ErrHostUnavailable
+}
+
+// hostBreaker 记录单个主机的拨号失败情况
+type hostBreaker struct {
+	failures    int       // 连续失败次数
+	openUntil   time.Time // 熔断截止时间
+	probing     bool      // 半开状态下是否已有试探拨号在进行
+	lastError   string
+	lastFailure time.Time
+}
+
+// BreakerInfo 熔断器状态快照，供管理接口展示
+type BreakerInfo struct {
+	Server      string    `json:"server"`
+	State       string    `json:"state"`
+	Failures    int       `json:"failures"`
+	OpenUntil   time.Time `json:"open_until"`
+	LastError   string    `json:"last_error"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+func (b *hostBreaker) state(now time.Time) string {
+	switch {
+	case b.openUntil.IsZero():
+		return BreakerClosed
+	case now.Before(b.openUntil):
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// AllowDial 判断是否允许向主机拨号，熔断期间返回 *HostUnavailableError
+func (p *SSHConnectionPool) AllowDial(server string) error {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+
+	b, exists := p.breakers[server]
+	if !exists {
+		return nil
+	}
+
+	now := time.Now()
+	switch b.state(now) {
+	case BreakerOpen:
+		return &HostUnavailableError{Server: server, RetryAfter: b.openUntil.Sub(now), LastError: b.lastError}
+	case BreakerHalfOpen:
+		if b.probing { // 已有试探拨号，其余请求继续快速失败
+			return &HostUnavailableError{Server: server, LastError: b.lastError}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// DialFailed 记录一次拨号失败，连续失败达到阈值后按指数退避打开熔断器
+func (p *SSHConnectionPool) DialFailed(server string, err error) {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+
+	if p.breakers == nil {
+		p.breakers = make(map[string]*hostBreaker)
+	}
+	b, exists := p.breakers[server]
+	if !exists {
+		b = &hostBreaker{}
+		p.breakers[server] = b
+	}
+
+	now := time.Now()
+	b.failures++
+	b.probing = false
+	b.lastError = err.Error()
+	b.lastFailure = now
+
+	if p.BreakerThreshold <= 0 || b.failures < p.BreakerThreshold { // 阈值<=0 表示不启用熔断
+		return
+	}
+	backoff := p.BreakerBackoff
+	for i := p.BreakerThreshold; i < b.failures && backoff < p.BreakerMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.BreakerMaxBackoff {
+		backoff = p.BreakerMaxBackoff
+	}
+	b.openUntil = now.Add(backoff)
+}
+
+// DialSucceeded 主机可达，关闭熔断器并清空失败记录
+func (p *SSHConnectionPool) DialSucceeded(server string) {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+
+	delete(p.breakers, server)
+}
+
+// ResetBreaker 手动关闭指定主机的熔断器
+func (p *SSHConnectionPool) ResetBreaker(server string) bool {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+
+	if _, exists := p.breakers[server]; !exists {
+		return false
+	}
+	delete(p.breakers, server)
+	return true
+}
+
+// Breakers 返回所有存在失败记录的主机的熔断器状态，按服务器排序
+func (p *SSHConnectionPool) Breakers() []BreakerInfo {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+
+	now := time.Now()
+	infos := make([]BreakerInfo, 0, len(p.breakers))
+	for server, b := range p.breakers {
+		infos = append(infos, BreakerInfo{
+			Server:      server,
+			State:       b.state(now),
+			Failures:    b.failures,
+			OpenUntil:   b.openUntil,
+			LastError:   b.lastError,
+			LastFailure: b.lastFailure,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Server < infos[j].Server })
+	return infos
+}
+
+// IsHostFailure 判断拨号错误是否说明主机不可达（网络错误、超时），认证失败等不计入熔断
+func IsHostFailure(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,120 @@
+package global
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newBreakerPool 创建只用于熔断测试的连接池
+func newBreakerPool(threshold int, backoff, maxBackoff time.Duration) *SSHConnectionPool {
+	return &SSHConnectionPool{BreakerThreshold: threshold, BreakerBackoff: backoff, BreakerMaxBackoff: maxBackoff}
+}
+
+// expire 让主机的熔断退避立即结束，进入半开状态
+func expire(p *SSHConnectionPool, server string) {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+	p.breakers[server].openUntil = time.Now().Add(-time.Millisecond)
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	p := newBreakerPool(3, time.Second, 10*time.Second)
+	dialErr := errors.New("connection refused")
+
+	for i := 1; i < 3; i++ {
+		p.DialFailed("h1", dialErr)
+		if err := p.AllowDial("h1"); err != nil {
+			t.Fatalf("第 %d 次失败后不应熔断: %v", i, err)
+		}
+	}
+	p.DialFailed("h1", dialErr)
+	err := p.AllowDial("h1")
+	var unavailable *HostUnavailableError
+	if !errors.Is(err, ErrHostUnavailable) || !errors.As(err, &unavailable) {
+		t.Fatalf("达到阈值后应熔断: err = %v", err)
+	}
+	if unavailable.LastError != dialErr.Error() || unavailable.RetryAfter <= 0 || unavailable.RetryAfter > time.Second {
+		t.Errorf("熔断详情 = %+v", unavailable)
+	}
+	if err := p.AllowDial("h2"); err != nil {
+		t.Errorf("其他主机不受影响: %v", err)
+	}
+	if info := p.Breakers(); len(info) != 1 || info[0].Server != "h1" || info[0].State != BreakerOpen || info[0].Failures != 3 {
+		t.Errorf("Breakers = %+v", info)
+	}
+}
+
+func TestBreakerBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{4, 4 * time.Second},
+		{5, 5 * time.Second}, // 不超过上限
+		{8, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		p := newBreakerPool(2, time.Second, 5*time.Second)
+		for i := 0; i < tt.failures; i++ {
+			p.DialFailed("h1", errors.New("timeout"))
+		}
+		got := time.Until(p.Breakers()[0].OpenUntil)
+		if got > tt.want || got < tt.want-time.Second/2 {
+			t.Errorf("连续失败 %d 次的退避时间 = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		probe     func(p *SSHConnectionPool)
+		wantAllow bool
+		wantState string
+	}{
+		{"试探成功后关闭", func(p *SSHConnectionPool) { p.DialSucceeded("h1") }, true, ""},
+		{"试探失败后重新熔断", func(p *SSHConnectionPool) { p.DialFailed("h1", errors.New("timeout")) }, false, BreakerOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newBreakerPool(1, time.Minute, time.Hour)
+			p.DialFailed("h1", errors.New("timeout"))
+			expire(p, "h1")
+
+			if err := p.AllowDial("h1"); err != nil {
+				t.Fatalf("半开状态应允许一次试探: %v", err)
+			}
+			if err := p.AllowDial("h1"); !errors.Is(err, ErrHostUnavailable) {
+				t.Fatalf("试探进行中其余拨号应快速失败: err = %v", err)
+			}
+
+			tt.probe(p)
+			if err := p.AllowDial("h1"); (err == nil) != tt.wantAllow {
+				t.Errorf("试探结束后 AllowDial err = %v, want allow %t", err, tt.wantAllow)
+			}
+			state := ""
+			if info := p.Breakers(); len(info) > 0 {
+				state = info[0].State
+			}
+			if state != tt.wantState {
+				t.Errorf("熔断器状态 = %q, want %q", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	p := newBreakerPool(-1, time.Second, time.Second)
+	for i := 0; i < 10; i++ {
+		p.DialFailed("h1", errors.New("timeout"))
+	}
+	if err := p.AllowDial("h1"); err != nil {
+		t.Errorf("阈值为负数时不应熔断: %v", err)
+	}
+	if !p.ResetBreaker("h1") || p.ResetBreaker("h1") {
+		t.Error("ResetBreaker 应清除失败记录且只成功一次")
+	}
+}
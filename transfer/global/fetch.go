@@ -0,0 +1,341 @@
+package global
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/sftp"
+	"github.com/zeromicro/go-zero/core/logx"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	ErrFetchFailed      = errors.New("获取源文件失败")
+	ErrChecksumMismatch = errors.New("文件内容的 sha256 不一致")
+)
+
+// URLSource 从 HTTP(S) 地址获取的文件
+type URLSource struct {
+	URL      string
+	Headers  map[string]string // 可选，请求头
+	Username string            // 可选，HTTP Basic 认证
+	Password string
+	SHA256   string // 可选，十六进制的 SHA-256 校验和，不一致时不写入目标文件
+	Resolve  string // 可选，host:port:ip，在目标服务器上下载时将源主机固定解析到已检查过的地址
+}
+
+// Validate 检查地址的协议，并拒绝含有换行的参数（会被拼接到请求头和 curl/wget 的配置中）
+func (s URLSource) Validate() (*url.URL, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 无效的地址: %v", ErrInvalidArgument, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%w: 只支持 http 和 https 地址", ErrInvalidArgument)
+	}
+	values := []string{s.URL, s.Username, s.Password, s.Resolve}
+	for k, v := range s.Headers {
+		if k == "" || strings.ContainsAny(k, ": ") {
+			return nil, fmt.Errorf("%w: 无效的请求头 %q", ErrInvalidArgument, k)
+		}
+		values = append(values, k, v)
+	}
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n\x00") {
+			return nil, fmt.Errorf("%w: 参数中不能含有换行", ErrInvalidArgument)
+		}
+	}
+	if s.SHA256 != "" {
+		if b, err := hex.DecodeString(s.SHA256); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("%w: 无效的 sha256", ErrInvalidArgument)
+		}
+	}
+	return u, nil
+}
+
+// FetchResult 获取结果
+type FetchResult struct {
+	TaskID string `json:"task_id"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+	Tool   string `json:"tool,omitempty"` // 在目标服务器上下载时使用的命令
+}
+
+// CreateURLUploadTask 由本服务下载 src，边下载边写入远程文件；client 为 nil 时使用 http.DefaultClient，
+// 重定向和允许访问的地址由 client 控制
+func (fts *FileTransferServiceImpl) CreateURLUploadTask(ctx context.Context, client *http.Client, src URLSource, mode os.FileMode, server, path string) (FetchResult, error) {
+	if _, err := src.Validate(); err != nil {
+		return FetchResult{}, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.URL, nil)
+	if err != nil {
+		return FetchResult{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	for k, v := range src.Headers {
+		req.Header.Set(k, v)
+	}
+	if src.Username != "" || src.Password != "" {
+		req.SetBasicAuth(src.Username, src.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return FetchResult{}, fmt.Errorf("%w: %w", ErrFetchFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return FetchResult{}, fmt.Errorf("%w: 源地址返回 %s", ErrFetchFailed, resp.Status)
+	}
+
+	size := resp.ContentLength
+	h := sha256.New()
+	verify := func(n int64) error {
+		if size >= 0 && n != size {
+			return fmt.Errorf("%w: 收到 %d 字节，Content-Length 为 %d", ErrFetchFailed, n, size)
+		}
+		if src.SHA256 != "" && !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), src.SHA256) {
+			return ErrChecksumMismatch
+		}
+		return nil
+	}
+	counter := &countingReader{r: io.TeeReader(resp.Body, h)}
+	taskID, err := fts.CreateStreamUploadTask(ctx, counter, size, mode, server, path, verify)
+	if err != nil {
+		return FetchResult{TaskID: taskID}, err
+	}
+	return FetchResult{TaskID: taskID, Size: counter.n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// CreateRemoteFetchTask 在目标服务器上用 curl（没有时用 wget）下载 src，内容不经过本服务。
+// 请求头和认证信息通过标准输入（curl）或只有所有者可读的临时配置文件（wget）传给命令，
+// 不出现在目标服务器的进程列表中；下载到同目录下的临时文件，校验通过后再重命名为目标文件。
+// 不跟随重定向，调用方需事先检查源地址；指定 Resolve 时只使用 curl，源主机固定解析到该地址，避免再次解析时被换成内网地址
+func (fts *FileTransferServiceImpl) CreateRemoteFetchTask(ctx context.Context, src URLSource, mode os.FileMode, server, path string) (FetchResult, error) {
+	if _, err := src.Validate(); err != nil {
+		return FetchResult{}, err
+	}
+
+	client, sftpClient, err := fts.Pool.GetSftp(server)
+	if err != nil {
+		logx.Errorf("获取连接失败: %v\n", err)
+		return FetchResult{}, err
+	}
+	defer fts.Pool.Put(server, client)
+
+	path, grant, err := fts.authorize(ctx, sftpClient, server, path, AccessWrite, -1)
+	if err != nil {
+		logx.Errorf("访问检查未通过: %v", err)
+		return FetchResult{}, err
+	}
+
+	done, err := fts.begin(ctx, -1)
+	if err != nil {
+		logx.Errorf("配额检查未通过: %v", err)
+		return FetchResult{}, err
+	}
+	result := FetchResult{TaskID: uuid.New().String()}
+	defer func() { done(result.Size) }()
+
+	tool, err := runCommand(ctx, client, "command -v curl || command -v wget", nil)
+	if err != nil || strings.TrimSpace(tool) == "" {
+		return result, fmt.Errorf("%w: 目标服务器上没有 curl 或 wget", ErrFetchFailed)
+	}
+	tool = strings.TrimSpace(strings.SplitN(tool, "\n", 2)[0])
+	result.Tool = tool
+	if src.Resolve != "" && strings.HasSuffix(tool, "wget") {
+		return result, fmt.Errorf("%w: 目标服务器上没有 curl，wget 无法固定源主机的地址", ErrFetchFailed)
+	}
+
+	tmpPath := partialTmpPath(path, result.TaskID)
+	committed := false
+	defer func() {
+		if !committed {
+			sftpClient.Remove(tmpPath)
+		}
+	}()
+
+	var cmd string
+	var stdin io.Reader
+	if strings.HasSuffix(tool, "wget") {
+		// wget 只能从文件读取配置
+		configPath := tmpPath + ".wgetrc"
+		cmd = wgetCommand(src, tmpPath, configPath)
+		if err := writeConfigFile(sftpClient, configPath, wgetConfig(src)); err != nil {
+			logx.Errorf("写入下载配置失败: %v", err)
+			return result, err
+		}
+		defer sftpClient.Remove(configPath)
+	} else {
+		cmd = curlCommand(tmpPath, grant.MaxSize)
+		stdin = bytes.NewReader(curlConfig(src))
+	}
+	out, err := runCommand(ctx, client, cmd, stdin)
+	if err != nil {
+		logx.Errorf("目标服务器下载失败: %v", err)
+		return result, fmt.Errorf("%w: %w", ErrFetchFailed, err)
+	}
+	// curl 输出状态码，3xx 同样视为失败
+	if code := strings.TrimSpace(out); code != "" && code != "200" {
+		return result, fmt.Errorf("%w: 源地址返回 %s", ErrFetchFailed, code)
+	}
+
+	stat, err := sftpClient.Stat(tmpPath)
+	if err != nil {
+		logx.Errorf("获取下载文件信息失败: %v", err)
+		return result, err
+	}
+	result.Size = stat.Size()
+	if grant.MaxSize > 0 && result.Size > grant.MaxSize {
+		return result, fmt.Errorf("%w: %d 字节，上限 %d 字节", ErrFileTooLarge, result.Size, grant.MaxSize)
+	}
+
+	if src.SHA256 != "" {
+		out, err := runCommand(ctx, client, "sha256sum "+shellQuote(tmpPath)+" 2>/dev/null || shasum -a 256 "+shellQuote(tmpPath), nil)
+		if err != nil {
+			logx.Errorf("计算 sha256 失败: %v", err)
+			return result, fmt.Errorf("计算 sha256 失败: %v", err)
+		}
+		result.SHA256 = strings.ToLower(strings.Fields(out + " ")[0])
+		if !strings.EqualFold(result.SHA256, src.SHA256) {
+			return result, ErrChecksumMismatch
+		}
+	}
+
+	if err := sftpClient.Chmod(tmpPath, mode); err != nil {
+		logx.Errorf("文件权限设置失败: %v", err)
+		return result, err
+	}
+	if err := replaceFile(sftpClient, tmpPath, path); err != nil {
+		logx.Errorf("重命名远程文件失败: %v", err)
+		return result, err
+	}
+	committed = true
+	return result, nil
+}
+
+// curlCommand 返回 curl 下载命令，地址、请求头和认证信息从标准输入读取，不跟随重定向
+func curlCommand(dest string, maxSize int64) string {
+	args := []string{"curl", "-fsS", "--proto", "=http,https", "-K", "-", "-o", shellQuote(dest), "-w", "'%{http_code}'"}
+	if maxSize > 0 {
+		args = append(args, "--max-filesize", fmt.Sprint(maxSize))
+	}
+	return strings.Join(args, " ")
+}
+
+// curlConfig 返回 curl 配置文件格式的参数，值用双引号括起
+func curlConfig(src URLSource) []byte {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+	var config bytes.Buffer
+	fmt.Fprintf(&config, "url = %s\n", quote(src.URL))
+	for k, v := range src.Headers {
+		fmt.Fprintf(&config, "header = %s\n", quote(k+": "+v))
+	}
+	if src.Username != "" || src.Password != "" {
+		fmt.Fprintf(&config, "user = %s\n", quote(src.Username+":"+src.Password))
+	}
+	if src.Resolve != "" {
+		fmt.Fprintf(&config, "resolve = %s\n", quote(src.Resolve))
+	}
+	return config.Bytes()
+}
+
+// wgetCommand 返回 wget 下载命令，请求头和认证信息从配置文件读取，不跟随重定向
+func wgetCommand(src URLSource, dest, configPath string) string {
+	args := []string{"wget", "-nv", "--config=" + shellQuote(configPath), "-O", shellQuote(dest), "--max-redirect=0"}
+	return strings.Join(append(args, "--", shellQuote(src.URL)), " ")
+}
+
+// wgetConfig 返回 wgetrc 格式的参数，值为整行内容
+func wgetConfig(src URLSource) []byte {
+	var config bytes.Buffer
+	for k, v := range src.Headers {
+		fmt.Fprintf(&config, "header = %s: %s\n", k, v)
+	}
+	if src.Username != "" || src.Password != "" {
+		fmt.Fprintf(&config, "user = %s\npassword = %s\nauth_no_challenge = on\n", src.Username, src.Password)
+	}
+	return config.Bytes()
+}
+
+// writeConfigFile 创建只有所有者可读写的文件并写入 data，文件已存在时失败
+func writeConfigFile(sftpClient *sftp.Client, p string, data []byte) error {
+	file, err := sftpClient.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := file.Chmod(0600); err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	return err
+}
+
+// shellQuote 用单引号括起参数，供远程 shell 使用
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runCommand 在远程服务器上执行命令并返回标准输出，ctx 取消时关闭会话；
+// 命令失败时错误中带有标准错误的内容
+func runCommand(ctx context.Context, client *ssh.Client, cmd string, stdin io.Reader) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdin = stdin
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.Signal(ssh.SIGKILL)
+			session.Close()
+		case <-finished:
+		}
+	}()
+
+	if err := session.Run(cmd); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), fmt.Errorf("%v: %s", err, msg)
+		}
+		return stdout.String(), err
+	}
+	return stdout.String(), nil
+}
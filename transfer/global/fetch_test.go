@@ -0,0 +1,81 @@
+package global
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestURLSourceValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     URLSource
+		wantErr bool
+	}{
+		{"https 地址", URLSource{URL: "https://example.com/a"}, false},
+		{"http 地址带认证和请求头", URLSource{URL: "http://example.com/a", Username: "u", Password: "p", Headers: map[string]string{"X-Token": "t"}}, false},
+		{"固定解析地址", URLSource{URL: "https://example.com/a", Resolve: "example.com:443:8.8.8.8"}, false},
+		{"其他协议", URLSource{URL: "file:///etc/passwd"}, true},
+		{"缺少主机", URLSource{URL: "http:///a"}, true},
+		{"地址中含有换行", URLSource{URL: "https://example.com/a\nurl = http://127.0.0.1/"}, true},
+		{"密码中含有换行", URLSource{URL: "https://example.com/a", Password: "p\nresolve = x"}, true},
+		{"请求头中含有换行", URLSource{URL: "https://example.com/a", Headers: map[string]string{"X": "a\r\nb"}}, true},
+		{"请求头名称中含有冒号", URLSource{URL: "https://example.com/a", Headers: map[string]string{"X:Y": "a"}}, true},
+		{"固定解析地址中含有换行", URLSource{URL: "https://example.com/a", Resolve: "example.com:443:8.8.8.8\nurl = http://127.0.0.1/"}, true},
+		{"无效的 sha256", URLSource{URL: "https://example.com/a", SHA256: "abc"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.src.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate err = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidArgument) {
+				t.Errorf("err = %v, want ErrInvalidArgument", err)
+			}
+		})
+	}
+}
+
+func TestCurlConfig(t *testing.T) {
+	got := string(curlConfig(URLSource{
+		URL:      `https://example.com/a?q="x"`,
+		Headers:  map[string]string{"X-Token": `t\1`},
+		Username: "u",
+		Password: "p:w",
+		Resolve:  "example.com:443:8.8.8.8",
+	}))
+	want := []string{
+		`url = "https://example.com/a?q=\"x\""`,
+		`header = "X-Token: t\\1"`,
+		`user = "u:p:w"`,
+		`resolve = "example.com:443:8.8.8.8"`,
+	}
+	for _, line := range want {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("curl 配置中缺少 %s:\n%s", line, got)
+		}
+	}
+	if strings.Contains(string(curlConfig(URLSource{URL: "https://example.com/a"})), "resolve") {
+		t.Error("未指定固定解析地址时不应包含 resolve")
+	}
+}
+
+func TestFetchCommands(t *testing.T) {
+	curl := curlCommand("/data/a'b.part", 100)
+	for _, arg := range []string{"-K -", `'/data/a'\''b.part'`, "--proto =http,https", "--max-filesize 100"} {
+		if !strings.Contains(curl, arg) {
+			t.Errorf("curl 命令中缺少 %s: %s", arg, curl)
+		}
+	}
+	if strings.Contains(curl, "-L") || strings.Contains(curl, "--location") {
+		t.Errorf("curl 不应跟随重定向: %s", curl)
+	}
+
+	wget := wgetCommand(URLSource{URL: "https://example.com/a"}, "/data/a.part", "/data/a.part.wgetrc")
+	for _, arg := range []string{"--max-redirect=0", "--config='/data/a.part.wgetrc'", "-- 'https://example.com/a'"} {
+		if !strings.Contains(wget, arg) {
+			t.Errorf("wget 命令中缺少 %s: %s", arg, wget)
+		}
+	}
+}
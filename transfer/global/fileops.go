@@ -0,0 +1,317 @@
+package global
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 目录列表的排序字段
+const (
+	SortByName  = "name"
+	SortBySize  = "size"
+	SortByMtime = "mtime"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+var ErrInvalidArgument = errors.New("无效的参数")
+
+// FileInfo 远程文件的信息，符号链接本身的信息（不跟随链接）
+type FileInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"` // 如 -rw-r--r--
+	Perm    uint32    `json:"perm"` // 权限位，如 0644
+	IsDir   bool      `json:"is_dir"`
+	IsLink  bool      `json:"is_link"`
+	UID     uint32    `json:"uid"`
+	GID     uint32    `json:"gid"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+func newFileInfo(dir string, fi os.FileInfo) FileInfo {
+	info := FileInfo{
+		Name:    fi.Name(),
+		Path:    path.Join(dir, fi.Name()),
+		Size:    fi.Size(),
+		Mode:    fi.Mode().String(),
+		Perm:    uint32(fi.Mode().Perm()),
+		IsDir:   fi.IsDir(),
+		IsLink:  fi.Mode()&os.ModeSymlink != 0,
+		ModTime: fi.ModTime(),
+	}
+	if stat, ok := fi.Sys().(*sftp.FileStat); ok {
+		info.UID, info.GID = stat.UID, stat.GID
+	}
+	return info
+}
+
+// ListOptions 目录列表的分页及排序
+type ListOptions struct {
+	Offset    int    // 跳过的条目数
+	Limit     int    // 返回的最大条目数，<=0 时为 100，最大 1000
+	SortBy    string // name / size / mtime，默认 name
+	Desc      bool   // 是否倒序
+	DirsFirst bool   // 目录是否排在文件前面
+}
+
+// ListResult 目录列表的一页
+type ListResult struct {
+	Path    string     `json:"path"`
+	Entries []FileInfo `json:"entries"`
+	Total   int        `json:"total"` // 目录中的条目总数
+}
+
+// withSftp 取出连接执行 fn，结束后放回连接
+func (fts *FileTransferServiceImpl) withSftp(server string, fn func(*sftp.Client) error) error {
+	client, sftpClient, err := fts.Pool.GetSftp(server)
+	if err != nil {
+		logx.Errorf("获取连接失败: %v\n", err)
+		return err
+	}
+	defer fts.Pool.Put(server, client)
+	return fn(sftpClient)
+}
+
+// ListDir 列出远程目录，按 opts 排序后分页返回
+func (fts *FileTransferServiceImpl) ListDir(ctx context.Context, server, dir string, opts ListOptions) (ListResult, error) {
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = SortByName
+	case SortByName, SortBySize, SortByMtime:
+	default:
+		return ListResult{}, fmt.Errorf("%w: 不支持的排序字段 %s", ErrInvalidArgument, opts.SortBy)
+	}
+	if opts.Offset < 0 {
+		return ListResult{}, fmt.Errorf("%w: offset 不能为负数", ErrInvalidArgument)
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = defaultListLimit
+	}
+	opts.Limit = min(opts.Limit, maxListLimit)
+
+	var result ListResult
+	err := fts.withSftp(server, func(sftpClient *sftp.Client) error {
+		dir, _, err := fts.authorize(ctx, sftpClient, server, dir, AccessRead, -1)
+		if err != nil {
+			return err
+		}
+		entries, err := sftpClient.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			if opts.DirsFirst && a.IsDir() != b.IsDir() {
+				return a.IsDir()
+			}
+			c := 0
+			switch opts.SortBy {
+			case SortBySize:
+				c = cmp.Compare(a.Size(), b.Size())
+			case SortByMtime:
+				c = a.ModTime().Compare(b.ModTime())
+			}
+			if c == 0 {
+				c = strings.Compare(a.Name(), b.Name())
+			}
+			if opts.Desc {
+				return c > 0
+			}
+			return c < 0
+		})
+
+		result = ListResult{Path: dir, Total: len(entries), Entries: []FileInfo{}}
+		if opts.Offset < len(entries) {
+			for _, fi := range entries[opts.Offset:min(opts.Offset+opts.Limit, len(entries))] {
+				result.Entries = append(result.Entries, newFileInfo(dir, fi))
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Stat 返回远程文件的信息，不跟随最后一级的符号链接
+func (fts *FileTransferServiceImpl) Stat(ctx context.Context, server, p string) (FileInfo, error) {
+	var info FileInfo
+	err := fts.withSftp(server, func(sftpClient *sftp.Client) error {
+		p, err := fts.authorizeEntry(ctx, sftpClient, server, p, AccessRead)
+		if err != nil {
+			return err
+		}
+		fi, err := sftpClient.Lstat(p)
+		if err != nil {
+			return err
+		}
+		info = newFileInfo(path.Dir(p), fi)
+		return nil
+	})
+	return info, err
+}
+
+// Mkdir 创建远程目录，parents 为 true 时同时创建不存在的上级目录，目录已存在不视为错误
+func (fts *FileTransferServiceImpl) Mkdir(ctx context.Context, server, dir string, parents bool) (string, error) {
+	var created string
+	err := fts.withSftp(server, func(sftpClient *sftp.Client) error {
+		dir, _, err := fts.authorize(ctx, sftpClient, server, dir, AccessWrite, -1)
+		if err != nil {
+			return err
+		}
+		created = dir
+		if parents {
+			return sftpClient.MkdirAll(dir)
+		}
+		return sftpClient.Mkdir(dir)
+	})
+	return created, err
+}
+
+// Remove 删除远程文件、符号链接或空目录；recursive 为 true 时删除整个目录，
+// 删除前检查目录下的每一项，任何一项被访问策略拒绝时不删除任何内容
+func (fts *FileTransferServiceImpl) Remove(ctx context.Context, server, p string, recursive bool) (string, error) {
+	var removed string
+	err := fts.withSftp(server, func(sftpClient *sftp.Client) error {
+		p, err := fts.authorizeEntry(ctx, sftpClient, server, p, AccessWrite)
+		if err != nil {
+			return err
+		}
+		if p == "/" {
+			return fmt.Errorf("%w: 不能删除根目录", ErrAccessDenied)
+		}
+		removed = p
+
+		fi, err := sftpClient.Lstat(p)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			return sftpClient.Remove(p)
+		}
+		if !recursive {
+			return sftpClient.RemoveDirectory(p)
+		}
+
+		if err := fts.checkTree(ctx, sftpClient, server, p, ""); err != nil {
+			return err
+		}
+		return sftpClient.RemoveAll(p)
+	})
+	return removed, err
+}
+
+// checkTree 对目录 dir 下的每一项（包括 dir 本身，不跟随符号链接）执行写入检查；
+// dest 不为空时同时检查移动到 dest 后的对应路径
+func (fts *FileTransferServiceImpl) checkTree(ctx context.Context, sftpClient *sftp.Client, server, dir, dest string) error {
+	if fts.Guard == nil {
+		return nil
+	}
+	walker := sftpClient.Walk(dir)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return err
+		}
+		p := walker.Path()
+		if _, err := fts.check(ctx, server, p, AccessWrite, -1); err != nil {
+			return err
+		}
+		if dest != "" {
+			if _, err := fts.check(ctx, server, path.Join(dest, strings.TrimPrefix(p, dir)), AccessWrite, -1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rename 重命名或移动远程文件，overwrite 为 true 时覆盖已存在的目标；
+// 移动目录前检查目录下的每一项及其新路径，防止把受保护的文件移出策略覆盖的位置
+func (fts *FileTransferServiceImpl) Rename(ctx context.Context, server, oldPath, newPath string, overwrite bool) (string, string, error) {
+	var from, to string
+	err := fts.withSftp(server, func(sftpClient *sftp.Client) error {
+		var err error
+		if from, err = fts.authorizeEntry(ctx, sftpClient, server, oldPath, AccessWrite); err != nil {
+			return err
+		}
+		if to, err = fts.authorizeEntry(ctx, sftpClient, server, newPath, AccessWrite); err != nil {
+			return err
+		}
+		if from == "/" || strings.HasPrefix(to, strings.TrimSuffix(from, "/")+"/") {
+			return fmt.Errorf("%w: 不能将目录移动到自身之下", ErrInvalidArgument)
+		}
+		fi, err := sftpClient.Lstat(from)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if err := fts.checkTree(ctx, sftpClient, server, from, to); err != nil {
+				return err
+			}
+		}
+		if overwrite {
+			return sftpClient.PosixRename(from, to)
+		}
+		return sftpClient.Rename(from, to)
+	})
+	return from, to, err
+}
+
+// Chmod 修改远程文件的权限，跟随符号链接
+func (fts *FileTransferServiceImpl) Chmod(ctx context.Context, server, p string, mode os.FileMode) (string, error) {
+	var changed string
+	err := fts.withSftp(server, func(sftpClient *sftp.Client) error {
+		p, _, err := fts.authorize(ctx, sftpClient, server, p, AccessWrite, -1)
+		if err != nil {
+			return err
+		}
+		changed = p
+		return sftpClient.Chmod(p, mode&os.ModePerm)
+	})
+	return changed, err
+}
+
+// Symlink 创建指向 target 的符号链接 link；target 原样写入链接，之后经由链接的访问按解析后的真实路径检查
+func (fts *FileTransferServiceImpl) Symlink(ctx context.Context, server, target, link string) (string, error) {
+	if target == "" || strings.ContainsRune(target, 0) {
+		return "", fmt.Errorf("%w: 无效的链接目标 %q", ErrInvalidArgument, target)
+	}
+	var created string
+	err := fts.withSftp(server, func(sftpClient *sftp.Client) error {
+		link, err := fts.authorizeEntry(ctx, sftpClient, server, link, AccessWrite)
+		if err != nil {
+			return err
+		}
+		created = link
+		return sftpClient.Symlink(target, link)
+	})
+	return created, err
+}
+
+// Readlink 返回符号链接指向的路径
+func (fts *FileTransferServiceImpl) Readlink(ctx context.Context, server, link string) (string, error) {
+	var target string
+	err := fts.withSftp(server, func(sftpClient *sftp.Client) error {
+		link, err := fts.authorizeEntry(ctx, sftpClient, server, link, AccessRead)
+		if err != nil {
+			return err
+		}
+		target, err = sftpClient.ReadLink(link)
+		return err
+	})
+	return target, err
+}
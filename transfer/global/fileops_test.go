@@ -0,0 +1,208 @@
+package global
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSFTP 启动进程内的 SSH/SFTP 服务器，接受任意密码，返回监听地址
+func startSFTP(t *testing.T) string {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) { return nil, nil }}
+	cfg.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, cfg)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveSFTP(conn net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		ch, creqs, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for r := range creqs {
+				ok := r.Type == "subsystem"
+				r.Reply(ok, nil)
+				if ok {
+					srv, _ := sftp.NewServer(ch)
+					go func() { srv.Serve(); ch.Close() }()
+				}
+			}
+		}()
+	}
+}
+
+// newTestService 创建连接到进程内 SFTP 服务器的传输服务，返回服务及连接池中的键
+func newTestService(t *testing.T, guard AccessGuard) (*FileTransferServiceImpl, string) {
+	t.Helper()
+	addr := startSFTP(t)
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            "alice",
+		Auth:            []ssh.AuthMethod{ssh.Password("pw")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("连接 SFTP 服务器失败: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	pool := &SSHConnectionPool{
+		Connections:      make(map[string]*SSHConnection),
+		Capacity:         4,
+		Timeout:          time.Minute,
+		KeepaliveTimeout: 5 * time.Second,
+	}
+	server := PoolKey(addr, "alice", "pw")
+	pool.Add(server, client)
+	if _, err := pool.Get(server); err != nil {
+		t.Fatalf("连接未加入连接池: %v", err)
+	}
+	pool.Put(server, client)
+	return &FileTransferServiceImpl{Pool: pool, Guard: guard}, server
+}
+
+// makeTree 在 root 下创建给定的文件，以 / 结尾的为目录
+func makeTree(t *testing.T, root string, entries ...string) {
+	t.Helper()
+	for _, e := range entries {
+		p := filepath.Join(root, e)
+		if strings.HasSuffix(e, "/") {
+			if err := os.MkdirAll(p, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(e), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// denySSHKeys 模拟 /home/*/.ssh/ 拒绝规则，路径相对于 root
+func denySSHKeys(root string) AccessGuard {
+	return func(ctx context.Context, req AccessRequest) (AccessGrant, error) {
+		parts := strings.Split(strings.TrimPrefix(req.Path, root), "/")
+		if len(parts) >= 4 && parts[1] == "home" && parts[3] == ".ssh" {
+			return AccessGrant{}, ErrAccessDenied
+		}
+		return AccessGrant{}, nil
+	}
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		wantErr  error
+	}{
+		{name: "重命名普通文件", from: "/data/a.txt", to: "/data/b.txt"},
+		{name: "移动不含受保护内容的目录", from: "/data/dir", to: "/tmp/dir"},
+		{name: "直接移动受保护的目录", from: "/home/alice/.ssh", to: "/tmp/keys", wantErr: ErrAccessDenied},
+		{name: "移动包含受保护目录的用户目录", from: "/home/alice", to: "/tmp/x", wantErr: ErrAccessDenied},
+		{name: "移动后会落入受保护的位置", from: "/tmp/stash", to: "/home/dave", wantErr: ErrAccessDenied},
+		{name: "移动到自身之下", from: "/data", to: "/data/dir/sub", wantErr: ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			makeTree(t, root, "home/alice/.ssh/id_rsa", "home/alice/docs/a.txt",
+				"data/a.txt", "data/dir/f.txt", "tmp/stash/.ssh/id_rsa")
+			fts, server := newTestService(t, denySSHKeys(root))
+
+			_, _, err := fts.Rename(context.Background(), server, root+tt.from, root+tt.to, false)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Rename 失败: %v", err)
+				}
+				if _, err := os.Lstat(root + tt.to); err != nil {
+					t.Errorf("移动后目标不存在: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Rename err = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := os.Lstat(root + tt.from); err != nil {
+				t.Errorf("被拒绝时不应移动源路径: %v", err)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		recursive bool
+		wantErr   error
+	}{
+		{name: "删除普通文件", path: "/data/a.txt"},
+		{name: "递归删除目录", path: "/data", recursive: true},
+		{name: "删除受保护的文件", path: "/home/alice/.ssh/id_rsa", wantErr: ErrAccessDenied},
+		{name: "递归删除包含受保护目录的用户目录", path: "/home/alice", recursive: true, wantErr: ErrAccessDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			makeTree(t, root, "home/alice/.ssh/id_rsa", "home/alice/docs/a.txt", "data/a.txt", "data/dir/f.txt")
+			fts, server := newTestService(t, denySSHKeys(root))
+
+			_, err := fts.Remove(context.Background(), server, root+tt.path, tt.recursive)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Remove err = %v, want %v", err, tt.wantErr)
+			}
+			_, statErr := os.Lstat(root + tt.path)
+			if exists := statErr == nil; exists != (tt.wantErr != nil) {
+				t.Errorf("删除后路径是否存在 = %t, want %t", exists, tt.wantErr != nil)
+			}
+			if tt.wantErr != nil {
+				if _, err := os.Lstat(filepath.Join(root, "home/alice/docs/a.txt")); err != nil {
+					t.Errorf("被拒绝时不应删除任何内容: %v", err)
+				}
+			}
+		})
+	}
+}
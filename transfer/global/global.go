@@ -1,9 +1,16 @@
 package global
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io"
-	"mime/multipart"
+	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,20 +22,43 @@ import (
 )
 
 type SSHConnection struct {
-	Client *ssh.Client
-	UsedAt time.Time
+	Client    *ssh.Client
+	CreatedAt time.Time // 连接加入连接池的时间
+	UsedAt    time.Time
+	AliveAt   time.Time // 最近一次确认连接存活（keepalive 成功）的时间
+	InUse     int       // 已取出尚未放回的次数
+
+	// 与SSH连接绑定的SFTP客户端，首次使用时创建，可被同一主机的并发任务共享
+	Sftp   *sftp.Client
+	sftpMu sync.Mutex // 保护 Sftp 的创建与替换
+
+	stop chan struct{} // 关闭后通知后台 keepalive 协程退出
+	once sync.Once
 }
 
 type SSHConnectionPool struct {
 	sync.Mutex
-	Connections map[string]*SSHConnection // key可以是server IP或者标识符
-	Capacity    int                       // 每个服务器的最大连接数
-	Timeout     time.Duration             // 连接的最大空闲时间
+	Connections       map[string]*SSHConnection // key可以是server IP或者标识符
+	Capacity          int                       // 每个服务器的最大连接数
+	Timeout           time.Duration             // 连接的最大空闲时间
+	KeepaliveInterval time.Duration             // 后台发送 keepalive 的间隔，<=0 表示不启动后台探测
+	KeepaliveTimeout  time.Duration             // 单次 keepalive 等待应答的超时时间
+	StaleAfter        time.Duration             // 超过该时间未确认存活的连接，在取用前需重新探测
+	DialTimeout       time.Duration             // 建立TCP连接及SSH握手的超时时间
+	BreakerThreshold  int                       // 连续拨号失败多少次后打开熔断器
+	BreakerBackoff    time.Duration             // 熔断的初始退避时间，之后每次失败翻倍
+	BreakerMaxBackoff time.Duration             // 熔断退避时间上限
+	SftpOptions       []sftp.ClientOption       // 创建SFTP客户端时使用的选项（MaxPacket、并发请求数等）
+
+	breakerMu sync.Mutex
+	breakers  map[string]*hostBreaker // 按服务器记录拨号失败情况
 }
 
 // 定义一个具体类型来实现FileTransferService接口
 type FileTransferServiceImpl struct {
-	Pool *SSHConnectionPool
+	Pool  *SSHConnectionPool
+	Guard AccessGuard   // 打开或创建远程文件前的访问检查
+	Meter TransferMeter // 传输前的配额检查及用量统计
 }
 
 type FileTransferService interface {
@@ -40,28 +70,57 @@ var Pool *SSHConnectionPool // 全局连接池
 
 var FTS *FileTransferServiceImpl // 全局文件传输服务
 
+// poolKeySecret 计算凭据指纹使用的随机密钥，每次启动重新生成，指纹无法用于反推凭据
+var poolKeySecret = func() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}()
+
+// PoolKey 返回连接在连接池中的键，形如 <主机>#<凭据指纹>，指纹由登录用户和凭据计算；
+// 使用不同凭据访问同一主机的请求不会复用彼此的连接
+func PoolKey(host, user string, secrets ...string) string {
+	mac := hmac.New(sha256.New, poolKeySecret)
+	mac.Write([]byte(user))
+	for _, s := range secrets {
+		mac.Write([]byte{0})
+		mac.Write([]byte(s))
+	}
+	return host + "#" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// HostOf 返回连接池键中的主机部分，用于访问策略、熔断、管理接口等按主机区分的场合
+func HostOf(server string) string {
+	host, _, _ := strings.Cut(server, "#")
+	return host
+}
+
+// keepalive@openssh.com 是 OpenSSH 约定的全局请求，服务端即使不支持也会回复失败，
+// 只要能收到回复就说明连接可用，不需要开会话执行命令，对仅允许 sftp 的账号同样有效
+const keepaliveRequest = "keepalive@openssh.com"
+
 // 添加连接到连接池中
 func (p *SSHConnectionPool) Add(server string, client *ssh.Client) {
-	p.Lock()
-	defer p.Unlock()
-
-	if !isConnectionValid(client) {
+	// 探测最长等待 KeepaliveTimeout，在锁外进行，避免一台慢主机阻塞所有服务器的任务
+	if !p.probe(client) {
 		logx.Error("Add：无效的连接")
 		return
 	}
 
+	p.Lock()
+	defer p.Unlock()
+
 	// 如果已有连接，，先关闭旧的，然后更新连接
 	if oldConn, exists := p.Connections[server]; exists {
 		if oldConn.Client == client { // 新旧连接相同
-			// 添加/更新连接
-			p.Connections[server] = &SSHConnection{
-				Client: client,
-				UsedAt: time.Now(),
-			}
+			// 更新连接状态
+			oldConn.UsedAt = time.Now()
+			oldConn.AliveAt = oldConn.UsedAt
 			return
 		}
-		oldConn.Client.Close()        // 关闭旧连接
-		delete(p.Connections, server) // 删除旧连接
+		p.remove(server, oldConn) // 关闭并删除旧连接
 	}
 
 	if len(p.Connections) >= p.Capacity {
@@ -69,57 +128,104 @@ func (p *SSHConnectionPool) Add(server string, client *ssh.Client) {
 		return
 	}
 
-	p.Connections[server] = &SSHConnection{
-		Client: client,
-		UsedAt: time.Now(),
-	}
+	p.Connections[server] = p.newConnection(server, client)
 }
 
 // 从连接池中获取连接
 func (p *SSHConnectionPool) Get(server string) (*ssh.Client, error) {
-	p.Lock()
-	defer p.Unlock()
+	conn, err := p.acquire(server)
+	if err != nil {
+		return nil, err
+	}
+	return conn.Client, nil
+}
 
-	if conn, exists := p.Connections[server]; exists {
-		if time.Since((*conn).UsedAt) > p.Timeout || !isConnectionValid((*conn).Client) {
-			conn.Client.Close() // 连接超时，关闭并删除
-			delete(p.Connections, server)
-			return nil, errors.New("Get：连接已过期或无效")
-		} else {
-			(*conn).UsedAt = time.Now() // 更新使用时间
-			return (*conn).Client, nil
-		}
+// GetSftp 从连接池中获取连接及其共享的SFTP客户端，用完后需调用 Put 放回 SSH 连接，
+// 返回的SFTP客户端由同一主机的任务共用，调用方不能关闭
+func (p *SSHConnectionPool) GetSftp(server string) (*ssh.Client, *sftp.Client, error) {
+	conn, err := p.acquire(server)
+	if err != nil {
+		return nil, nil, err
 	}
-	// 如果不存在有效连接，返回错误
-	return nil, errors.New("Get：没有可用连接")
+
+	sftpClient, err := conn.sftpClient(p.SftpOptions)
+	if err != nil {
+		p.Put(server, conn.Client)
+		return nil, nil, err
+	}
+	return conn.Client, sftpClient, nil
+}
+
+// acquire 取出可用连接并增加使用计数；需要探测时在锁外进行，调用方不能持有锁
+func (p *SSHConnectionPool) acquire(server string) (*SSHConnection, error) {
+	p.Lock()
+	conn, exists := p.Connections[server]
+	if !exists {
+		p.Unlock()
+		// 如果不存在有效连接，返回错误
+		return nil, errors.New("Get：没有可用连接")
+	}
+	if time.Since(conn.UsedAt) > p.Timeout {
+		p.remove(server, conn) // 连接超时，关闭并删除
+		p.Unlock()
+		return nil, errors.New("Get：连接已过期或无效")
+	}
+	// 先占用连接再探测，避免探测期间被清理协程关闭
+	conn.UsedAt = time.Now() // 更新使用时间
+	conn.InUse++
+	p.Unlock()
+
+	if !p.checkAlive(server, conn) {
+		p.Lock()
+		conn.InUse--
+		p.Unlock()
+		return nil, errors.New("Get：连接已过期或无效")
+	}
+	return conn, nil
 }
 
 // 放回/更新连接到连接池中
 func (p *SSHConnectionPool) Put(server string, client *ssh.Client) {
 	p.Lock()
-	defer p.Unlock()
+	oldConn, exists := p.Connections[server]
+
+	// 放回的就是池中的连接，只需刷新使用时间
+	if exists && oldConn.Client == client {
+		if oldConn.InUse > 0 {
+			oldConn.InUse--
+		}
+		oldConn.UsedAt = time.Now()
+		p.Unlock()
+		if !p.checkAlive(server, oldConn) {
+			logx.Error("Put：无效的连接")
+		}
+		return
+	}
+	p.Unlock()
 
 	// 判断新连接是否有效
-	if !isConnectionValid(client) {
+	if !p.probe(client) {
 		logx.Error("Put：无效的连接")
-		if client == p.Connections[server].Client { // 新旧连接相同
-			client.Close()                // 关闭旧连接
-			delete(p.Connections, server) // 删除旧连接
-		}
 		return
 	}
 
-	// 如果已有旧连接，则更新旧
-	if oldConn, exists := p.Connections[server]; exists {
-		if isConnectionValid(oldConn.Client) {
-			oldConn.UsedAt = time.Now()
-			// client.Close()
-			p.Connections[server] = oldConn
-			return
-		} else {
-			_ = oldConn.Client.Close()
-			delete(p.Connections, server)
-		}
+	// 如果已有有效的旧连接，则保留旧连接；失效的旧连接由 checkAlive 删除
+	if exists && p.checkAlive(server, oldConn) {
+		p.Lock()
+		oldConn.UsedAt = time.Now()
+		p.Unlock()
+		client.Close()
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	// 探测期间其他任务已放入新连接，保留该连接
+	if cur, exists := p.Connections[server]; exists {
+		cur.UsedAt = time.Now()
+		client.Close()
+		return
 	}
 
 	// 没有旧连接或旧连接已失效，则判断容量，准备添加新连接
@@ -128,22 +234,219 @@ func (p *SSHConnectionPool) Put(server string, client *ssh.Client) {
 		return
 	}
 
-	p.Connections[server] = &SSHConnection{
-		Client: client,
-		UsedAt: time.Now(),
+	p.Connections[server] = p.newConnection(server, client)
+}
+
+// newConnection 包装连接并启动后台 keepalive 协程，调用方需持有锁
+func (p *SSHConnectionPool) newConnection(server string, client *ssh.Client) *SSHConnection {
+	now := time.Now()
+	conn := &SSHConnection{
+		Client:    client,
+		CreatedAt: now,
+		UsedAt:    now,
+		AliveAt:   now,
+		stop:      make(chan struct{}),
+	}
+	if p.KeepaliveInterval > 0 {
+		go p.keepalive(server, conn)
+	}
+	return conn
+}
+
+// remove 从连接池中删除连接并关闭，调用方需持有锁
+func (p *SSHConnectionPool) remove(server string, conn *SSHConnection) {
+	if cur, exists := p.Connections[server]; exists && cur == conn {
+		delete(p.Connections, server)
 	}
+	conn.close()
 }
 
-func isConnectionValid(client *ssh.Client) bool {
-	session, err := client.NewSession()
+// close 停止后台 keepalive 并关闭底层连接，可重复调用
+func (conn *SSHConnection) close() {
+	conn.once.Do(func() {
+		close(conn.stop)
+		conn.Client.Close() // 先关闭SSH连接，使正在创建中的SFTP客户端尽快失败
+
+		conn.sftpMu.Lock()
+		if conn.Sftp != nil {
+			conn.Sftp.Close()
+			conn.Sftp = nil
+		}
+		conn.sftpMu.Unlock()
+	})
+}
+
+// sftpClient 返回连接共享的SFTP客户端，不存在则创建；SFTP会话意外结束后会在下次取用时重建
+func (conn *SSHConnection) sftpClient(opts []sftp.ClientOption) (*sftp.Client, error) {
+	conn.sftpMu.Lock()
+	defer conn.sftpMu.Unlock()
+
+	if conn.Sftp != nil {
+		return conn.Sftp, nil
+	}
+
+	sftpClient, err := sftp.NewClient(conn.Client, opts...)
 	if err != nil {
+		logx.Errorf("创建SFTP客户端失败: %v", err)
+		return nil, err
+	}
+	conn.Sftp = sftpClient
+
+	go func() {
+		sftpClient.Wait()
+		conn.sftpMu.Lock()
+		if conn.Sftp == sftpClient {
+			conn.Sftp = nil
+		}
+		conn.sftpMu.Unlock()
+	}()
+
+	return sftpClient, nil
+}
+
+// checkAlive 判断池中连接是否可用：在过期窗口内确认过存活的直接视为可用，否则同步探测一次，
+// 探测失败时将连接移出连接池。探测在锁外进行，调用方不能持有锁
+func (p *SSHConnectionPool) checkAlive(server string, conn *SSHConnection) bool {
+	p.Lock()
+	fresh := time.Since(conn.AliveAt) <= p.StaleAfter
+	p.Unlock()
+	if fresh {
+		return true
+	}
+
+	alive := p.probe(conn.Client)
+
+	p.Lock()
+	defer p.Unlock()
+	if !alive {
+		p.remove(server, conn)
+		return false
+	}
+	conn.AliveAt = time.Now()
+	return true
+}
+
+// probe 发送一次 keepalive 全局请求，超时未应答视为连接失效
+func (p *SSHConnectionPool) probe(client *ssh.Client) bool {
+	if client == nil {
+		return false
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest(keepaliveRequest, true, nil)
+		errChan <- err
+	}()
+
+	timer := time.NewTimer(p.KeepaliveTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-errChan:
+		return err == nil
+	case <-timer.C:
 		return false
 	}
-	defer session.Close()
+}
+
+// keepalive 定期探测连接，失败时将连接移出连接池
+func (p *SSHConnectionPool) keepalive(server string, conn *SSHConnection) {
+	ticker := time.NewTicker(p.KeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			alive := p.probe(conn.Client)
+
+			p.Lock()
+			if alive {
+				conn.AliveAt = time.Now()
+			} else {
+				logx.Errorf("keepalive 失败，移除与服务器 %s 的连接", server)
+				p.remove(server, conn)
+			}
+			p.Unlock()
+
+			if !alive {
+				return
+			}
+		case <-conn.stop:
+			return
+		}
+	}
+}
+
+// ConnectionInfo 连接池中单个连接的快照，供管理接口展示
+type ConnectionInfo struct {
+	Server      string    `json:"server"`
+	User        string    `json:"user"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	LastAliveAt time.Time `json:"last_alive_at"`
+	AgeSeconds  int64     `json:"age_seconds"`
+	InUse       int       `json:"in_use"`
+	SftpActive  bool      `json:"sftp_active"` // 是否已有共享的SFTP会话
+}
+
+// Snapshot 返回连接池中所有连接的快照，按服务器排序
+func (p *SSHConnectionPool) Snapshot() []ConnectionInfo {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	infos := make([]ConnectionInfo, 0, len(p.Connections))
+	for server, conn := range p.Connections {
+		conn.sftpMu.Lock()
+		sftpActive := conn.Sftp != nil
+		conn.sftpMu.Unlock()
+
+		infos = append(infos, ConnectionInfo{
+			Server:      HostOf(server),
+			User:        conn.Client.User(),
+			CreatedAt:   conn.CreatedAt,
+			LastUsedAt:  conn.UsedAt,
+			LastAliveAt: conn.AliveAt,
+			AgeSeconds:  int64(now.Sub(conn.CreatedAt).Seconds()),
+			InUse:       conn.InUse,
+			SftpActive:  sftpActive,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Server != infos[j].Server {
+			return infos[i].Server < infos[j].Server
+		}
+		return infos[i].User < infos[j].User
+	})
+	return infos
+}
+
+// Evict 强制关闭并移除指定服务器的连接，server 为主机时移除该主机使用各凭据的连接，
+// 正在使用这些连接的任务会失败；返回移除的数量
+func (p *SSHConnectionPool) Evict(server string) int {
+	p.Lock()
+	defer p.Unlock()
+
+	n := 0
+	for key, conn := range p.Connections {
+		if key == server || HostOf(key) == server {
+			p.remove(key, conn)
+			n++
+		}
+	}
+	return n
+}
+
+// EvictAll 强制关闭并移除所有连接，返回移除的数量
+func (p *SSHConnectionPool) EvictAll() int {
+	p.Lock()
+	defer p.Unlock()
 
-	// _, err = session.StdoutPipe() // 尝试获取会话输出流，检查连接是否有效
-	_, err = session.CombinedOutput("whoami") // 检查连接是否有效
-	return err == nil
+	n := len(p.Connections)
+	for server, conn := range p.Connections {
+		p.remove(server, conn)
+	}
+	return n
 }
 
 // 定期清理连接池
@@ -157,9 +460,8 @@ func (p *SSHConnectionPool) Cleanup(stopChan chan struct{}) {
 			p.Lock()
 			now := time.Now()
 			for server, conn := range p.Connections {
-				if now.Sub(conn.UsedAt) > p.Timeout {
-					conn.Client.Close() // 连接超时，关闭并删除
-					delete(p.Connections, server)
+				if conn.InUse == 0 && now.Sub(conn.UsedAt) > p.Timeout {
+					p.remove(server, conn) // 连接空闲超时，关闭并删除
 				}
 			}
 			p.Unlock()
@@ -170,20 +472,27 @@ func (p *SSHConnectionPool) Cleanup(stopChan chan struct{}) {
 }
 
 // CreateCommonUploadTaskFromBytes 是基于文件字节流的上传方法
-func (fts *FileTransferServiceImpl) CreateCommonUploadTaskFromBytes(data []byte, server, path string) (string, error) {
-	client, err := fts.Pool.Get(server)
+func (fts *FileTransferServiceImpl) CreateCommonUploadTaskFromBytes(ctx context.Context, data []byte, server, path string) (string, error) {
+	client, sftpClient, err := fts.Pool.GetSftp(server)
 	if err != nil {
 		logx.Errorf("获取连接失败: %v\n", err)
 		return "", err
 	}
 	defer fts.Pool.Put(server, client)
 
-	sftpClient, err := sftp.NewClient(client)
+	path, _, err = fts.authorize(ctx, sftpClient, server, path, AccessWrite, int64(len(data)))
+	if err != nil {
+		logx.Errorf("访问检查未通过: %v", err)
+		return "", err
+	}
+
+	done, err := fts.begin(ctx, int64(len(data)))
 	if err != nil {
-		logx.Errorf("创建SFTP客户端失败: %v\n", err)
+		logx.Errorf("配额检查未通过: %v", err)
 		return "", err
 	}
-	defer sftpClient.Close()
+	var written int
+	defer func() { done(int64(written)) }()
 
 	destFile, err := sftpClient.Create(path)
 	if err != nil {
@@ -192,7 +501,7 @@ func (fts *FileTransferServiceImpl) CreateCommonUploadTaskFromBytes(data []byte,
 	}
 	defer destFile.Close()
 
-	_, err = destFile.Write(data)
+	written, err = destFile.Write(data)
 	if err != nil {
 		logx.Errorf("文件写入失败: %v\n", err)
 		return "", err
@@ -201,144 +510,217 @@ func (fts *FileTransferServiceImpl) CreateCommonUploadTaskFromBytes(data []byte,
 	return uuid.New().String(), nil
 }
 
-// 创建普通传输任务：客户端上传文件给指定服务器
-func (fts *FileTransferServiceImpl) CreateCommonUploadTask(file *multipart.FileHeader, server, path string) (string, error) {
-	// 获取连接（不放回，因为传输过程中需要保持连接）
-	client, err := fts.Pool.Get(server)
+// CreateStreamUploadTask 边读边把 r 中的内容写入远程文件，size 为预计大小，未知时为 -1。
+// 内容先写入同目录下的临时文件，verify（可为 nil）通过后再重命名为目标文件，任一步失败都会删除临时文件
+func (fts *FileTransferServiceImpl) CreateStreamUploadTask(ctx context.Context, r io.Reader, size int64, mode os.FileMode, server, path string, verify func(n int64) error) (string, error) {
+	client, sftpClient, err := fts.Pool.GetSftp(server)
 	if err != nil {
 		logx.Errorf("获取连接失败: %v\n", err)
 		return "", err
 	}
 	defer fts.Pool.Put(server, client)
 
-	// 创建SFTP客户端
-	sftpClient, err := sftp.NewClient(client)
+	path, grant, err := fts.authorize(ctx, sftpClient, server, path, AccessWrite, size)
 	if err != nil {
-		logx.Errorf("创建SFTP客户端失败: %v\n", err)
-		fts.Pool.Put(server, client) // 放回连接
+		logx.Errorf("访问检查未通过: %v", err)
 		return "", err
 	}
-	defer sftpClient.Close()
 
-	// 实际传输逻辑
-	srcFile, err := file.Open()
+	done, err := fts.begin(ctx, size)
 	if err != nil {
-		logx.Errorf("打开文件失败: %v", err)
+		logx.Errorf("配额检查未通过: %v", err)
 		return "", err
 	}
-	defer srcFile.Close()
+	var written int64
+	defer func() { done(written) }()
 
-	destFile, err := sftpClient.Create(path) // 创建远程文件
+	taskID := uuid.New().String()
+	tmpPath := partialTmpPath(path, taskID)
+	tmpFile, err := sftpClient.Create(tmpPath)
 	if err != nil {
-		logx.Errorf("创建远程文件失败: %v", err)
+		logx.Errorf("创建远程临时文件失败: %v", err)
 		return "", err
 	}
-	defer destFile.Close()
+	committed := false
+	defer func() {
+		if !committed {
+			sftpClient.Remove(tmpPath)
+		}
+	}()
 
-	// 复制文件内容
-	if _, err := io.Copy(destFile, srcFile); err != nil {
-		logx.Errorf("文件复制失败: %v", err)
+	written, err = io.Copy(newLimitWriter(tmpFile, grant), r)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		logx.Errorf("文件写入失败: %v", err)
 		return "", err
 	}
+	if verify != nil {
+		if err := verify(written); err != nil {
+			logx.Errorf("上传内容校验失败: %v", err)
+			return "", err
+		}
+	}
 
-	// 确保文件权限正确
-	if err := sftpClient.Chmod(path, 0644); err != nil { // 假设目标文件需要0644权限
+	if err := sftpClient.Chmod(tmpPath, mode); err != nil {
 		logx.Errorf("文件权限设置失败: %v", err)
 		return "", err
 	}
-
-	// 生成任务ID
-	taskID := uuid.New().String()
+	if err := replaceFile(sftpClient, tmpPath, path); err != nil {
+		logx.Errorf("重命名远程文件失败: %v", err)
+		return "", err
+	}
+	committed = true
 
 	return taskID, nil
 }
 
+// replaceFile 将临时文件重命名为目标文件：优先使用可覆盖目标文件的 posix-rename，
+// 服务端不支持时先删除目标文件再重命名
+func replaceFile(sftpClient *sftp.Client, tmpPath, path string) error {
+	if err := sftpClient.PosixRename(tmpPath, path); err != nil {
+		sftpClient.Remove(path)
+		return sftpClient.Rename(tmpPath, path)
+	}
+	return nil
+}
+
 // 创建普通传输任务：客户端下载文件给指定服务器
-func (fts *FileTransferServiceImpl) CreateCommonDownloadTask(server, path string) (*sftp.Client, string, error) {
-	// 获取连接
-	client, err := fts.Pool.Get(server)
+// length 根据文件大小返回预计读取的字节数，用于配额检查，为 nil 时按整个文件计算；返回已打开的远程文件，
+// 下载结束后调用 release 关闭文件、放回连接，并传入实际发送的字节数计入用量
+func (fts *FileTransferServiceImpl) CreateCommonDownloadTask(ctx context.Context, server, path string, length func(size int64) int64) (*sftp.File, func(n int64), string, error) {
+	// 获取连接及共享的SFTP客户端
+	client, sftpClient, err := fts.Pool.GetSftp(server)
 	if err != nil {
 		logx.Errorf("获取连接失败: %v\n", err)
-		return nil, "", err
+		return nil, nil, "", err
 	}
-	defer fts.Pool.Put(server, client)
-	// 创建SFTP客户端
-	sftpClient, err := sftp.NewClient(client)
+
+	path, _, err = fts.authorize(ctx, sftpClient, server, path, AccessRead, -1)
+	if err != nil {
+		logx.Errorf("访问检查未通过: %v", err)
+		fts.Pool.Put(server, client)
+		return nil, nil, "", err
+	}
+
+	file, err := sftpClient.Open(path) // 打开远程文件
 	if err != nil {
-		logx.Errorf("创建SFTP客户端失败: %v\n", err)
-		fts.Pool.Put(server, client) // 放回连接
-		return nil, "", err
+		logx.Errorf("远程文件打开失败: %v", err)
+		fts.Pool.Put(server, client)
+		return nil, nil, "", err
+	}
+
+	expected := int64(-1) // 无法获取文件大小时按未知处理
+	if stat, err := file.Stat(); err == nil {
+		expected = stat.Size()
+		if length != nil {
+			expected = length(expected)
+		}
+	}
+	done, err := fts.begin(ctx, expected)
+	if err != nil {
+		logx.Errorf("配额检查未通过: %v", err)
+		file.Close()
+		fts.Pool.Put(server, client)
+		return nil, nil, "", err
+	}
+	release := func(n int64) {
+		file.Close()
+		fts.Pool.Put(server, client)
+		done(n)
 	}
-	// defer sftpClient.Close() // 不关闭，后面需要使用
 
 	// 生成任务ID
 	taskID := uuid.New().String()
 
-	return sftpClient, taskID, nil
+	return file, release, taskID, nil
 }
 
 // 创建两个服务器间的传输任务
-func (fts *FileTransferServiceImpl) CreateTransferBetween2STask(srcServer, srcPath, destServer, destPath string) (string, error) {
-	// 获取连接（不放回，因为传输过程中需要保持连接）
-	srcClient, err := fts.Pool.Get(srcServer)
+func (fts *FileTransferServiceImpl) CreateTransferBetween2STask(ctx context.Context, srcServer, srcPath, destServer, destPath string) (string, error) {
+	return fts.CreateTransferBetween2STaskWithProgress(ctx, srcServer, srcPath, destServer, destPath, nil)
+}
+
+// CreateTransferBetween2STaskWithProgress 创建两个服务器间的传输任务，progress 不为 nil 时回调传输进度；
+// 失败时同样返回任务ID（任务创建前失败时为空）
+func (fts *FileTransferServiceImpl) CreateTransferBetween2STaskWithProgress(ctx context.Context, srcServer, srcPath, destServer, destPath string, progress TransferProgress) (string, error) {
+	// 获取连接及共享的SFTP客户端，传输结束后放回
+	srcClient, srcSftp, err := fts.Pool.GetSftp(srcServer)
 	if err != nil {
 		logx.Errorf("获取连接失败: %v\n", err)
 		return "", err
 	}
 	defer fts.Pool.Put(srcServer, srcClient)
 
-	destClient, err := fts.Pool.Get(destServer)
+	destClient, destSftp, err := fts.Pool.GetSftp(destServer)
 	if err != nil {
 		logx.Errorf("获取连接失败: %v\n", err)
 		return "", err
 	}
 	defer fts.Pool.Put(destServer, destClient)
 
-	// 创建SFTP客户端
-	srcSftp, err := sftp.NewClient(srcClient)
+	// 实际传输逻辑
+	srcPath, _, err = fts.authorize(ctx, srcSftp, srcServer, srcPath, AccessRead, -1)
 	if err != nil {
-		logx.Errorf("创建SFTP客户端失败: %v\n", err)
+		logx.Errorf("源文件访问检查未通过: %v", err)
 		return "", err
 	}
-	defer srcSftp.Close()
+	srcFile, err := srcSftp.Open(srcPath)
+	if err != nil {
+		logx.Errorf("打开源文件失败: %v", err)
+		return "", err
+	}
+	defer srcFile.Close()
 
-	destSftp, err := sftp.NewClient(destClient)
+	srcStat, err := srcFile.Stat()
 	if err != nil {
-		logx.Errorf("创建SFTP客户端失败: %v\n", err)
+		logx.Errorf("获取源文件信息失败: %v", err)
+		return "", err
+	}
+	destPath, grant, err := fts.authorize(ctx, destSftp, destServer, destPath, AccessWrite, srcStat.Size())
+	if err != nil {
+		logx.Errorf("目标文件访问检查未通过: %v", err)
 		return "", err
 	}
-	defer destSftp.Close()
 
-	// 实际传输逻辑
-	srcFile, err := srcSftp.Open(srcPath)
+	done, err := fts.begin(ctx, srcStat.Size())
 	if err != nil {
-		logx.Errorf("打开源文件失败: %v", err)
+		logx.Errorf("配额检查未通过: %v", err)
 		return "", err
 	}
-	defer srcFile.Close()
+	var written int64
+	defer func() { done(written) }()
+
+	// 生成任务ID
+	taskID := uuid.New().String()
 
 	destFile, err := destSftp.Create(destPath)
 	if err != nil {
 		logx.Errorf("创建目标文件失败: %v", err)
-		return "", err
+		return taskID, err
 	}
 	defer destFile.Close()
 
-	// 复制文件内容
-	if _, err := io.Copy(destFile, srcFile); err != nil {
+	// 复制文件内容，源文件在传输过程中变大时按上限截止
+	dest := newLimitWriter(destFile, grant)
+	if progress != nil {
+		if err := progress(taskID, 0, srcStat.Size()); err != nil {
+			return taskID, err
+		}
+		dest = &progressWriter{w: dest, taskID: taskID, total: srcStat.Size(), progress: progress}
+	}
+	if written, err = io.Copy(dest, srcFile); err != nil {
 		logx.Errorf("文件复制失败: %v", err)
-		return "", err
+		return taskID, err
 	}
 
 	// 确保文件权限正确
 	if err := destSftp.Chmod(destPath, 0644); err != nil { // 假设目标文件需要0644权限
 		logx.Errorf("文件权限设置失败: %v", err)
-		return "", err
+		return taskID, err
 	}
 
-	// 生成任务ID
-	taskID := uuid.New().String()
-
 	return taskID, nil
 }
 
@@ -0,0 +1,41 @@
+package global
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPoolKey(t *testing.T) {
+	base := PoolKey("10.0.0.1", "alice", "pw")
+	tests := []struct {
+		name string
+		key  string
+		same bool
+	}{
+		{"相同的主机和凭据", PoolKey("10.0.0.1", "alice", "pw"), true},
+		{"不同的密码", PoolKey("10.0.0.1", "alice", "pw2"), false},
+		{"不同的用户", PoolKey("10.0.0.1", "bob", "pw"), false},
+		{"不同的主机", PoolKey("10.0.0.2", "alice", "pw"), false},
+		{"凭据拼接方式不同", PoolKey("10.0.0.1", "alicep", "w"), false},
+		{"私钥与密码位置不同", PoolKey("10.0.0.1", "alice", "", "pw"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.key == base) != tt.same {
+				t.Errorf("PoolKey = %q, base = %q, want same %t", tt.key, base, tt.same)
+			}
+			if HostOf(tt.key) != strings.SplitN(tt.key, "#", 2)[0] {
+				t.Errorf("HostOf(%q) = %q", tt.key, HostOf(tt.key))
+			}
+		})
+	}
+	if strings.Contains(base, "pw") {
+		t.Errorf("连接池中的键不应包含凭据明文: %s", base)
+	}
+	if got := HostOf("10.0.0.1:2222#abcd"); got != "10.0.0.1:2222" {
+		t.Errorf("HostOf = %q, want 10.0.0.1:2222", got)
+	}
+	if got := HostOf("10.0.0.1"); got != "10.0.0.1" {
+		t.Errorf("不带指纹的键 HostOf = %q", got)
+	}
+}
@@ -0,0 +1,21 @@
+package global
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrQuotaExceeded 超出传输配额或并发任务数上限
+var ErrQuotaExceeded = errors.New("超出配额")
+
+// TransferMeter 在传输开始前调用，用于配额检查和用量统计；size 为预计传输的字节数，未知时为 -1。
+// 返回的 done 必须在传输结束时调用一次，传入实际传输的字节数
+type TransferMeter func(ctx context.Context, size int64) (done func(n int64), err error)
+
+// begin 开始一次计量，未设置 Meter 时不做限制
+func (fts *FileTransferServiceImpl) begin(ctx context.Context, size int64) (func(int64), error) {
+	if fts.Meter == nil {
+		return func(int64) {}, nil
+	}
+	return fts.Meter(ctx, size)
+}
@@ -0,0 +1,25 @@
+package global
+
+import "io"
+
+// TransferProgress 传输进度回调：任务创建后以 written=0 调用一次，之后每写入一段内容调用一次；total 为源文件大小。
+// 返回错误时中止传输，如调用方已断开
+type TransferProgress func(taskID string, written, total int64) error
+
+// progressWriter 统计写入的字节数并回调进度
+type progressWriter struct {
+	w        io.Writer
+	taskID   string
+	written  int64
+	total    int64
+	progress TransferProgress
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if err != nil || n == 0 {
+		return n, err
+	}
+	return n, p.progress(p.taskID, p.written, p.total)
+}
@@ -0,0 +1,181 @@
+package global
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// ErrOffsetMismatch 续传的起始偏移与已写入的字节数不一致
+var ErrOffsetMismatch = errors.New("偏移量不一致")
+
+// 断点续传每次 WriteAt 的块大小，sftp 会把一块拆成多个请求并发发送
+const partialChunkSize = 1 << 20
+
+// PartialFile 分多次写入的远程文件：内容先写入同目录下的临时文件，全部写完后再重命名为目标文件
+type PartialFile struct {
+	ID      string // 任务ID
+	Server  string
+	Path    string // 规范化后的目标路径
+	TmpPath string
+	Size    int64 // 文件总大小
+
+	mu     sync.Mutex   // 同一时间只允许一个写入
+	offset atomic.Int64 // 已写入的字节数，写入过程中也可以读取
+}
+
+// Offset 返回已写入的字节数
+func (p *PartialFile) Offset() int64 {
+	return p.offset.Load()
+}
+
+// CreatePartialFile 检查访问策略和配额，并在目标目录下创建空的临时文件。
+// 创建时只检查配额，不占用任务名额；名额和用量在每次写入时计算
+func (fts *FileTransferServiceImpl) CreatePartialFile(ctx context.Context, server, path string, size int64) (*PartialFile, error) {
+	client, sftpClient, err := fts.Pool.GetSftp(server)
+	if err != nil {
+		logx.Errorf("获取连接失败: %v\n", err)
+		return nil, err
+	}
+	defer fts.Pool.Put(server, client)
+
+	path, _, err = fts.authorize(ctx, sftpClient, server, path, AccessWrite, size)
+	if err != nil {
+		logx.Errorf("访问检查未通过: %v", err)
+		return nil, err
+	}
+
+	done, err := fts.begin(ctx, size)
+	if err != nil {
+		logx.Errorf("配额检查未通过: %v", err)
+		return nil, err
+	}
+	done(0)
+
+	p := &PartialFile{ID: uuid.New().String(), Server: server, Path: path, Size: size}
+	p.TmpPath = partialTmpPath(path, p.ID)
+	file, err := sftpClient.Create(p.TmpPath)
+	if err != nil {
+		logx.Errorf("创建远程临时文件失败: %v", err)
+		return nil, err
+	}
+	file.Close()
+	return p, nil
+}
+
+func partialTmpPath(p, id string) string {
+	return path.Join(path.Dir(p), "."+path.Base(p)+"."+id+".part")
+}
+
+// WritePartial 从 offset 开始写入 r 中的内容，offset 必须等于已写入的字节数；
+// 中途出错时已写入的部分仍然有效，返回本次写入的字节数。同一文件同时只能有一个写入；
+// 每次写入占用一个任务名额，写入结束即释放，本次写入的字节数计入用量
+func (fts *FileTransferServiceImpl) WritePartial(ctx context.Context, p *PartialFile, offset int64, r io.Reader) (written int64, err error) {
+	if !p.mu.TryLock() {
+		return 0, fmt.Errorf("%w: 该文件正在写入", ErrOffsetMismatch)
+	}
+	defer p.mu.Unlock()
+	if current := p.offset.Load(); offset != current {
+		return 0, fmt.Errorf("%w: 请求的偏移为 %d，已写入 %d 字节", ErrOffsetMismatch, offset, current)
+	}
+
+	done, err := fts.begin(ctx, p.Size-offset)
+	if err != nil {
+		logx.Errorf("配额检查未通过: %v", err)
+		return 0, err
+	}
+	defer func() { done(written) }()
+
+	client, sftpClient, err := fts.Pool.GetSftp(p.Server)
+	if err != nil {
+		logx.Errorf("获取连接失败: %v\n", err)
+		return 0, err
+	}
+	defer fts.Pool.Put(p.Server, client)
+
+	file, err := sftpClient.OpenFile(p.TmpPath, os.O_WRONLY)
+	if err != nil {
+		logx.Errorf("打开远程临时文件失败: %v", err)
+		return 0, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, partialChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		offset := p.offset.Load()
+		n, readErr := io.ReadFull(r, buf[:min(int64(len(buf)), p.Size-offset+1)])
+		if n > 0 {
+			if offset+int64(n) > p.Size {
+				return written, fmt.Errorf("%w: 写入的内容超出文件大小 %d", ErrFileTooLarge, p.Size)
+			}
+			if _, err := file.WriteAt(buf[:n], offset); err != nil {
+				logx.Errorf("文件写入失败: %v", err)
+				return written, err
+			}
+			p.offset.Add(int64(n))
+			written += int64(n)
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
+
+// CommitPartial 全部内容写入后设置权限并重命名为目标文件
+func (fts *FileTransferServiceImpl) CommitPartial(p *PartialFile, mode os.FileMode) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if offset := p.offset.Load(); offset != p.Size {
+		return fmt.Errorf("%w: 文件大小 %d，已写入 %d 字节", ErrOffsetMismatch, p.Size, offset)
+	}
+
+	client, sftpClient, err := fts.Pool.GetSftp(p.Server)
+	if err != nil {
+		logx.Errorf("获取连接失败: %v\n", err)
+		return err
+	}
+	defer fts.Pool.Put(p.Server, client)
+
+	if err := sftpClient.Chmod(p.TmpPath, mode); err != nil {
+		logx.Errorf("文件权限设置失败: %v", err)
+		return err
+	}
+	if err := replaceFile(sftpClient, p.TmpPath, p.Path); err != nil {
+		logx.Errorf("重命名远程文件失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// AbortPartial 放弃写入并删除临时文件，已写入的字节数在写入时已计入用量
+func (fts *FileTransferServiceImpl) AbortPartial(p *PartialFile) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	client, sftpClient, err := fts.Pool.GetSftp(p.Server)
+	if err != nil {
+		logx.Errorf("获取连接失败: %v\n", err)
+		return err
+	}
+	defer fts.Pool.Put(p.Server, client)
+
+	if err := sftpClient.Remove(p.TmpPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logx.Errorf("删除远程临时文件失败: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"file-transfer/logs"
+	g "file-transfer/transfer/global"
+	trans "file-transfer/transfer/trans-init"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type EvictRequest struct {
+	Server string `json:"server" form:"server"` // 要驱逐的服务器
+	All    bool   `json:"all" form:"all"`       // 为 true 时驱逐全部连接
+}
+
+type ResetBreakerRequest struct {
+	Server string `json:"server" form:"server" binding:"required"`
+}
+
+type WarmupRequest struct {
+	Hosts []trans.WarmupHost `json:"hosts"`
+}
+
+// 查看连接池中的连接
+func ListPoolConnections(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"connections": g.Pool.Snapshot(),
+		"capacity":    g.Pool.Capacity,
+		"breakers":    g.Pool.Breakers(),
+	})
+}
+
+// 强制驱逐指定服务器或全部连接
+func EvictPoolConnections(c *gin.Context) {
+	username := c.GetString("username")
+
+	var request EvictRequest
+	if err := c.ShouldBind(&request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+
+	evicted, err := EvictConnections(request.Server, request.All)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	logs.Sugar.Infow("驱逐连接", "username", username, "detail", fmt.Sprintf("server=%s all=%v evicted=%d", request.Server, request.All, evicted))
+	c.JSON(http.StatusOK, gin.H{"message": "驱逐完成", "evicted": evicted})
+}
+
+// 为一组服务器预先建立连接
+func WarmupPool(c *gin.Context) {
+	username := c.GetString("username")
+
+	var request WarmupRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+	if len(request.Hosts) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "服务器列表不能为空"})
+		return
+	}
+
+	results := WarmupHosts(request.Hosts)
+
+	logs.Sugar.Infow("预热连接", "username", username, "detail", fmt.Sprintf("预热 %d 台服务器", len(request.Hosts)))
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// 手动关闭指定服务器的熔断器
+func ResetBreaker(c *gin.Context) {
+	username := c.GetString("username")
+
+	var request ResetBreakerRequest
+	if err := c.ShouldBind(&request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+
+	cleared := g.Pool.ResetBreaker(request.Server)
+
+	logs.Sugar.Infow("重置熔断器", "username", username, "detail", fmt.Sprintf("server=%s cleared=%v", request.Server, cleared))
+	c.JSON(http.StatusOK, gin.H{"message": "熔断器已重置", "cleared": cleared})
+}
+
+// EvictConnections 驱逐指定服务器或全部连接，返回驱逐的数量
+func EvictConnections(server string, all bool) (int, error) {
+	if all {
+		return g.Pool.EvictAll(), nil
+	}
+	if server == "" {
+		return 0, errors.New("请指定要驱逐的服务器")
+	}
+	return g.Pool.Evict(server), nil
+}
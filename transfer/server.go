@@ -1,65 +1,121 @@
 package transfer
 
 import (
+	"context"
+	"errors"
+	"file-transfer/inventory"
 	"file-transfer/transfer/global"
 	trans "file-transfer/transfer/trans-init"
 	"io"
+	"os"
+
+	"github.com/pkg/sftp"
 )
 
-// UploadFileToServer 将文件内容上传到目标服务器
-func UploadFileToServer(server, path, user, auth string, fileData []byte) error {
-	// 如果不存在连接，尝试创建
-	if global.FTS.Pool.Connections[server] == nil {
-		err := trans.CreateConnectionToPool(global.Pool, server, user, auth)
+// ResolveTarget 确定要连接的服务器：指定主机ID时从主机清单读取地址和凭据，否则使用请求中携带的地址和凭据
+func ResolveTarget(hostID, server, user, auth, proxy string) (trans.SSHTarget, error) {
+	if hostID != "" {
+		if inventory.Default == nil {
+			return trans.SSHTarget{}, errors.New("主机清单未启用")
+		}
+		target, err := inventory.Default.Resolve(hostID)
 		if err != nil {
-			return err
+			return trans.SSHTarget{}, err
+		}
+		if proxy != "" { // 请求中指定的代理优先
+			target.Proxy = proxy
 		}
+		return target, nil
 	}
 
-	// 创建上传任务
-	_, err := global.FTS.CreateCommonUploadTaskFromBytes(fileData, server, path)
-	return err
+	if server == "" {
+		return trans.SSHTarget{}, errors.New("请指定服务器地址或主机ID")
+	}
+	return trans.SSHTarget{User: user, Password: auth, Proxy: proxy}.WithPoolKey(server), nil
 }
 
-// DownloadFileFromServer 从服务器下载文件并返回字节流
-func DownloadFileFromServer(server, path, user, auth string) ([]byte, error) {
-	if global.FTS.Pool.Connections[server] == nil {
-		err := trans.CreateConnectionToPool(global.Pool, server, user, auth)
+// WarmupHosts 解析预热列表中的服务器并建立连接，解析失败的服务器记入结果
+func WarmupHosts(hosts []trans.WarmupHost) []trans.WarmupResult {
+	results := make([]trans.WarmupResult, len(hosts))
+
+	var targets []trans.SSHTarget
+	var indexes []int
+	for i, h := range hosts {
+		target, err := ResolveTarget(h.HostID, h.Server, h.User, h.Auth, h.Proxy)
 		if err != nil {
-			return nil, err
+			results[i] = trans.WarmupResult{Server: h.Server, Message: err.Error()}
+			if h.HostID != "" {
+				results[i].Server = h.HostID
+			}
+			continue
 		}
+		targets = append(targets, target)
+		indexes = append(indexes, i)
 	}
 
-	sftpClient, _, err := global.FTS.CreateCommonDownloadTask(server, path)
-	if err != nil {
-		return nil, err
+	for j, r := range trans.WarmupPool(global.Pool, targets) {
+		results[indexes[j]] = r
 	}
-	defer sftpClient.Close()
+	return results
+}
 
-	file, err := sftpClient.Open(path)
-	if err != nil {
-		return nil, err
+// UploadFileToServer 将文件内容上传到目标服务器
+func UploadFileToServer(ctx context.Context, target trans.SSHTarget, path string, fileData []byte) error {
+	// 如果不存在连接，尝试创建
+	if _, err := trans.EnsureConnection(global.Pool, target); err != nil {
+		return err
 	}
-	defer file.Close()
 
-	return io.ReadAll(file)
+	// 创建上传任务
+	_, err := global.FTS.CreateCommonUploadTaskFromBytes(ctx, fileData, target.Server, path)
+	return err
 }
 
-// TransferBetweenTwoServers 实现两个服务器之间的文件传输
-func TransferBetweenTwoServers(srcServer, srcPath, destServer, destPath string,
-	srcUser, dstUser, srcAuth, dstAuth string) error {
+// UploadStreamToServer 将 r 中的内容边读边上传到目标服务器，见 CreateStreamUploadTask
+func UploadStreamToServer(ctx context.Context, target trans.SSHTarget, path string, r io.Reader, size int64, mode os.FileMode, verify func(n int64) error) error {
+	if _, err := trans.EnsureConnection(global.Pool, target); err != nil {
+		return err
+	}
 
-	if global.FTS.Pool.Connections[srcServer] == nil {
-		if err := trans.CreateConnectionToPool(global.Pool, srcServer, srcUser, srcAuth); err != nil {
-			return err
-		}
+	_, err := global.FTS.CreateStreamUploadTask(ctx, r, size, mode, target.Server, path, verify)
+	return err
+}
+
+// OpenFileOnServer 打开服务器上的文件供流式读取，length 根据文件大小返回预计读取的字节数，为 nil 时按整个文件计算；
+// 读取结束后调用 release 并传入实际发送的字节数
+func OpenFileOnServer(ctx context.Context, target trans.SSHTarget, path string, length func(size int64) int64) (*sftp.File, func(n int64), error) {
+	if _, err := trans.EnsureConnection(global.Pool, target); err != nil {
+		return nil, nil, err
 	}
-	if global.FTS.Pool.Connections[destServer] == nil {
-		if err := trans.CreateConnectionToPool(global.Pool, destServer, dstUser, dstAuth); err != nil {
-			return err
-		}
+
+	file, release, _, err := global.FTS.CreateCommonDownloadTask(ctx, target.Server, path, length)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, release, nil
+}
+
+// TransferBetweenTwoServers 实现两个服务器之间的文件传输
+func TransferBetweenTwoServers(ctx context.Context, source trans.SSHTarget, srcPath string, target trans.SSHTarget, destPath string) error {
+	if _, err := trans.EnsureConnection(global.Pool, source); err != nil {
+		return err
+	}
+	if _, err := trans.EnsureConnection(global.Pool, target); err != nil {
+		return err
 	}
 
-	_, err := global.FTS.CreateTransferBetween2STask(srcServer, srcPath, destServer, destPath)
+	_, err := global.FTS.CreateTransferBetween2STask(ctx, source.Server, srcPath, target.Server, destPath)
 	return err
-}
\ No newline at end of file
+}
+
+// TransferBetweenTwoServersWithProgress 两个服务器之间传输文件并回调进度，返回任务ID（任务创建前失败时为空）
+func TransferBetweenTwoServersWithProgress(ctx context.Context, source trans.SSHTarget, srcPath string, target trans.SSHTarget, destPath string, progress global.TransferProgress) (string, error) {
+	if _, err := trans.EnsureConnection(global.Pool, source); err != nil {
+		return "", err
+	}
+	if _, err := trans.EnsureConnection(global.Pool, target); err != nil {
+		return "", err
+	}
+
+	return global.FTS.CreateTransferBetween2STaskWithProgress(ctx, source.Server, srcPath, target.Server, destPath, progress)
+}
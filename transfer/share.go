@@ -0,0 +1,253 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"file-transfer/config"
+	"file-transfer/logs"
+	"file-transfer/middlewire"
+	"file-transfer/share"
+	g "file-transfer/transfer/global"
+	trans "file-transfer/transfer/trans-init"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// Share 分享链接的配置
+var Share config.ShareConfig
+
+const defaultShareTTL = 24 * time.Hour
+
+// 分享链接只能指向主机清单中的服务器，链接中不保存服务器凭据
+type ShareRequest struct {
+	HostID       string     `json:"host_id" binding:"required"` // 主机清单中的服务器
+	Path         string     `json:"path" binding:"required"`    // 远程文件路径
+	Password     string     `json:"password"`                   // 可选，下载时需要提供的密码
+	MaxDownloads int        `json:"max_downloads"`              // 可选，下载次数上限，0 表示不限制
+	ExpiresIn    string     `json:"expires_in"`                 // 可选，有效期，如 2h
+	ExpiresAt    *time.Time `json:"expires_at"`                 // 可选，过期时间，优先于 expires_in
+}
+
+// shareView 返回给调用方的分享链接，附带完整的下载地址
+type shareView struct {
+	share.Link
+	URL string `json:"url"`
+}
+
+// shareURL 生成分享链接的下载地址，未配置 BaseURL 时按请求的地址生成
+func shareURL(c *gin.Context, token string) string {
+	base := Share.BaseURL
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return strings.TrimRight(base, "/") + "/share/" + token
+}
+
+// 访问分享链接失败时的HTTP状态码：链接失效返回410，缺少或密码错误返回401，密码错误次数过多返回429
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, share.ErrLinkNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, share.ErrLinkExpired), errors.Is(err, share.ErrLinkRevoked), errors.Is(err, share.ErrLinkExhausted):
+		return http.StatusGone
+	case errors.Is(err, share.ErrPasswordRequired), errors.Is(err, share.ErrWrongPassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, share.ErrTooManyAttempts):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// 创建远程文件的分享链接
+func CreateShare(c *gin.Context) {
+	username := c.GetString("username")
+
+	var request ShareRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		logs.Sugar.Errorw("创建分享链接", "username", username, "detail", "解析请求失败，请检查请求格式是否正确")
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+
+	ttl := Share.DefaultTTL
+	if ttl <= 0 {
+		ttl = defaultShareTTL
+	}
+	if Share.MaxTTL > 0 && ttl > Share.MaxTTL { // 默认有效期不超过上限
+		ttl = Share.MaxTTL
+	}
+	if request.ExpiresIn != "" {
+		d, err := time.ParseDuration(request.ExpiresIn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("无效的有效期: %v", err)})
+			return
+		}
+		ttl = d
+	}
+	expiresAt := time.Now().Add(ttl)
+	if request.ExpiresAt != nil {
+		expiresAt = *request.ExpiresAt
+	}
+	if Share.MaxTTL > 0 && expiresAt.After(time.Now().Add(Share.MaxTTL)) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("有效期不能超过 %s", Share.MaxTTL)})
+		return
+	}
+
+	target, ok := fileTarget(c, "创建分享链接", CommonTransRequest{HostID: request.HostID, Path: request.Path})
+	if !ok {
+		return
+	}
+	// 创建时确认文件存在且创建者有读取权限，访问时还会以创建者的身份重新校验
+	info, err := g.FTS.Stat(c.Request.Context(), target.Server, request.Path)
+	if err != nil {
+		logx.Errorf("获取文件信息失败: %v", err)
+		logs.Sugar.Errorw("创建分享链接", "username", username, "detail", "获取文件信息失败："+err.Error())
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("获取文件信息失败: %v", err)})
+		return
+	}
+	if info.IsDir {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "只能分享文件，不能分享目录"})
+		return
+	}
+
+	link, token, err := share.Default.Create(share.Link{
+		Owner:        username,
+		HostID:       request.HostID,
+		Path:         request.Path,
+		MaxDownloads: request.MaxDownloads,
+		ExpiresAt:    expiresAt,
+	}, request.Password)
+	if err != nil {
+		logx.Errorf("创建分享链接失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("创建分享链接失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("创建分享链接", "username", username, "detail", fmt.Sprintf("分享ID：%s，服务器：%s，路径：%s，过期时间：%s，下载次数上限：%d，需要密码：%t",
+		link.ID, target.Hostname(), link.Path, link.ExpiresAt.Format(time.DateTime), link.MaxDownloads, link.Protected))
+	c.JSON(http.StatusOK, gin.H{"message": "创建分享链接成功", "share": shareView{Link: link, URL: shareURL(c, token)}})
+}
+
+// 查看分享链接列表，管理员可以看到所有用户的链接
+func ListShares(c *gin.Context) {
+	owner := c.GetString("username")
+	if middlewire.IsAdmin(c.GetString("role")) {
+		owner = ""
+	}
+
+	links := share.Default.List(owner)
+	views := make([]shareView, 0, len(links))
+	for _, link := range links {
+		views = append(views, shareView{Link: link, URL: shareURL(c, share.Default.Token(link))})
+	}
+	c.JSON(http.StatusOK, gin.H{"shares": views})
+}
+
+// 撤销分享链接，只有创建者和管理员可以撤销
+func RevokeShare(c *gin.Context) {
+	username := c.GetString("username")
+	id := c.Param("id")
+
+	link, err := share.Default.Get(id)
+	if err == nil && link.Owner != username && !middlewire.IsAdmin(c.GetString("role")) {
+		err = share.ErrLinkNotFound
+	}
+	if err == nil {
+		_, err = share.Default.Revoke(id)
+	}
+	if err != nil {
+		logx.Errorf("撤销分享链接失败: %v", err)
+		c.JSON(shareErrorStatus(err), gin.H{"message": fmt.Sprintf("撤销分享链接失败: %v", err)})
+		return
+	}
+
+	logs.Sugar.Infow("撤销分享链接", "username", username, "detail", "分享ID："+id+"，所属用户："+link.Owner)
+	c.JSON(http.StatusOK, gin.H{"message": "撤销分享链接成功"})
+}
+
+// 通过分享链接下载文件，无需登录；密码通过 X-Share-Password 请求头或表单字段 password 提供。
+// 以创建者的身份下载，每次 GET/POST 请求占用一次下载次数（断点续传的后续请求同样计数），
+// 文件未能发送时归还；HEAD 请求不计数
+func ShareDownload(c *gin.Context) {
+	visitor := "访问者：" + c.ClientIP()
+
+	password := c.GetHeader("X-Share-Password")
+	if password == "" {
+		password = c.PostForm("password")
+	}
+	link, err := share.Default.Resolve(c.Param("token"), password)
+	if err != nil {
+		logx.Errorf("访问分享链接失败: %v", err)
+		logs.Sugar.Errorw("分享下载", "username", link.Owner, "detail", fmt.Sprintf("访问分享链接失败：%v，分享ID：%s，%s", err, link.ID, visitor))
+		c.JSON(shareErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+	source := "分享ID：" + link.ID + "，" + visitor
+
+	// 创建者的角色和服务器归属在每次访问时重新确定，失去权限后链接随之失效
+	role, err := middlewire.ResolveRole(c.Request.Context(), &middlewire.Claims{Username: link.Owner})
+	if err != nil {
+		logx.Errorf("查询分享创建者的角色失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": "查询用户角色失败"})
+		return
+	}
+	id := middlewire.Identity{Username: link.Owner, Role: role}
+	if !id.Can(middlewire.PermDownload) {
+		logs.Sugar.Errorw("分享下载", "username", link.Owner, "detail", "创建者已没有下载权限，"+source)
+		c.JSON(http.StatusForbidden, gin.H{"message": "分享链接已失效"})
+		return
+	}
+	c.Request = c.Request.WithContext(middlewire.WithIdentity(c.Request.Context(), id))
+
+	target, err := ResolveTarget(link.HostID, "", "", "", "")
+	if err != nil {
+		logx.Errorf("解析服务器失败: %v", err)
+		logs.Sugar.Errorw("分享下载", "username", link.Owner, "detail", "解析服务器失败，"+source)
+		c.JSON(resolveErrorStatus(err), gin.H{"message": fmt.Sprintf("解析服务器失败: %v", err)})
+		return
+	}
+	flag, err := CheckServerBelongs(c.Request.Context(), link.Owner, target.Hostname())
+	if err != nil {
+		logx.Errorf("查询服务器与用户（所在公司）的关系失败: %v", err)
+		c.JSON(belongsErrorStatus(err), gin.H{"message": fmt.Sprintf("查询服务器与用户（所在公司）的关系失败: %v", err)})
+		return
+	}
+	if !flag {
+		logs.Sugar.Errorw("分享下载", "username", link.Owner, "detail", "该服务器已不属于创建者（所在公司），"+source)
+		c.JSON(http.StatusForbidden, gin.H{"message": "分享链接已失效"})
+		return
+	}
+	if _, err := trans.EnsureConnection(g.Pool, target); err != nil {
+		logx.Errorf("创建与目标服务器的连接失败: %v", err)
+		logs.Sugar.Errorw("分享下载", "username", link.Owner, "detail", "创建与目标服务器的连接失败，"+source)
+		c.JSON(connectErrorStatus(err), gin.H{"message": fmt.Sprintf("创建与目标服务器的连接失败: %v", err)})
+		return
+	}
+
+	if c.Request.Method != http.MethodHead {
+		claimed, err := share.Default.Claim(link.ID)
+		if err != nil {
+			logs.Sugar.Errorw("分享下载", "username", link.Owner, "detail", fmt.Sprintf("访问分享链接失败：%v，%s", err, source))
+			c.JSON(shareErrorStatus(err), gin.H{"message": err.Error()})
+			return
+		}
+		defer func() {
+			// 出错或命中缓存（304）时没有发送文件，不计入下载次数
+			if c.Writer.Status() >= http.StatusMultipleChoices {
+				share.Default.Release(link.ID)
+			}
+		}()
+		source += fmt.Sprintf("，第 %d 次下载", claimed.Downloads)
+	}
+	serveRemoteFile(c, "分享下载", link.Owner, source, target, link.Path)
+}
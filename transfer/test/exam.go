@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -26,6 +27,7 @@ func main() {
 
 	// 执行文件传输任务
 	taskID, err := service.CreateTransferBetween2STask(
+		context.Background(),
 		"192.168.202.128", // 源服务器IP
 		"/home/czh/docker.txt",   // 源文件路径
 		"47.86.232.20", // 目标服务器IP
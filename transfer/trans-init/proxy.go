@@ -0,0 +1,178 @@
+package transCreateControl
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"file-transfer/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+// Proxy 出站SSH连接使用的代理配置，由 main 根据配置文件设置
+var Proxy config.ProxyConfig
+
+// 请求中指定该值表示强制直连，忽略配置文件中的代理
+const directProxy = "direct"
+
+// resolveProxy 确定连接某台服务器时使用的代理地址，优先级：请求指定 > 按主机配置 > NoProxy 直连 > 全局代理
+func resolveProxy(server, requested string) string {
+	if requested != "" {
+		if requested == directProxy {
+			return ""
+		}
+		return requested
+	}
+	if p, exists := Proxy.Hosts[server]; exists {
+		if p == directProxy {
+			return ""
+		}
+		return p
+	}
+	for _, host := range Proxy.NoProxy {
+		if host == server {
+			return ""
+		}
+	}
+	return Proxy.URL
+}
+
+// dialSSH 经由代理（可为空表示直连）建立TCP连接并完成SSH握手
+func dialSSH(addr, proxyURL string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := dialTCP(addr, proxyURL, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// 握手阶段同样受超时限制，避免对端不响应时一直阻塞
+	if config.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(config.Timeout))
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// dialTCP 根据代理地址的协议选择拨号方式：直连、SOCKS5 或 HTTP CONNECT
+func dialTCP(addr, proxyURL string, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if proxyURL == "" {
+		return dialer.Dial("tcp", addr)
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("代理地址格式错误: %v", err)
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(u, dialer)
+		if err != nil {
+			return nil, fmt.Errorf("创建SOCKS5代理失败: %v", err)
+		}
+		if cd, ok := d.(proxy.ContextDialer); ok {
+			return cd.DialContext(ctx, "tcp", addr)
+		}
+		return d.Dial("tcp", addr)
+	case "http", "https":
+		return dialHTTPConnect(ctx, dialer, u, addr)
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %s", u.Scheme)
+	}
+}
+
+// dialHTTPConnect 通过 HTTP CONNECT 隧道连接目标地址
+func dialHTTPConnect(ctx context.Context, dialer *net.Dialer, u *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			proxyAddr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			proxyAddr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("连接HTTP代理失败: %w", err)
+	}
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("与HTTP代理的TLS握手失败: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		credential := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credential)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("发送CONNECT请求失败: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("读取代理响应失败: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("代理拒绝建立隧道: %s", strings.TrimSpace(resp.Status))
+	}
+
+	// 代理可能在响应后紧跟着发送了SSH服务端的数据，保留已读入缓冲区的部分
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn 先读取缓冲区中剩余的数据，再读取底层连接
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,146 @@
+package transCreateControl
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"file-transfer/config"
+)
+
+// useProxy 设置全局代理配置，测试结束后恢复
+func useProxy(t *testing.T, cfg config.ProxyConfig) {
+	t.Helper()
+	old := Proxy
+	Proxy = cfg
+	t.Cleanup(func() { Proxy = old })
+}
+
+// connectProxy 记录收到的 CONNECT 目标并拒绝建立隧道的 HTTP 代理
+type connectProxy struct {
+	URL string
+
+	mu      sync.Mutex
+	targets []string
+}
+
+func newConnectProxy(t *testing.T) *connectProxy {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	p := &connectProxy{URL: "http://" + ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if req, err := http.ReadRequest(bufio.NewReader(conn)); err == nil {
+				p.mu.Lock()
+				p.targets = append(p.targets, req.Host)
+				p.mu.Unlock()
+			}
+			conn.Write([]byte("HTTP/1.1 403 Forbidden\r\nContent-Length: 0\r\n\r\n"))
+			conn.Close()
+		}
+	}()
+	return p
+}
+
+func (p *connectProxy) Targets() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.targets...)
+}
+
+// closedAddr 返回一个没有监听的本地地址，直连时会立即失败
+func closedAddr(t *testing.T) (string, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	ln.Close()
+	return addr.IP.String(), addr.Port
+}
+
+func TestResolveProxy(t *testing.T) {
+	useProxy(t, config.ProxyConfig{
+		URL:     "socks5://global:1080",
+		NoProxy: []string{"10.0.0.2"},
+		Hosts: map[string]string{
+			"10.0.0.1": "http://per-host:3128",
+			"10.0.0.3": directProxy,
+		},
+	})
+
+	tests := []struct {
+		name      string
+		server    string
+		requested string
+		want      string
+	}{
+		{"使用全局代理", "10.0.0.9", "", "socks5://global:1080"},
+		{"按主机配置的代理", "10.0.0.1", "", "http://per-host:3128"},
+		{"按主机配置为直连", "10.0.0.3", "", ""},
+		{"NoProxy 中的主机直连", "10.0.0.2", "", ""},
+		{"请求指定的代理优先", "10.0.0.1", "socks5://req:1080", "socks5://req:1080"},
+		{"请求强制直连", "10.0.0.9", directProxy, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveProxy(tt.server, tt.requested); got != tt.want {
+				t.Errorf("resolveProxy(%q, %q) = %q, want %q", tt.server, tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectTargetUsesHostProxy(t *testing.T) {
+	global := newConnectProxy(t)
+	perHost := newConnectProxy(t)
+	ip, port := closedAddr(t)
+
+	tests := []struct {
+		name        string
+		cfg         config.ProxyConfig
+		wantPerHost bool // 是否经由按主机配置的代理
+		wantGlobal  bool // 是否经由全局代理
+	}{
+		{"按主机配置的代理", config.ProxyConfig{URL: global.URL, Hosts: map[string]string{ip: perHost.URL}}, true, false},
+		{"按主机配置为直连", config.ProxyConfig{URL: global.URL, Hosts: map[string]string{ip: directProxy}}, false, false},
+		{"NoProxy 中的主机直连", config.ProxyConfig{URL: global.URL, NoProxy: []string{ip}}, false, false},
+		{"其他主机使用全局代理", config.ProxyConfig{URL: global.URL, Hosts: map[string]string{"10.0.0.1": perHost.URL}}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useProxy(t, tt.cfg)
+			perHostBefore, globalBefore := len(perHost.Targets()), len(global.Targets())
+
+			// 连接池中的键带有凭据指纹，代理规则仍应按主机名匹配
+			target := SSHTarget{Port: port, User: "alice", Password: "pw"}.WithPoolKey(ip)
+			pool := NewSSHConnectionPool(1, time.Minute)
+			pool.DialTimeout = 2 * time.Second
+			if err := ConnectTarget(pool, target); err == nil {
+				t.Fatal("连接应当失败")
+			}
+
+			want := net.JoinHostPort(ip, strconv.Itoa(port))
+			if got := perHost.Targets()[perHostBefore:]; (len(got) > 0) != tt.wantPerHost || (tt.wantPerHost && got[0] != want) {
+				t.Errorf("按主机配置的代理收到 %v, want 经由代理 %t 连接 %s", got, tt.wantPerHost, want)
+			}
+			if got := global.Targets()[globalBefore:]; (len(got) > 0) != tt.wantGlobal {
+				t.Errorf("全局代理收到 %v, want 经由代理 %t", got, tt.wantGlobal)
+			}
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package transCreateControl
 
 import (
+	"file-transfer/config"
 	g "file-transfer/transfer/global"
 	"fmt"
+	"net"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	"github.com/zeromicro/go-zero/core/logx"
 )
@@ -19,31 +24,255 @@ func NewDefaultFileTransferService() g.FileTransferServiceImpl {
 	return g.FileTransferServiceImpl{Pool: NewSSHConnectionPool(10, 20*time.Minute)}
 }
 
+// 连接健康检查的默认参数
+const (
+	defaultKeepaliveInterval = 30 * time.Second
+	defaultKeepaliveTimeout  = 10 * time.Second
+	defaultStaleAfter        = time.Minute
+	defaultDialTimeout       = 10 * time.Second
+	defaultBreakerThreshold  = 3
+	defaultBreakerBackoff    = 5 * time.Second
+	defaultBreakerMaxBackoff = 5 * time.Minute
+)
+
 // 提供一个创建连接池的方法
 func NewSSHConnectionPool(capacity int, timeout time.Duration) *g.SSHConnectionPool {
 	return &g.SSHConnectionPool{
-		Connections: make(map[string]*g.SSHConnection),
-		Capacity:    capacity,
-		Timeout:     timeout,
+		Connections:       make(map[string]*g.SSHConnection),
+		Capacity:          capacity,
+		Timeout:           timeout,
+		KeepaliveInterval: defaultKeepaliveInterval,
+		KeepaliveTimeout:  defaultKeepaliveTimeout,
+		StaleAfter:        defaultStaleAfter,
+		DialTimeout:       defaultDialTimeout,
+		BreakerThreshold:  defaultBreakerThreshold,
+		BreakerBackoff:    defaultBreakerBackoff,
+		BreakerMaxBackoff: defaultBreakerMaxBackoff,
+	}
+}
+
+// 根据配置创建连接池，未配置的项使用默认值
+func NewSSHConnectionPoolFromConfig(cfg config.PoolConfig) *g.SSHConnectionPool {
+	capacity := cfg.Capacity
+	if capacity <= 0 {
+		capacity = 10
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+
+	pool := NewSSHConnectionPool(capacity, timeout)
+	if cfg.KeepaliveInterval != 0 {
+		pool.KeepaliveInterval = cfg.KeepaliveInterval // 配置为负数表示关闭后台探测
+	}
+	if cfg.KeepaliveTimeout > 0 {
+		pool.KeepaliveTimeout = cfg.KeepaliveTimeout
+	}
+	if cfg.StaleAfter > 0 {
+		pool.StaleAfter = cfg.StaleAfter
+	}
+	if cfg.DialTimeout > 0 {
+		pool.DialTimeout = cfg.DialTimeout
+	}
+	if cfg.BreakerThreshold != 0 {
+		pool.BreakerThreshold = cfg.BreakerThreshold // 配置为负数表示关闭熔断
+	}
+	if cfg.BreakerBackoff > 0 {
+		pool.BreakerBackoff = cfg.BreakerBackoff
+	}
+	if cfg.BreakerMaxBackoff > 0 {
+		pool.BreakerMaxBackoff = cfg.BreakerMaxBackoff
+	}
+	pool.SftpOptions = NewSftpOptions(cfg.Sftp)
+	return pool
+}
+
+// 根据配置生成创建SFTP客户端的选项，未配置的项保持 sftp 库的默认值
+func NewSftpOptions(cfg config.SftpConfig) []sftp.ClientOption {
+	var opts []sftp.ClientOption
+	if cfg.MaxPacket > 0 {
+		opts = append(opts, sftp.MaxPacket(cfg.MaxPacket))
+	}
+	if cfg.MaxConcurrentRequestsPerFile > 0 {
+		opts = append(opts, sftp.MaxConcurrentRequestsPerFile(cfg.MaxConcurrentRequestsPerFile))
+	}
+	if cfg.UseConcurrentWrites {
+		opts = append(opts, sftp.UseConcurrentWrites(true))
+	}
+	if cfg.DisableConcurrentReads {
+		opts = append(opts, sftp.UseConcurrentReads(false))
+	}
+	return opts
+}
+
+// SSHTarget 建立SSH连接所需的信息，既可来自请求参数，也可来自主机清单
+type SSHTarget struct {
+	Server     string // 连接池中的键，由 WithPoolKey 按主机和凭据生成
+	Name       string // 主机名，用于校验服务器归属，为空时使用 Server
+	Address    string // 拨号地址，为空时使用 Server
+	Port       int    // SSH端口，为 0 时使用 22
+	User       string
+	Password   string
+	PrivateKey string // PEM 格式私钥
+	Passphrase string // 私钥口令
+	Proxy      string // 为空时使用配置文件中的代理，为 "direct" 时强制直连
+}
+
+// WithPoolKey 按主机和凭据设置连接池中的键；host 未另行指定 Address 时同时作为拨号地址
+func (t SSHTarget) WithPoolKey(host string) SSHTarget {
+	t.Server = g.PoolKey(host, t.User, t.Password, t.PrivateKey, t.Passphrase)
+	return t
+}
+
+// Hostname 返回用于归属校验的主机名
+func (t SSHTarget) Hostname() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return g.HostOf(t.Server)
+}
+
+// dialAddr 返回拨号使用的 host:port
+func (t SSHTarget) dialAddr() string {
+	host := t.Address
+	if host == "" {
+		host = g.HostOf(t.Server)
+	}
+	port := t.Port
+	if port == 0 {
+		port = 22
 	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// authMethods 根据凭据生成认证方式，私钥优先于密码
+func (t SSHTarget) authMethods() ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	if t.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if t.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(t.PrivateKey), []byte(t.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(t.PrivateKey))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析私钥失败: %v", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if t.Password != "" {
+		methods = append(methods, ssh.Password(t.Password))
+	}
+	return methods, nil
 }
 
 // CreateConnectionToPool 创建一个SSH连接并添加到连接池中
 func CreateConnectionToPool(pool *g.SSHConnectionPool, server, user, auth string) error {
+	return CreateConnectionToPoolWithProxy(pool, server, user, auth, "")
+}
+
+// CreateConnectionToPoolWithProxy 经由指定代理创建SSH连接并添加到连接池中，
+// proxyURL 为空时使用配置文件中的代理，为 "direct" 时强制直连
+func CreateConnectionToPoolWithProxy(pool *g.SSHConnectionPool, server, user, auth, proxyURL string) error {
+	return ConnectTarget(pool, SSHTarget{User: user, Password: auth, Proxy: proxyURL}.WithPoolKey(server))
+}
+
+// ConnectTarget 按目标信息创建SSH连接并添加到连接池中
+func ConnectTarget(pool *g.SSHConnectionPool, target SSHTarget) error {
+	server := target.Server
+	host := g.HostOf(server) // 熔断按主机记录，与使用的凭据无关
+
+	// 先校验凭据再申请拨号，否则半开状态下的试探名额会被凭据错误占住
+	auth, err := target.authMethods()
+	if err != nil {
+		logx.Errorf("服务器 %s 的凭据无效: %v", host, err)
+		return err
+	}
+
+	// 主机熔断中则快速失败，不再等待拨号超时
+	if err := pool.AllowDial(host); err != nil {
+		logx.Errorf("跳过拨号: %v", err)
+		return err
+	}
+
 	config := &ssh.ClientConfig{
-		User: user,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(auth), // 如果是使用私钥，则应使用ssh.PrivateKey
-		},
+		User:            target.User,
+		Auth:            auth,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 在生产环境中应该使用更安全的方式
+		Timeout:         pool.DialTimeout,
 	}
 
-	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:22", server), config)
+	client, err := dialSSH(target.dialAddr(), resolveProxy(host, target.Proxy), config)
 	if err != nil {
-		logx.Errorf("无法连接到服务器 %s: %v", server, err)
+		logx.Errorf("无法连接到服务器 %s: %v", host, err)
+		if g.IsHostFailure(err) {
+			pool.DialFailed(host, err)
+		} else {
+			pool.DialSucceeded(host) // 主机可达（如认证失败），不计入熔断
+		}
 		return err
 	}
+	pool.DialSucceeded(host)
 
 	pool.Add(server, client)
 	return nil
 }
+
+// EnsureConnection 连接池中没有可用连接时按目标信息新建连接，返回是否新建
+func EnsureConnection(pool *g.SSHConnectionPool, target SSHTarget) (bool, error) {
+	if client, err := pool.Get(target.Server); err == nil {
+		pool.Put(target.Server, client)
+		return false, nil
+	}
+	if err := ConnectTarget(pool, target); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WarmupHost 预热连接所需的服务器信息，指定 HostID 时从主机清单读取地址和凭据
+type WarmupHost struct {
+	HostID string `json:"host_id"`
+	Server string `json:"server"`
+	User   string `json:"user"`
+	Auth   string `json:"auth"`
+	Proxy  string `json:"proxy"` // 可选，覆盖配置文件中的代理
+}
+
+// WarmupResult 单个服务器的预热结果
+type WarmupResult struct {
+	Server  string `json:"server"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// WarmupPool 并发地为一组服务器建立连接并放入连接池，已有可用连接的服务器直接跳过
+func WarmupPool(pool *g.SSHConnectionPool, targets []SSHTarget) []WarmupResult {
+	results := make([]WarmupResult, len(targets))
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target SSHTarget) {
+			defer wg.Done()
+			results[i].Server = g.HostOf(target.Server)
+
+			created, err := EnsureConnection(pool, target)
+			if err != nil {
+				results[i].Message = err.Error()
+				return
+			}
+			results[i].Success = true
+			if created {
+				results[i].Message = "连接已建立"
+			} else {
+				results[i].Message = "连接已存在"
+			}
+		}(i, target)
+	}
+	wg.Wait()
+
+	return results
+}
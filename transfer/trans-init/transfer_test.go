@@ -0,0 +1,36 @@
+package transCreateControl
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"file-transfer/config"
+	g "file-transfer/transfer/global"
+)
+
+func TestConnectTargetInvalidKeyKeepsProbe(t *testing.T) {
+	useProxy(t, config.ProxyConfig{}) // 直连，不受配置文件中代理的影响
+	ip, port := closedAddr(t)
+	pool := NewSSHConnectionPool(1, time.Minute)
+	pool.BreakerThreshold = 1
+	pool.BreakerBackoff = time.Millisecond
+	pool.BreakerMaxBackoff = time.Millisecond
+	pool.DialFailed(ip, errors.New("connection refused"))
+	time.Sleep(5 * time.Millisecond) // 退避结束，进入半开状态
+
+	bad := SSHTarget{Port: port, User: "alice", PrivateKey: "not a key"}.WithPoolKey(ip)
+	if err := ConnectTarget(pool, bad); err == nil || errors.Is(err, g.ErrHostUnavailable) {
+		t.Fatalf("私钥无效应返回凭据错误: err = %v", err)
+	}
+	if info := pool.Breakers(); len(info) != 1 || info[0].State != g.BreakerHalfOpen {
+		t.Fatalf("凭据错误不应改变熔断状态: %+v", info)
+	}
+
+	// 试探名额未被占用，下一次拨号仍可进行
+	good := SSHTarget{Port: port, User: "alice", Password: "pw"}.WithPoolKey(ip)
+	err := ConnectTarget(pool, good)
+	if err == nil || errors.Is(err, g.ErrHostUnavailable) {
+		t.Fatalf("半开状态应允许试探拨号: err = %v", err)
+	}
+}
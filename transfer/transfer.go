@@ -1,10 +1,9 @@
 package transfer
 
 import (
-	// "context"
-
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"path"
@@ -12,9 +11,12 @@ import (
 	"strings"
 	"time"
 
+	"file-transfer/inventory"
 	"file-transfer/logs"
+	"file-transfer/middlewire"
 	g "file-transfer/transfer/global"
 	trans "file-transfer/transfer/trans-init" // 请替换为您的实际项目路径
+	"file-transfer/usersvc"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zeromicro/go-zero/core/logx"
@@ -29,48 +31,88 @@ type RequestP2P struct {
 	TargetUser   string `json:"target_user"`
 	SourceAuth   string `json:"source_auth"`
 	TargetAuth   string `json:"target_auth"`
+	SourceProxy  string `json:"source_proxy"`   // 可选，连接源服务器使用的代理
+	TargetProxy  string `json:"target_proxy"`   // 可选，连接目标服务器使用的代理
+	SourceHostID string `json:"source_host_id"` // 可选，主机清单中的源服务器，指定后无需携带地址和凭据
+	TargetHostID string `json:"target_host_id"` // 可选，主机清单中的目标服务器
 }
 
 type CommonTransRequest struct {
-	Server string `json:"server" form:"server"` // 服务器地址
-	Path   string `json:"path" form:"path"`     // 文件路径
-	User   string `json:"user" form:"user"`     // SSH用户名
-	Auth   string `json:"auth" form:"auth"`     // SSH密码或密钥
+	Server string `json:"server" form:"server"`   // 服务器地址
+	Path   string `json:"path" form:"path"`       // 文件路径
+	User   string `json:"user" form:"user"`       // SSH用户名
+	Auth   string `json:"auth" form:"auth"`       // SSH密码或密钥
+	Proxy  string `json:"proxy" form:"proxy"`     // 可选，连接服务器使用的代理，"direct" 表示直连
+	HostID string `json:"host_id" form:"host_id"` // 可选，主机清单中的服务器，指定后无需携带地址和凭据
 }
 
-// 查询服务器是否是用户所在公司的服务器
-func CheckServerBelongs(username, server string) (bool, error) {
-	// conn, err := grpc.NewClient("localhost:9001", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	// if err != nil {
-	// 	logx.Errorf("did not connect: %v", err)
-	// 	return false, err
-	// }
-	// defer conn.Close()
+// 查询服务器是否是用户所在公司的服务器；用户服务不可用时返回错误，调用方应拒绝请求
+func CheckServerBelongs(ctx context.Context, username, server string) (bool, error) {
+	// API密钥限定了服务器时，范围外的服务器一律视为不属于
+	if id, ok := middlewire.IdentityFrom(ctx); ok && len(id.Hosts) > 0 && !hostInScope(id.Hosts, server) {
+		return false, nil
+	}
+	if usersvc.Default == nil {
+		return false, usersvc.ErrNotConfigured
+	}
+	return usersvc.Default.ServerBelongs(ctx, username, server)
+}
 
-	// userServiceClient := user.NewUserServiceClient(conn)
+// hostInScope 判断服务器是否在限定范围内，范围中的每一项可以是主机名或主机清单中的主机ID
+func hostInScope(hosts []string, server string) bool {
+	for _, h := range hosts {
+		if h == server {
+			return true
+		}
+		if inventory.Default == nil {
+			continue
+		}
+		if host, err := inventory.Default.GetHost(h); err == nil && (host.Name == server || host.Endpoint() == server) {
+			return true
+		}
+	}
+	return false
+}
 
-	// // 调用 GetUserByName 获取用户信息
-	// userResp, err := userServiceClient.GetUserInfo(context.Background(), &user.GetUserInfoRequest{Username: username})
-	// if err != nil {
-	// 	logx.Errorf("查询用户失败: %v", err)
-	// 	return false, err
-	// }
+// 查询服务器归属失败时的HTTP状态码：用户服务不可用返回503
+func belongsErrorStatus(err error) int {
+	if errors.Is(err, usersvc.ErrUnavailable) || errors.Is(err, usersvc.ErrNotConfigured) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
 
-	// // 调用 GetHostInfoByName 获取服务器信息
-	// hostInfoResp, err := userServiceClient.GetHostInfo(context.Background(), &user.GetHostInfoRequest{Hostname: server})
-	// if err != nil {
-	// 	logx.Errorf("查询服务器失败: %v", err)
-	// 	return false, err
-	// }
+// 解析服务器失败时的HTTP状态码：主机不存在返回404，其余视为请求参数问题
+func resolveErrorStatus(err error) int {
+	if errors.Is(err, inventory.ErrHostNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
 
-	// // 判断服务器是否属于用户所在的公司或者属于用户自己
-	// if userResp.CompanyId == hostInfoResp.CompanyId || userResp.Name == hostInfoResp.UserName {
-	// 	return true, nil // 服务器属于用户(所在公司)
-	// } else {
-	// 	return false, nil // 服务器不属于用户(所在公司)
-	// }
+// 连接失败时的HTTP状态码：主机熔断中返回503，其余视为请求参数问题
+func connectErrorStatus(err error) int {
+	if errors.Is(err, g.ErrHostUnavailable) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
 
-	return true, nil // 测试环境,暂时不做判断
+// 访问远程文件失败时的HTTP状态码：被访问策略拒绝返回403，超出大小上限返回413，
+// 超出配额返回429，查询配额所需的用户信息失败返回503，其余返回 fallback
+func accessErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, g.ErrAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, g.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, g.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, usersvc.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return fallback
+	}
 }
 
 // 两服务器间单文件传输
@@ -91,10 +133,24 @@ func TransferBetweenTwoServer(c *gin.Context) {
 		return
 	}
 
-	flag, err := CheckServerBelongs(username, request.SourceServer)
+	// 确定源、目标服务器的地址和凭据（指定主机ID时由主机清单提供）
+	source, err := ResolveTarget(request.SourceHostID, request.SourceServer, request.SourceUser, request.SourceAuth, request.SourceProxy)
+	if err != nil {
+		logx.Errorf("解析源服务器失败: %v", err)
+		c.JSON(resolveErrorStatus(err), gin.H{"message": fmt.Sprintf("解析源服务器失败: %v", err)})
+		return
+	}
+	target, err := ResolveTarget(request.TargetHostID, request.TargetServer, request.TargetUser, request.TargetAuth, request.TargetProxy)
+	if err != nil {
+		logx.Errorf("解析目标服务器失败: %v", err)
+		c.JSON(resolveErrorStatus(err), gin.H{"message": fmt.Sprintf("解析目标服务器失败: %v", err)})
+		return
+	}
+
+	flag, err := CheckServerBelongs(c.Request.Context(), username, source.Hostname())
 	if err != nil {
 		logx.Errorf("查询源服务器是否属于用户（所在公司）: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("查询源服务器是否属于用户（所在公司）失败: %v", err.Error())})
+		c.JSON(belongsErrorStatus(err), gin.H{"message": fmt.Sprintf("查询源服务器是否属于用户（所在公司）失败: %v", err.Error())})
 		return
 	}
 	if !flag {
@@ -103,10 +159,10 @@ func TransferBetweenTwoServer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "该源服务器不属于用户（所在公司）"})
 		return
 	}
-	flag, err = CheckServerBelongs(username, request.TargetServer)
+	flag, err = CheckServerBelongs(c.Request.Context(), username, target.Hostname())
 	if err != nil {
 		logx.Errorf("查询用户与目标服务器是否属于同一公司失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("查询目标服务器是否属于用户（所在公司）失败: %v", err.Error())})
+		c.JSON(belongsErrorStatus(err), gin.H{"message": fmt.Sprintf("查询目标服务器是否属于用户（所在公司）失败: %v", err.Error())})
 		return
 	}
 	if !flag {
@@ -120,40 +176,33 @@ func TransferBetweenTwoServer(c *gin.Context) {
 	if g.Pool == nil {
 		g.Pool = trans.NewSSHConnectionPool(10, 5*time.Minute) // 假设容量为10，超时时间为5分钟
 	}
-	// 检查是否已存在到源服务器的SSH连接
-	if g.FTS.Pool.Connections[request.SourceServer] == nil {
-		// 如果不存在，则创建并添加到池中
-		err = trans.CreateConnectionToPool(g.Pool, request.SourceServer, request.SourceUser, request.SourceAuth)
-		if err != nil {
-			logx.Errorf("创建与源服务器的连接失败: %v", err)
-			logs.Sugar.Errorw("两服务器间单文件传输", "username", username, "detail", "创建与源服务器的连接失败，请检查源服务器是否正确")
-			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("创建与源服务器的连接失败: %v", err)})
-			return
-		}
+	// 检查是否已存在到源服务器的SSH连接，如果不存在，则创建并添加到池中
+	if _, err := trans.EnsureConnection(g.Pool, source); err != nil {
+		logx.Errorf("创建与源服务器的连接失败: %v", err)
+		logs.Sugar.Errorw("两服务器间单文件传输", "username", username, "detail", "创建与源服务器的连接失败，请检查源服务器是否正确")
+		c.JSON(connectErrorStatus(err), gin.H{"message": fmt.Sprintf("创建与源服务器的连接失败: %v", err)})
+		return
 	}
-	// 检查是否已存在到目标服务器的SSH连接
-	if g.FTS.Pool.Connections[request.TargetServer] == nil {
-		// 如果不存在，则创建并添加到池中
-		err = trans.CreateConnectionToPool(g.Pool, request.TargetServer, request.TargetUser, request.TargetAuth)
-		if err != nil {
-			logx.Errorf("创建与目标服务器的连接失败: %v", err)
-			logs.Sugar.Errorw("两服务器间单文件传输", "username", username, "detail", "创建与目标服务器的连接失败，请检查目标服务器是否正确")
-			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("创建与目标服务器的连接失败: %v", err)})
-			return
-		}
+	// 检查是否已存在到目标服务器的SSH连接，如果不存在，则创建并添加到池中
+	if _, err := trans.EnsureConnection(g.Pool, target); err != nil {
+		logx.Errorf("创建与目标服务器的连接失败: %v", err)
+		logs.Sugar.Errorw("两服务器间单文件传输", "username", username, "detail", "创建与目标服务器的连接失败，请检查目标服务器是否正确")
+		c.JSON(connectErrorStatus(err), gin.H{"message": fmt.Sprintf("创建与目标服务器的连接失败: %v", err)})
+		return
 	}
 
 	// 执行文件传输任务
 	taskID, err := g.FTS.CreateTransferBetween2STask(
-		request.SourceServer, // 源服务器IP
-		request.SourcePath,   // 源文件路径
-		request.TargetServer, // 目标服务器IP
-		request.TargetPath,   // 目标文件路径
+		c.Request.Context(),
+		source.Server,      // 源服务器IP
+		request.SourcePath, // 源文件路径
+		target.Server,      // 目标服务器IP
+		request.TargetPath, // 目标文件路径
 	)
 	if err != nil {
 		logx.Errorf("文件传输失败: %v", err)
 		logs.Sugar.Errorw("两服务器间单文件传输", "username", username, "detail", "文件传输失败，请确认文件路径是否正确")
-		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("文件传输失败: %v", err), "task_id": taskID})
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": fmt.Sprintf("文件传输失败: %v", err), "task_id": taskID})
 		return
 	}
 
@@ -162,8 +211,14 @@ func TransferBetweenTwoServer(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "文件传输任务已启动", "task_id": taskID})
 }
 
-// 客户端与一个指定的服务器进行文件传输，上传
-func CommonUpload(c *gin.Context) {
+// GET/HEAD 下载通过请求头传递SSH凭据
+const (
+	sshUserHeader = "X-SSH-User"
+	sshAuthHeader = "X-SSH-Auth"
+)
+
+// 客户端与一个指定的服务器进行文件传输，下载
+func CommonDownload(c *gin.Context) {
 	Username, exists := c.Get("username") // 从上下文中获取用户名
 	if !exists {
 		logx.Error("用户未登录")
@@ -175,86 +230,34 @@ func CommonUpload(c *gin.Context) {
 	var request CommonTransRequest
 	if err := c.ShouldBind(&request); err != nil {
 		logx.Errorf("解析请求失败: %v", err)
-		logs.Sugar.Errorw("文件上传", "username", username, "detail", "解析请求失败，请检查请求格式是否正确")
+		logs.Sugar.Errorw("文件下载", "username", username, "detail", "解析请求失败，请检查请求格式是否正确")
 		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
 		return
 	}
-
-	// 检查服务器是否属于用户所在的公司或是否是用户自己的服务器
-	flag, err := CheckServerBelongs(username, request.Server)
-	if err != nil {
-		logx.Errorf("查询服务器与用户（所在公司）的关系失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("查询服务器与用户（所在公司）的关系失败: %v", err)})
-		return
-	}
-	if !flag {
-		logx.Error("该服务器不属于用户（所在公司）")
-		logs.Sugar.Errorw("文件上传", "username", username, "detail", "该服务器不属于用户（所在公司）")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "该服务器不属于用户（所在公司）"})
+	// 查询字符串会出现在浏览器历史、代理和访问日志中，不允许携带SSH凭据；
+	// GET/HEAD 下载使用 host_id 或通过请求头传递凭据，POST 下载可以在请求体中携带
+	query := c.Request.URL.Query()
+	if query.Has("user") || query.Has("auth") {
+		logs.Sugar.Errorw("文件下载", "username", username, "detail", "查询字符串中携带了SSH凭据")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "不能在查询字符串中携带SSH凭据，请使用 host_id、X-SSH-User/X-SSH-Auth 请求头或 POST 请求体"})
 		return
 	}
-
-	// 获取上传的文件
-	file, err := c.FormFile("file")
-	if err != nil {
-		logx.Errorf("获取要上传的文件失败: %v", err)
-		logs.Sugar.Errorw("文件上传", "username", username, "detail", "获取要上传的文件失败")
-		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("获取要上传的文件失败: %v", err)})
-		return
-	}
-
-	// 检查是否已存在到指定服务器的SSH连接
-	if g.FTS.Pool.Connections[request.Server] == nil {
-		// 如果不存在，则创建并添加到池中
-		err = trans.CreateConnectionToPool(g.Pool, request.Server, request.User, request.Auth)
-		if err != nil {
-			logx.Errorf("创建与目标服务器的连接失败: %v", err)
-			logs.Sugar.Errorw("文件上传", "username", username, "detail", "创建与目标服务器的连接失败，请检查目标服务器是否正确")
-			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("创建与目标服务器的连接失败: %v", err)})
-			return
-		}
+	if c.Request.Method != http.MethodPost {
+		request.User, request.Auth = c.GetHeader(sshUserHeader), c.GetHeader(sshAuthHeader)
 	}
 
-	// 执行文件传输任务
-	taskID, err := g.FTS.CreateCommonUploadTask(
-		file,
-		request.Server, // 目标服务器IP
-		request.Path,   // 目标文件路径
-	)
+	// 确定服务器的地址和凭据（指定主机ID时由主机清单提供）
+	target, err := ResolveTarget(request.HostID, request.Server, request.User, request.Auth, request.Proxy)
 	if err != nil {
-		logx.Errorf("文件上传失败: %v", err)
-		logs.Sugar.Errorw("文件上传", "username", username, "detail", "文件上传失败，请检查文件路径是否正确")
-		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("文件上传失败: %v", err), "task_id": taskID})
-		return
-	}
-
-	fmt.Printf("文件上传任务已完成，任务ID: %s\n", taskID)
-	logs.Sugar.Infow("文件上传", "username", username, "detail", "文件上传成功，任务ID："+taskID)
-	c.JSON(http.StatusOK, gin.H{"message": "文件上传完成", "task_id": taskID})
-}
-
-// 客户端与一个指定的服务器进行文件传输，下载
-func CommonDownload(c *gin.Context) {
-	Username, exists := c.Get("username") // 从上下文中获取用户名
-	if !exists {
-		logx.Error("用户未登录")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "未登录"})
-		return
-	}
-	username := Username.(string)
-
-	var request CommonTransRequest
-	if err := c.ShouldBind(&request); err != nil {
-		logx.Errorf("解析请求失败: %v", err)
-		logs.Sugar.Errorw("文件下载", "username", username, "detail", "解析请求失败，请检查请求格式是否正确")
-		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		logx.Errorf("解析服务器失败: %v", err)
+		c.JSON(resolveErrorStatus(err), gin.H{"message": fmt.Sprintf("解析服务器失败: %v", err)})
 		return
 	}
 
-	flag, err := CheckServerBelongs(username, request.Server)
+	flag, err := CheckServerBelongs(c.Request.Context(), username, target.Hostname())
 	if err != nil {
 		logx.Errorf("查询服务器与用户（所在公司）的关系失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("查询服务器与用户（所在公司）的关系失败: %v", err)})
+		c.JSON(belongsErrorStatus(err), gin.H{"message": fmt.Sprintf("查询服务器与用户（所在公司）的关系失败: %v", err)})
 		return
 	}
 	if !flag {
@@ -263,80 +266,123 @@ func CommonDownload(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "该服务器不属于用户（所在公司）"})
 		return
 	}
-	// 检查是否已存在到指定服务器的SSH连接
-	if g.FTS.Pool.Connections[request.Server] == nil {
-		// 如果不存在，则创建并添加到池中
-		err = trans.CreateConnectionToPool(g.Pool, request.Server, request.User, request.Auth)
-		if err != nil {
-			logx.Errorf("创建与目标服务器的连接失败: %v", err)
-			logs.Sugar.Errorw("文件下载", "username", username, "detail", "创建与目标服务器的连接失败，请检查目标服务器是否正确")
-			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("创建与目标服务器的连接失败: %v", err)})
-			return
-		}
-	}
-	// 执行文件传输任务
-	sftpClient, task_id, err := g.FTS.CreateCommonDownloadTask(
-		request.Server,
-		request.Path,
-	)
-	if err != nil {
-		logx.Errorf("获取连接失败: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("获取连接失败: %v", err)})
+	// 检查是否已存在到指定服务器的SSH连接，如果不存在，则创建并添加到池中
+	if _, err := trans.EnsureConnection(g.Pool, target); err != nil {
+		logx.Errorf("创建与目标服务器的连接失败: %v", err)
+		logs.Sugar.Errorw("文件下载", "username", username, "detail", "创建与目标服务器的连接失败，请检查目标服务器是否正确")
+		c.JSON(connectErrorStatus(err), gin.H{"message": fmt.Sprintf("创建与目标服务器的连接失败: %v", err)})
 		return
 	}
-	defer sftpClient.Close()
+	serveRemoteFile(c, "文件下载", username, "", target, request.Path)
+}
 
-	file, err := sftpClient.Open(request.Path) // 打开远程文件
+// serveRemoteFile 以附件形式返回远程文件，支持 Range 和条件请求，并记录审计日志。
+// 调用方需已校验服务器归属并建立连接；source 说明下载来源（如分享链接），附加在审计日志中
+func serveRemoteFile(c *gin.Context, operation, username, source string, target trans.SSHTarget, filePath string) {
+	detail := func(s string) string {
+		if source == "" {
+			return s
+		}
+		return s + "，" + source
+	}
+
+	// 执行文件传输任务；范围请求只发送文件的一部分，配额预检按请求的范围计算
+	rangeHeader, ifRange := c.GetHeader("Range"), c.GetHeader("If-Range")
+	file, release, task_id, err := g.FTS.CreateCommonDownloadTask(
+		c.Request.Context(),
+		target.Server,
+		filePath,
+		func(size int64) int64 { return rangeLength(rangeHeader, ifRange, size) },
+	)
 	if err != nil {
 		logx.Errorf("远程文件打开失败: %v", err)
-		logs.Sugar.Errorw("文件下载", "username", username, "detail", "远程文件打开失败，请检查文件路径是否正确")
-		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("远程文件打开失败: %v", err)})
+		logs.Sugar.Errorw(operation, "username", username, "detail", detail("远程文件打开失败，请检查文件路径是否正确"))
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": fmt.Sprintf("远程文件打开失败: %v", err)})
 		return
 	}
-	defer file.Close()
+	defer func() { release(int64(max(c.Writer.Size(), 0))) }()
 
 	// 判断文件是否存在或是目录
 	stat, err := file.Stat() // 获取文件信息，包括大小等
 	if err != nil {
 		logx.Errorf("文件不存在: %v", err)
-		logs.Sugar.Errorw("文件下载", "username", username, "detail", "文件不存在")
+		logs.Sugar.Errorw(operation, "username", username, "detail", detail("文件不存在"))
 		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("文件不存在: %v", err)})
 		return
 	}
 	if stat.IsDir() {
 		logx.Errorf("路径是一个目录: %v", err)
-		logs.Sugar.Errorw("文件下载", "username", username, "detail", "路径是一个目录")
+		logs.Sugar.Errorw(operation, "username", username, "detail", detail("路径是一个目录"))
 		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("路径是一个目录: %v", err)})
 		return
 	}
 
-	filename := path.Base(request.Path)
+	filename := path.Base(filePath)
 	encodedFilename := url.PathEscape(filename)
-	// fmt.Println(filename + "\n" + encodedFilename)
 	c.Header("Content-Type", "application/octet-stream")
 	c.Header("Content-Disposition", "attachment; "+fmt.Sprintf(`filename="%s"; filename*=UTF-8''%s`,
 		encodedFilename, encodedFilename))
+	// ETag 由修改时间和大小生成，文件变化后失效，供 If-None-Match 和 If-Range 使用
+	c.Header("ETag", fmt.Sprintf(`"%x-%x"`, stat.ModTime().Unix(), stat.Size()))
+
+	// ServeContent 处理 Range（单段及多段）、If-Range、If-None-Match、If-Modified-Since 等，
+	// 通过 Seek 只读取请求的部分。条件请求按 GET 处理，POST 下载命中缓存时同样返回 304
+	req := c.Request
+	if req.Method == http.MethodPost {
+		req = req.Clone(req.Context())
+		req.Method = http.MethodGet
+	}
+	http.ServeContent(c.Writer, req, filename, stat.ModTime(), file)
 
-	fi, err := file.Stat() // 获取文件信息，包括大小等
-	if err != nil {
-		logx.Errorf("获取文件信息失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "获取文件信息失败"})
+	status := c.Writer.Status()
+	if status >= http.StatusBadRequest {
+		logx.Errorf("文件下载失败，状态码：%d", status)
+		logs.Sugar.Errorw(operation, "username", username, "detail", detail(fmt.Sprintf("文件下载失败，状态码：%d，任务ID：%s", status, task_id)))
 		return
 	}
-	c.Header("Content-Length", strconv.FormatInt(fi.Size(), 10)) // 设置文件大小
-
-	// WriterHeader 不是必须的，Gin会自动处理
-	// c.Writer.WriteHeader(http.StatusOK)
+	if req.Context().Err() != nil {
+		logx.Error("客户端已断开连接")
+		return
+	}
+	logs.Sugar.Infow(operation, "username", username, "detail", detail(fmt.Sprintf("文件下载成功，状态码：%d，任务ID：%s", status, task_id)))
+}
 
-	if _, err := io.Copy(c.Writer, file); err != nil {
-		if strings.Contains(err.Error(), "broken pipe") || err.Error() == "connection lost" {
-			logx.Error("客户端已断开连接")
-			return
+// rangeLength 返回按 Range 请求头将要发送的字节数，用于配额预检。
+// 与 http.ServeContent 的处理保持一致：没有 Range、带有 If-Range（可能发送整个文件）、
+// 格式错误或各段之和超过文件大小时按整个文件计算；多段之间的分隔信息不计入
+func rangeLength(header, ifRange string, size int64) int64 {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || ifRange != "" {
+		return size
+	}
+	var total int64
+	for _, ra := range strings.Split(spec, ",") {
+		start, end, ok := strings.Cut(strings.TrimSpace(ra), "-")
+		if !ok {
+			return size
+		}
+		if start == "" { // bytes=-N 表示最后 N 个字节
+			n, err := strconv.ParseInt(end, 10, 64)
+			if err != nil || n < 0 {
+				return size
+			}
+			total += min(n, size)
+			continue
+		}
+		first, err := strconv.ParseInt(start, 10, 64)
+		if err != nil || first < 0 {
+			return size
+		}
+		last := size - 1
+		if end != "" {
+			if last, err = strconv.ParseInt(end, 10, 64); err != nil || last < first {
+				return size
+			}
+			last = min(last, size-1)
+		}
+		if first < size { // 起始位置超出文件大小的段不发送
+			total += last - first + 1
 		}
-		logx.Errorf("文件写入响应失败: %v", err)
-		logs.Sugar.Errorw("文件下载", "username", username, "detail", "文件写入响应失败，请检查网络连接是否正常")
-		return
 	}
-	c.Writer.Flush()
-	logs.Sugar.Infow("文件下载", "username", username, "detail", "文件下载成功，任务ID："+task_id)
+	return min(total, size)
 }
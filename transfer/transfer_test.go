@@ -0,0 +1,242 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"file-transfer/config"
+	"file-transfer/inventory"
+	"file-transfer/logs"
+	g "file-transfer/transfer/global"
+	trans "file-transfer/transfer/trans-init"
+	"file-transfer/usersvc"
+	"file-transfer/usersvc/fake"
+	"file-transfer/vault"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/sftp"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSFTP 启动进程内的 SSH/SFTP 服务器，接受任意密码，返回监听端口
+func startSFTP(t *testing.T) int {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) { return nil, nil }}
+	cfg.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, cfg)
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func serveSFTP(conn net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		ch, creqs, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for r := range creqs {
+				ok := r.Type == "subsystem"
+				r.Reply(ok, nil)
+				if ok {
+					srv, _ := sftp.NewServer(ch)
+					go func() { srv.Serve(); ch.Close() }()
+				}
+			}
+		}()
+	}
+}
+
+// useService 将连接到进程内 SFTP 服务器的连接池和传输服务设为全局对象，测试结束后恢复
+func useService(t *testing.T, meter g.TransferMeter) trans.SSHTarget {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	oldPool, oldFTS, oldSugar := g.Pool, g.FTS, logs.Sugar
+	t.Cleanup(func() { g.Pool, g.FTS, logs.Sugar = oldPool, oldFTS, oldSugar })
+
+	logs.Sugar = zap.NewNop().Sugar()
+	g.Pool = trans.NewSSHConnectionPool(4, time.Minute)
+	g.Pool.KeepaliveInterval = 0
+	g.FTS = &g.FileTransferServiceImpl{Pool: g.Pool, Meter: meter}
+
+	target := trans.SSHTarget{Address: "127.0.0.1", Port: startSFTP(t), User: "alice", Password: "pw", Proxy: "direct"}.WithPoolKey("sftp-test")
+	if _, err := trans.EnsureConnection(g.Pool, target); err != nil {
+		t.Fatalf("连接 SFTP 服务器失败: %v", err)
+	}
+	return target
+}
+
+// useHost 将进程内 SFTP 服务器登记到主机清单，并由假用户服务将其归属于 alice，返回主机ID
+func useHost(t *testing.T, target trans.SSHTarget) string {
+	t.Helper()
+	return useHosts(t, map[string]trans.SSHTarget{"sftp-test": target})["sftp-test"]
+}
+
+// useHosts 按名称将服务器登记到主机清单，并由假用户服务将其归属于 alice，返回名称到主机ID的映射
+func useHosts(t *testing.T, targets map[string]trans.SSHTarget) map[string]string {
+	t.Helper()
+	oldInventory, oldUsers := inventory.Default, usersvc.Default
+	t.Cleanup(func() { inventory.Default, usersvc.Default = oldInventory, oldUsers })
+
+	t.Setenv("TRANSFER_TEST_KEYS", "v1:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	keyring, err := vault.LoadKeyring(config.VaultConfig{MasterKeyEnv: "TRANSFER_TEST_KEYS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Default, err = inventory.Open(filepath.Join(t.TempDir(), "inventory.json"), keyring); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := fake.NewUserService()
+	svc.AddUser("alice", 2, 7)
+	ids := make(map[string]string, len(targets))
+	for name, target := range targets {
+		cred, err := inventory.Default.CreateCredential(name, inventory.CredentialPassword, target.Password, "", "root")
+		if err != nil {
+			t.Fatal(err)
+		}
+		host, err := inventory.Default.CreateHost(inventory.Host{Name: name, Address: target.Address, Port: target.Port, User: target.User, CredentialRef: cred.ID, Proxy: target.Proxy}, "root")
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc.AddHost(name, "alice", 7)
+		ids[name] = host.ID
+	}
+
+	addr, stop, err := svc.Serve("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stop)
+	if usersvc.Default, err = usersvc.NewClient(config.UserServiceConfig{Addr: addr}); err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// byteQuota 模拟剩余 limit 字节的配额，记录每次预检的字节数
+type byteQuota struct {
+	limit int64
+
+	mu    sync.Mutex
+	sizes []int64
+}
+
+func (q *byteQuota) meter(ctx context.Context, size int64) (func(int64), error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sizes = append(q.sizes, size)
+	if size > q.limit {
+		return nil, g.ErrQuotaExceeded
+	}
+	return func(int64) {}, nil
+}
+
+func TestRangeLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		ifRange string
+		want    int64
+	}{
+		{"没有 Range", "", "", 100},
+		{"从头到尾", "bytes=0-", "", 100},
+		{"从中间到尾", "bytes=90-", "", 10},
+		{"指定范围", "bytes=0-9", "", 10},
+		{"结束位置超出文件大小", "bytes=95-200", "", 5},
+		{"最后 N 个字节", "bytes=-5", "", 5},
+		{"最后 N 个字节超出文件大小", "bytes=-500", "", 100},
+		{"多段", "bytes=0-4, 10-14", "", 10},
+		{"多段之和超过文件大小", "bytes=0-99,0-99", "", 100},
+		{"起始位置超出文件大小", "bytes=100-", "", 0},
+		{"带 If-Range 时可能发送整个文件", "bytes=0-9", `"etag"`, 100},
+		{"单位不是 bytes", "items=0-9", "", 100},
+		{"格式错误", "bytes=abc", "", 100},
+		{"结束位置小于起始位置", "bytes=9-0", "", 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rangeLength(tt.header, tt.ifRange, 100); got != tt.want {
+				t.Errorf("rangeLength(%q, %q, 100) = %d, want %d", tt.header, tt.ifRange, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeRemoteFileQuota(t *testing.T) {
+	quota := &byteQuota{limit: 10}
+	target := useService(t, quota.meter)
+	file := filepath.Join(t.TempDir(), "a.bin")
+	if err := os.WriteFile(file, []byte(strings.Repeat("x", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		want     int
+		wantSize int64 // 配额预检的字节数
+	}{
+		{"整个文件超出配额", http.Header{}, http.StatusTooManyRequests, 100},
+		{"从头到尾的范围请求同样超出配额", http.Header{"Range": {"bytes=0-"}}, http.StatusTooManyRequests, 100},
+		{"最后 N 个字节超出配额", http.Header{"Range": {"bytes=-50"}}, http.StatusTooManyRequests, 50},
+		{"带 If-Range 的范围请求按整个文件计算", http.Header{"Range": {"bytes=0-9"}, "If-Range": {`"stale"`}}, http.StatusTooManyRequests, 100},
+		{"配额内的范围请求", http.Header{"Range": {"bytes=0-9"}}, http.StatusPartialContent, 10},
+		{"配额内的多段请求", http.Header{"Range": {"bytes=0-4,10-14"}}, http.StatusPartialContent, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/download", nil)
+			c.Request.Header = tt.header
+
+			serveRemoteFile(c, "文件下载", "alice", "", target, file)
+			if w.Code != tt.want {
+				t.Fatalf("状态码 = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if got := quota.sizes[len(quota.sizes)-1]; got != tt.wantSize {
+				t.Errorf("配额预检字节数 = %d, want %d", got, tt.wantSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,382 @@
+package transfer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"file-transfer/config"
+	"file-transfer/logs"
+	g "file-transfer/transfer/global"
+	trans "file-transfer/transfer/trans-init"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 断点续传上传（tus 协议 1.0.0），支持 creation、termination、expiration 扩展：
+// POST 创建上传，PATCH 从指定偏移追加内容，HEAD 查询已上传的字节数，DELETE 终止上传。
+// 每次 PATCH 的内容直接写入目标服务器上的临时文件，全部写完后重命名为目标文件
+
+const (
+	TusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+	tusBasePath   = "/filetransfer/tus/"
+
+	defaultTusExpiration      = 24 * time.Hour
+	defaultTusCleanupInterval = 10 * time.Minute
+)
+
+// tusUpload 一个未完成的上传
+type tusUpload struct {
+	Owner     string          // 创建上传的用户，只有该用户可以继续上传
+	Target    trans.SSHTarget // 目标服务器，每次写入前确保连接可用
+	File      *g.PartialFile
+	Mode      os.FileMode
+	ExpiresAt time.Time // 由 tusStore 的锁保护
+}
+
+// tusStore 保存在内存中的未完成上传，服务重启后需要重新上传
+type tusStore struct {
+	mu         sync.Mutex
+	uploads    map[string]*tusUpload
+	maxSize    int64
+	expiration time.Duration
+	interval   time.Duration
+}
+
+var tusUploads = newTusStore(config.TusConfig{})
+
+func newTusStore(cfg config.TusConfig) *tusStore {
+	s := &tusStore{
+		uploads:    make(map[string]*tusUpload),
+		maxSize:    cfg.MaxSize,
+		expiration: cfg.Expiration,
+		interval:   cfg.CleanupInterval,
+	}
+	if s.expiration <= 0 {
+		s.expiration = defaultTusExpiration
+	}
+	if s.interval <= 0 {
+		s.interval = defaultTusCleanupInterval
+	}
+	return s
+}
+
+// InitTus 按配置设置断点续传的大小上限和过期时间
+func InitTus(cfg config.TusConfig) {
+	tusUploads = newTusStore(cfg)
+}
+
+// CleanupTusUploads 定期终止过期的上传并删除临时文件，直到 stop 关闭
+func CleanupTusUploads(stop <-chan struct{}) {
+	ticker := time.NewTicker(tusUploads.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for _, u := range tusUploads.expired(time.Now()) {
+				logx.Infof("断点续传上传已过期，任务ID: %s", u.File.ID)
+				abortTusUpload(u)
+			}
+		}
+	}
+}
+
+// expired 取出所有过期的上传
+func (s *tusStore) expired(now time.Time) []*tusUpload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var uploads []*tusUpload
+	for id, u := range s.uploads {
+		if now.After(u.ExpiresAt) {
+			uploads = append(uploads, u)
+			delete(s.uploads, id)
+		}
+	}
+	return uploads
+}
+
+func (s *tusStore) add(u *tusUpload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u.ExpiresAt = time.Now().Add(s.expiration)
+	s.uploads[u.File.ID] = u
+}
+
+// get 返回 owner 的上传及其过期时间；过期的上传会被取出，gone 为 true
+func (s *tusStore) get(id, owner string) (u *tusUpload, expiresAt time.Time, gone bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, exists := s.uploads[id]
+	if !exists || u.Owner != owner {
+		return nil, time.Time{}, false
+	}
+	if time.Now().After(u.ExpiresAt) {
+		delete(s.uploads, id)
+		return u, time.Time{}, true
+	}
+	return u, u.ExpiresAt, false
+}
+
+// touch 写入后延长过期时间
+func (s *tusStore) touch(u *tusUpload) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u.ExpiresAt = time.Now().Add(s.expiration)
+	return u.ExpiresAt
+}
+
+// remove 取出上传，已被其他请求取出时返回 false
+func (s *tusStore) remove(u *tusUpload) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.uploads[u.File.ID] != u {
+		return false
+	}
+	delete(s.uploads, u.File.ID)
+	return true
+}
+
+// abortTusUpload 删除目标服务器上的临时文件
+func abortTusUpload(u *tusUpload) {
+	if _, err := trans.EnsureConnection(g.Pool, u.Target); err != nil {
+		logx.Errorf("创建与目标服务器的连接失败: %v", err)
+		return
+	}
+	if err := g.FTS.AbortPartial(u.File); err != nil {
+		logx.Errorf("删除断点续传临时文件失败: %v", err)
+	}
+}
+
+// TusResumable 为响应加上 Tus-Resumable 头，并拒绝协议版本不一致的请求
+func TusResumable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Tus-Resumable", TusVersion)
+		if version := c.GetHeader("Tus-Resumable"); version != TusVersion {
+			c.Header("Tus-Version", TusVersion)
+			c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"message": fmt.Sprintf("不支持的 tus 协议版本: %q", version)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// 返回服务端支持的 tus 协议版本和扩展，无需登录
+func TusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", TusVersion)
+	c.Header("Tus-Version", TusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	if tusUploads.maxSize > 0 {
+		c.Header("Tus-Max-Size", strconv.FormatInt(tusUploads.maxSize, 10))
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// parseUploadMetadata 解析 Upload-Metadata 头：逗号分隔的键值对，键与 base64 编码的值之间以空格分隔
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%s 的值不是有效的 base64", key)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func tusExpiresHeader(c *gin.Context, expiresAt time.Time) {
+	c.Header("Upload-Expires", expiresAt.UTC().Format(http.TimeFormat))
+}
+
+// 创建上传：Upload-Length 为文件大小，Upload-Metadata 中携带目标服务器及路径
+// （host_id / server / user / auth / proxy / path，可选 filename / mode），
+// path 以 / 结尾时上传到该目录下的 filename
+func TusCreate(c *gin.Context) {
+	username := c.GetString("username")
+
+	size, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "缺少或无效的 Upload-Length"})
+		return
+	}
+	if tusUploads.maxSize > 0 && size > tusUploads.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": fmt.Sprintf("文件大小 %d 字节超出上限 %d 字节", size, tusUploads.maxSize)})
+		return
+	}
+	metadata, err := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("无效的 Upload-Metadata: %v", err)})
+		return
+	}
+
+	request := CommonTransRequest{
+		Server: metadata["server"],
+		Path:   metadata["path"],
+		User:   metadata["user"],
+		Auth:   metadata["auth"],
+		Proxy:  metadata["proxy"],
+		HostID: metadata["host_id"],
+	}
+	if strings.HasSuffix(request.Path, "/") && metadata["filename"] != "" {
+		request.Path = path.Join(request.Path, path.Base(metadata["filename"]))
+	}
+	if request.Path == "" || strings.HasSuffix(request.Path, "/") {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Upload-Metadata 中缺少目标文件路径"})
+		return
+	}
+	mode := os.FileMode(0644)
+	if m := metadata["mode"]; m != "" {
+		perm, err := strconv.ParseUint(m, 8, 32)
+		if err != nil || perm > 0o777 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("无效的权限: %s", m)})
+			return
+		}
+		mode = os.FileMode(perm)
+	}
+
+	target, ok := fileTarget(c, "文件上传", request)
+	if !ok {
+		return
+	}
+
+	file, err := g.FTS.CreatePartialFile(c.Request.Context(), target.Server, request.Path, size)
+	if err != nil {
+		logx.Errorf("创建上传失败: %v", err)
+		logs.Sugar.Errorw("文件上传", "username", username, "detail", "创建断点续传上传失败："+err.Error())
+		c.JSON(fileErrorStatus(err), gin.H{"message": fmt.Sprintf("创建上传失败: %v", err)})
+		return
+	}
+	upload := &tusUpload{Owner: username, Target: target, File: file, Mode: mode}
+
+	// 空文件无需 PATCH，创建后直接完成
+	if size == 0 {
+		if err := g.FTS.CommitPartial(file, mode); err != nil {
+			logx.Errorf("文件上传失败: %v", err)
+			logs.Sugar.Errorw("文件上传", "username", username, "detail", "文件上传失败："+err.Error())
+			g.FTS.AbortPartial(file)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("文件上传失败: %v", err)})
+			return
+		}
+		logs.Sugar.Infow("文件上传", "username", username, "detail", "断点续传上传成功，任务ID："+file.ID+"，服务器："+target.Hostname()+"，路径："+file.Path)
+		c.Header("Location", tusBasePath+file.ID)
+		c.Header("Upload-Offset", "0")
+		c.Status(http.StatusCreated)
+		return
+	}
+
+	tusUploads.add(upload)
+	c.Header("Location", tusBasePath+file.ID)
+	tusExpiresHeader(c, upload.ExpiresAt)
+	c.Status(http.StatusCreated)
+}
+
+// tusLookup 查找当前用户的上传，找不到或已过期时已写入响应
+func tusLookup(c *gin.Context) (*tusUpload, time.Time, bool) {
+	c.Header("Cache-Control", "no-store")
+	upload, expiresAt, gone := tusUploads.get(c.Param("id"), c.GetString("username"))
+	if gone {
+		abortTusUpload(upload)
+		c.JSON(http.StatusGone, gin.H{"message": "上传已过期"})
+		return nil, time.Time{}, false
+	}
+	if upload == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "上传不存在"})
+		return nil, time.Time{}, false
+	}
+	return upload, expiresAt, true
+}
+
+// 查询已上传的字节数
+func TusHead(c *gin.Context) {
+	upload, expiresAt, ok := tusLookup(c)
+	if !ok {
+		return
+	}
+	c.Header("Upload-Offset", strconv.FormatInt(upload.File.Offset(), 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.File.Size, 10))
+	tusExpiresHeader(c, expiresAt)
+	c.Status(http.StatusOK)
+}
+
+// 从 Upload-Offset 开始追加内容，偏移与已上传的字节数不一致时返回409；
+// 写入中断时已写入的部分保留，客户端用 HEAD 查询偏移后继续上传
+func TusPatch(c *gin.Context) {
+	username := c.GetString("username")
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"message": "Content-Type 必须为 application/offset+octet-stream"})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "缺少或无效的 Upload-Offset"})
+		return
+	}
+	upload, _, ok := tusLookup(c)
+	if !ok {
+		return
+	}
+
+	if _, err := trans.EnsureConnection(g.Pool, upload.Target); err != nil {
+		logx.Errorf("创建与目标服务器的连接失败: %v", err)
+		c.JSON(connectErrorStatus(err), gin.H{"message": fmt.Sprintf("创建与目标服务器的连接失败: %v", err)})
+		return
+	}
+
+	_, err = g.FTS.WritePartial(c.Request.Context(), upload.File, offset, c.Request.Body)
+	c.Header("Upload-Offset", strconv.FormatInt(upload.File.Offset(), 10))
+	tusExpiresHeader(c, tusUploads.touch(upload))
+	if err != nil {
+		logx.Errorf("写入上传内容失败: %v", err)
+		status := accessErrorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, g.ErrOffsetMismatch) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"message": fmt.Sprintf("写入上传内容失败: %v", err)})
+		return
+	}
+
+	if upload.File.Offset() == upload.File.Size {
+		if err := g.FTS.CommitPartial(upload.File, upload.Mode); err != nil {
+			logx.Errorf("文件上传失败: %v", err)
+			logs.Sugar.Errorw("文件上传", "username", username, "detail", "文件上传失败："+err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("文件上传失败: %v", err)})
+			return
+		}
+		tusUploads.remove(upload)
+		logs.Sugar.Infow("文件上传", "username", username, "detail", "断点续传上传成功，任务ID："+upload.File.ID+"，服务器："+upload.Target.Hostname()+"，路径："+upload.File.Path)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// 终止上传并删除已上传的内容
+func TusDelete(c *gin.Context) {
+	username := c.GetString("username")
+	upload, _, ok := tusLookup(c)
+	if !ok {
+		return
+	}
+	if !tusUploads.remove(upload) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "上传不存在"})
+		return
+	}
+	abortTusUpload(upload)
+	logs.Sugar.Infow("终止上传", "username", username, "detail", "任务ID："+upload.File.ID+"，服务器："+upload.Target.Hostname()+"，路径："+upload.File.Path)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,208 @@
+package transfer
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"file-transfer/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// slotQuota 记录每次预检的字节数、当前占用的任务名额和累计用量
+type slotQuota struct {
+	mu      sync.Mutex
+	sizes   []int64
+	running int
+	bytes   int64
+}
+
+func (q *slotQuota) meter(ctx context.Context, size int64) (func(int64), error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sizes = append(q.sizes, size)
+	q.running++
+	var once sync.Once
+	return func(n int64) {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.running--
+			q.bytes += n
+		})
+	}, nil
+}
+
+func (q *slotQuota) state() (running int, bytes int64, sizes []int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running, q.bytes, append([]int64(nil), q.sizes...)
+}
+
+// tusRouter 按 main.go 的方式注册 tus 路由，用户名取自 X-Test-User 请求头
+func tusRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	old := tusUploads
+	t.Cleanup(func() { tusUploads = old })
+	InitTus(config.TusConfig{MaxSize: 1 << 20})
+
+	r := gin.New()
+	tus := r.Group("/filetransfer/tus", func(c *gin.Context) { c.Set("username", c.GetHeader("X-Test-User")) }, TusResumable())
+	tus.POST("", TusCreate)
+	tus.HEAD("/:id", TusHead)
+	tus.PATCH("/:id", TusPatch)
+	tus.DELETE("/:id", TusDelete)
+	return r
+}
+
+// tusRequest 以 alice 的身份发送 tus 请求，header 中的值覆盖默认请求头
+func tusRequest(r *gin.Engine, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", TusVersion)
+	req.Header.Set("X-Test-User", "alice")
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// tusCreate 创建上传到 file 的任务，返回 Location
+func tusCreate(t *testing.T, r *gin.Engine, hostID, file string, size int) string {
+	t.Helper()
+	metadata := "host_id " + base64.StdEncoding.EncodeToString([]byte(hostID)) + ",path " + base64.StdEncoding.EncodeToString([]byte(file))
+	w := tusRequest(r, http.MethodPost, "/filetransfer/tus", "", map[string]string{"Upload-Length": strconv.Itoa(size), "Upload-Metadata": metadata})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建上传状态码 = %d: %s", w.Code, w.Body.String())
+	}
+	return w.Header().Get("Location")
+}
+
+func TestTusUpload(t *testing.T) {
+	quota := &slotQuota{}
+	hostID := useHost(t, useService(t, quota.meter))
+	r := tusRouter(t)
+	file := filepath.Join(t.TempDir(), "a.txt")
+
+	location := tusCreate(t, r, hostID, file, 10)
+	if !strings.HasPrefix(location, tusBasePath) {
+		t.Fatalf("Location = %q", location)
+	}
+	if running, _, _ := quota.state(); running != 0 {
+		t.Errorf("创建上传后占用 %d 个任务名额，want 0", running)
+	}
+
+	steps := []struct {
+		name       string
+		method     string
+		body       string
+		header     map[string]string
+		want       int
+		wantOffset string
+	}{
+		{"写入第一块", http.MethodPatch, "0123", map[string]string{"Upload-Offset": "0"}, http.StatusNoContent, "4"},
+		{"查询偏移", http.MethodHead, "", nil, http.StatusOK, "4"},
+		{"偏移与已写入的字节数不一致", http.MethodPatch, "xx", map[string]string{"Upload-Offset": "2"}, http.StatusConflict, "4"},
+		{"缺少偏移", http.MethodPatch, "xx", nil, http.StatusBadRequest, ""},
+		{"Content-Type 错误", http.MethodPatch, "xx", map[string]string{"Upload-Offset": "4", "Content-Type": "text/plain"}, http.StatusUnsupportedMediaType, ""},
+		{"协议版本不一致", http.MethodHead, "", map[string]string{"Tus-Resumable": "0.2.2"}, http.StatusPreconditionFailed, ""},
+		{"其他用户无法查询", http.MethodHead, "", map[string]string{"X-Test-User": "bob"}, http.StatusNotFound, ""},
+		{"其他用户无法写入", http.MethodPatch, "456789", map[string]string{"Upload-Offset": "4", "X-Test-User": "bob"}, http.StatusNotFound, ""},
+		{"超出文件大小", http.MethodPatch, "456789abc", map[string]string{"Upload-Offset": "4"}, http.StatusRequestEntityTooLarge, "4"},
+	}
+	for _, tt := range steps {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tusRequest(r, tt.method, location, tt.body, tt.header)
+			if w.Code != tt.want {
+				t.Fatalf("状态码 = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if got := w.Header().Get("Upload-Offset"); got != tt.wantOffset {
+				t.Errorf("Upload-Offset = %q, want %q", got, tt.wantOffset)
+			}
+			if running, _, _ := quota.state(); running != 0 {
+				t.Errorf("请求结束后占用 %d 个任务名额，want 0", running)
+			}
+		})
+	}
+	if _, err := os.Stat(file); err == nil {
+		t.Fatal("写完之前不应出现目标文件")
+	}
+}
+
+func TestTusUploadComplete(t *testing.T) {
+	quota := &slotQuota{}
+	hostID := useHost(t, useService(t, quota.meter))
+	r := tusRouter(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+
+	location := tusCreate(t, r, hostID, file, 10)
+	for _, chunk := range []struct{ offset, body string }{{"0", "0123"}, {"4", "456789"}} {
+		if w := tusRequest(r, http.MethodPatch, location, chunk.body, map[string]string{"Upload-Offset": chunk.offset}); w.Code != http.StatusNoContent {
+			t.Fatalf("从 %s 写入状态码 = %d: %s", chunk.offset, w.Code, w.Body.String())
+		}
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("上传完成后读取目标文件失败: %v", err)
+	}
+	if string(data) != "0123456789" {
+		t.Errorf("文件内容 = %q", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("目录中应只剩目标文件: %v", entries)
+	}
+
+	// 每次写入按剩余字节数预检，写入结束即释放名额，用量按实际写入计算
+	running, bytes, sizes := quota.state()
+	if running != 0 || bytes != 10 {
+		t.Errorf("名额 = %d, 用量 = %d, want 0, 10", running, bytes)
+	}
+	if got := sizes[len(sizes)-2:]; got[0] != 10 || got[1] != 6 {
+		t.Errorf("写入时预检的字节数 = %v, want [10 6]", got)
+	}
+
+	if w := tusRequest(r, http.MethodHead, location, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("完成后查询状态码 = %d, want 404", w.Code)
+	}
+}
+
+func TestTusDelete(t *testing.T) {
+	hostID := useHost(t, useService(t, nil))
+	r := tusRouter(t)
+	dir := t.TempDir()
+
+	location := tusCreate(t, r, hostID, filepath.Join(dir, "a.txt"), 10)
+	if w := tusRequest(r, http.MethodPatch, location, "0123", map[string]string{"Upload-Offset": "0"}); w.Code != http.StatusNoContent {
+		t.Fatalf("写入状态码 = %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := tusRequest(r, http.MethodDelete, location, "", map[string]string{"X-Test-User": "bob"}); w.Code != http.StatusNotFound {
+		t.Errorf("其他用户终止上传状态码 = %d, want 404", w.Code)
+	}
+	if w := tusRequest(r, http.MethodDelete, location, "", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("终止上传状态码 = %d: %s", w.Code, w.Body.String())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("终止后临时文件应被删除: %v", entries)
+	}
+	for _, method := range []string{http.MethodHead, http.MethodDelete} {
+		if w := tusRequest(r, method, location, "", nil); w.Code != http.StatusNotFound {
+			t.Errorf("终止后 %s 状态码 = %d, want 404", method, w.Code)
+		}
+	}
+}
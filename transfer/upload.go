@@ -0,0 +1,409 @@
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"file-transfer/logs"
+	g "file-transfer/transfer/global"
+	trans "file-transfer/transfer/trans-init"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// MaxUploadBodySize HTTP 上传请求体的大小上限，0 表示不限制
+var MaxUploadBodySize int64
+
+// 表单字段值的最大长度
+const maxUploadFieldSize = 64 << 10
+
+// errUploadFieldOrder 参数字段出现在文件之后。文件边读边上传，读到第一个文件时就要确定目标服务器和路径
+var errUploadFieldOrder = errors.New("表单字段顺序错误")
+
+// UploadTarget 上传的一个目标服务器
+type UploadTarget struct {
+	Server string `json:"server"`
+	User   string `json:"user"`
+	Auth   string `json:"auth"`
+	Proxy  string `json:"proxy"`
+	HostID string `json:"host_id"`
+}
+
+// UploadRequest 表单上传的参数，放在查询字符串中或作为表单字段放在第一个文件之前。
+// path 以 / 结尾时为目标目录，可以上传多个文件，每个文件按其相对路径（浏览器上传目录时携带）放在该目录下；
+// 否则为目标文件路径，只能上传一个文件
+type UploadRequest struct {
+	CommonTransRequest
+	Targets []UploadTarget // 可选，targets 字段为 JSON 数组，同时上传到多个服务器；为空时使用 CommonTransRequest 中的服务器
+}
+
+// UploadResult 一个文件上传到一个服务器的结果
+type UploadResult struct {
+	File    string `json:"file"` // 文件的相对路径
+	Server  string `json:"server"`
+	Path    string `json:"path"` // 目标路径
+	Size    int64  `json:"size"`
+	TaskID  string `json:"task_id,omitempty"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	status  int    // 失败时对应的HTTP状态码
+}
+
+func (r *UploadResult) fail(status int, message string) {
+	r.Success = false
+	r.Message = message
+	r.status = status
+}
+
+// uploadHost 一个已通过归属检查的目标服务器
+type uploadHost struct {
+	target trans.SSHTarget
+	err    error           // 连接失败时不再向该服务器上传
+	dirs   map[string]bool // 已创建的目录
+}
+
+// 客户端与一个或多个指定的服务器进行文件传输，上传。
+// 参数可以放在查询字符串中，也可以作为表单字段放在第一个文件之前（如 curl -F server=... -F path=... -F file=@a.txt），
+// 文件之后出现参数字段时返回 400。文件内容边读边写入所有目标服务器，不在本地缓存
+func CommonUpload(c *gin.Context) {
+	Username, exists := c.Get("username") // 从上下文中获取用户名
+	if !exists {
+		logx.Error("用户未登录")
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "未登录"})
+		return
+	}
+	username := Username.(string)
+
+	// 限制请求体大小，超出时读取请求体返回错误
+	if MaxUploadBodySize > 0 {
+		if c.Request.ContentLength > MaxUploadBodySize {
+			logs.Sugar.Errorw("文件上传", "username", username, "detail", "请求体超出大小上限")
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": fmt.Sprintf("请求体 %d 字节超出上限 %d 字节", c.Request.ContentLength, MaxUploadBodySize)})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxUploadBodySize)
+	}
+
+	var request UploadRequest
+	err := c.ShouldBindQuery(&request.CommonTransRequest)
+	if err == nil && c.Query("targets") != "" {
+		err = json.Unmarshal([]byte(c.Query("targets")), &request.Targets)
+	}
+	if err != nil {
+		logx.Errorf("解析请求失败: %v", err)
+		logs.Sugar.Errorw("文件上传", "username", username, "detail", "解析请求失败，请检查请求格式是否正确")
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		logx.Errorf("获取要上传的文件失败: %v", err)
+		logs.Sugar.Errorw("文件上传", "username", username, "detail", "获取要上传的文件失败")
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("获取要上传的文件失败: %v", err)})
+		return
+	}
+
+	taskID := uuid.New().String() // 本次上传的父任务，每个文件在每个服务器上的上传为一个子任务
+	var hosts []*uploadHost
+	var results []UploadResult
+	var files int
+	for {
+		fields := &request
+		if hosts != nil {
+			fields = nil // 第一个文件之后不再接受参数字段
+		}
+		part, err := nextUploadFile(reader, fields)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, errUploadFieldOrder) {
+			logs.Sugar.Errorw("文件上传", "username", username, "detail", fmt.Sprintf("表单字段顺序错误，任务ID：%s，已上传 %d 个文件", taskID, files))
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error(), "task_id": taskID, "results": results})
+			return
+		}
+		if err != nil {
+			logx.Errorf("获取要上传的文件失败: %v", err)
+			logs.Sugar.Errorw("文件上传", "username", username, "detail", "获取要上传的文件失败")
+			if files == 0 {
+				c.JSON(uploadErrorStatus(err, http.StatusBadRequest), gin.H{"message": fmt.Sprintf("获取要上传的文件失败: %v", err)})
+				return
+			}
+			results = append(results, UploadResult{Message: fmt.Sprintf("获取要上传的文件失败: %v", err), status: uploadErrorStatus(err, http.StatusBadRequest)})
+			break
+		}
+
+		// 收到第一个文件时字段已经齐全，检查目标服务器
+		if hosts == nil {
+			if missing := missingUploadField(request); missing != "" {
+				io.Copy(io.Discard, part)
+				logs.Sugar.Errorw("文件上传", "username", username, "detail", "缺少"+missing)
+				c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("缺少%s，参数需放在查询字符串中或作为表单字段放在第一个文件之前", missing)})
+				return
+			}
+			var ok bool
+			if hosts, ok = uploadHosts(c, username, request); !ok {
+				return
+			}
+		}
+		files++
+
+		isDir := strings.HasSuffix(request.Path, "/")
+		name := uploadFileName(part)
+		dest := request.Path
+		var reject string
+		switch {
+		case isDir && name == "":
+			reject = "缺少文件名"
+		case isDir:
+			dest = path.Join(request.Path, name)
+		case files > 1:
+			reject = "目标路径不是目录（以 / 结尾），只能上传一个文件"
+		}
+		if reject != "" {
+			io.Copy(io.Discard, part)
+			for _, h := range hosts {
+				r := UploadResult{File: name, Server: h.target.Hostname(), Path: dest}
+				r.fail(http.StatusBadRequest, reject)
+				results = append(results, r)
+			}
+			continue
+		}
+
+		fileResults, err := uploadToHosts(c.Request.Context(), part, hosts, name, dest, isDir)
+		results = append(results, fileResults...)
+		if err != nil {
+			break // 请求体读取失败，后面的文件无法继续读取
+		}
+	}
+	if files == 0 {
+		logs.Sugar.Errorw("文件上传", "username", username, "detail", "获取要上传的文件失败")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "获取要上传的文件失败: 请求中没有名为 file 的文件"})
+		return
+	}
+
+	failed := 0
+	for _, r := range results {
+		if !r.Success {
+			failed++
+			logs.Sugar.Errorw("文件上传", "username", username, "detail", fmt.Sprintf("文件上传失败，任务ID：%s，文件：%s，服务器：%s，原因：%s", taskID, r.File, r.Server, r.Message))
+		}
+	}
+
+	// 只有一个文件和一个服务器时与单文件上传的响应一致
+	if len(results) == 1 && failed == 1 {
+		c.JSON(results[0].status, gin.H{"message": results[0].Message, "task_id": taskID})
+		return
+	}
+	if failed > 0 {
+		c.JSON(http.StatusMultiStatus, gin.H{"message": fmt.Sprintf("部分文件上传失败，成功 %d 个，失败 %d 个", len(results)-failed, failed), "task_id": taskID, "results": results})
+		return
+	}
+	logs.Sugar.Infow("文件上传", "username", username, "detail", fmt.Sprintf("文件上传成功，任务ID：%s，共 %d 个文件，%d 台服务器", taskID, files, len(hosts)))
+	c.JSON(http.StatusOK, gin.H{"message": "文件上传完成", "task_id": taskID, "results": results})
+}
+
+// uploadHosts 解析目标服务器并校验归属，任一服务器未通过时拒绝整个请求（已写入响应）；
+// 连接失败的服务器只记录错误，不影响向其他服务器上传
+func uploadHosts(c *gin.Context, username string, request UploadRequest) ([]*uploadHost, bool) {
+	targets := request.Targets
+	if len(targets) == 0 {
+		r := request.CommonTransRequest
+		targets = []UploadTarget{{Server: r.Server, User: r.User, Auth: r.Auth, Proxy: r.Proxy, HostID: r.HostID}}
+	}
+
+	hosts := make([]*uploadHost, 0, len(targets))
+	for _, t := range targets {
+		// 确定服务器的地址和凭据（指定主机ID时由主机清单提供）
+		target, err := ResolveTarget(t.HostID, t.Server, t.User, t.Auth, t.Proxy)
+		if err != nil {
+			logx.Errorf("解析服务器失败: %v", err)
+			c.JSON(resolveErrorStatus(err), gin.H{"message": fmt.Sprintf("解析服务器失败: %v", err)})
+			return nil, false
+		}
+
+		// 检查服务器是否属于用户所在的公司或是否是用户自己的服务器
+		flag, err := CheckServerBelongs(c.Request.Context(), username, target.Hostname())
+		if err != nil {
+			logx.Errorf("查询服务器与用户（所在公司）的关系失败: %v", err)
+			c.JSON(belongsErrorStatus(err), gin.H{"message": fmt.Sprintf("查询服务器与用户（所在公司）的关系失败: %v", err)})
+			return nil, false
+		}
+		if !flag {
+			logx.Error("该服务器不属于用户（所在公司）")
+			logs.Sugar.Errorw("文件上传", "username", username, "detail", "该服务器不属于用户（所在公司）："+target.Hostname())
+			c.JSON(http.StatusBadRequest, gin.H{"message": "该服务器不属于用户（所在公司）"})
+			return nil, false
+		}
+		hosts = append(hosts, &uploadHost{target: target, dirs: make(map[string]bool)})
+	}
+
+	// 检查是否已存在到指定服务器的SSH连接，如果不存在，则创建并添加到池中
+	for _, h := range hosts {
+		if _, err := trans.EnsureConnection(g.Pool, h.target); err != nil {
+			logx.Errorf("创建与目标服务器的连接失败: %v", err)
+			h.err = err
+		}
+	}
+	return hosts, true
+}
+
+// missingUploadField 返回读到第一个文件时仍缺少的参数，齐全时返回空
+func missingUploadField(request UploadRequest) string {
+	switch {
+	case request.Server == "" && request.HostID == "" && len(request.Targets) == 0:
+		return "目标服务器（server、host_id 或 targets）"
+	case request.Path == "":
+		return "目标路径 path"
+	}
+	return ""
+}
+
+// nextUploadFile 读取 multipart 请求中的字段直到下一个名为 file 的文件，字段填入 request；
+// request 为 nil 时表示已经开始上传，再出现参数字段返回 errUploadFieldOrder。没有更多文件时返回 io.EOF
+func nextUploadFile(reader *multipart.Reader, request *UploadRequest) (*multipart.Part, error) {
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+		if request == nil {
+			switch name := part.FormName(); name {
+			case "server", "path", "user", "auth", "proxy", "host_id", "targets":
+				return nil, fmt.Errorf("%w: 字段 %s 必须放在第一个文件之前", errUploadFieldOrder, name)
+			}
+			io.Copy(io.Discard, part) // 其他字段与上传无关，忽略
+			continue
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxUploadFieldSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(value) > maxUploadFieldSize {
+			return nil, fmt.Errorf("字段 %s 过长", part.FormName())
+		}
+		switch part.FormName() {
+		case "server":
+			request.Server = string(value)
+		case "path":
+			request.Path = string(value)
+		case "user":
+			request.User = string(value)
+		case "auth":
+			request.Auth = string(value)
+		case "proxy":
+			request.Proxy = string(value)
+		case "host_id":
+			request.HostID = string(value)
+		case "targets":
+			if err := json.Unmarshal(value, &request.Targets); err != nil {
+				return nil, fmt.Errorf("解析 targets 失败: %v", err)
+			}
+		}
+	}
+}
+
+// uploadFileName 返回文件的相对路径。浏览器上传目录时文件名中带有相对路径，
+// 而 multipart.Part.FileName 只保留最后一级，这里从 Content-Disposition 中重新取出
+func uploadFileName(part *multipart.Part) string {
+	name := part.FileName()
+	if _, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		name = params["filename"]
+	}
+	name = strings.ReplaceAll(name, `\`, "/")
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// fanoutWriter 把内容依次写入每个服务器的管道，写入失败（该服务器的上传已结束）的管道被移除，其余继续
+type fanoutWriter struct {
+	writers []*io.PipeWriter
+}
+
+func (f *fanoutWriter) Write(p []byte) (int, error) {
+	live := f.writers[:0]
+	for _, w := range f.writers {
+		if _, err := w.Write(p); err == nil {
+			live = append(live, w)
+		}
+	}
+	f.writers = live
+	return len(p), nil
+}
+
+// uploadToHosts 把一个文件同时上传到所有可用的服务器，isDir 时先创建目标文件的上级目录；
+// 返回每个服务器的结果，读取请求体失败时同时返回该错误
+func uploadToHosts(ctx context.Context, file io.Reader, hosts []*uploadHost, name, dest string, isDir bool) ([]UploadResult, error) {
+	results := make([]UploadResult, len(hosts))
+	var pipes []*io.PipeWriter
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		results[i] = UploadResult{File: name, Server: h.target.Hostname(), Path: dest}
+		if h.err != nil {
+			results[i].fail(connectErrorStatus(h.err), fmt.Sprintf("创建与目标服务器的连接失败: %v", h.err))
+			continue
+		}
+
+		pr, pw := io.Pipe()
+		pipes = append(pipes, pw)
+		wg.Add(1)
+		go func(r *UploadResult, h *uploadHost) {
+			defer wg.Done()
+			// 上传提前结束时关闭管道，写入端不再阻塞
+			defer pr.CloseWithError(io.ErrClosedPipe)
+
+			if dir := path.Dir(dest); isDir && !h.dirs[dir] {
+				if _, err := g.FTS.Mkdir(ctx, h.target.Server, dir, true); err != nil {
+					logx.Errorf("创建目录失败: %v", err)
+					r.fail(fileErrorStatus(err), fmt.Sprintf("创建目录失败: %v", err))
+					return
+				}
+				h.dirs[dir] = true
+			}
+			taskID, err := g.FTS.CreateStreamUploadTask(ctx, pr, -1, 0644, h.target.Server, dest, nil)
+			if err != nil {
+				logx.Errorf("文件上传失败: %v", err)
+				r.fail(uploadErrorStatus(err, http.StatusInternalServerError), fmt.Sprintf("文件上传失败: %v", err))
+				return
+			}
+			r.TaskID = taskID
+			r.Success = true
+		}(&results[i], h)
+	}
+
+	fanout := &fanoutWriter{writers: append([]*io.PipeWriter(nil), pipes...)}
+	n, err := io.Copy(fanout, file)
+	for _, pw := range pipes {
+		pw.CloseWithError(err) // err 为 nil 时各服务器读到 EOF
+	}
+	wg.Wait()
+
+	for i := range results {
+		if results[i].Success {
+			results[i].Size = n
+		}
+	}
+	return results, err
+}
+
+// 上传失败时的HTTP状态码：请求体超出大小上限返回413，其余同 accessErrorStatus
+func uploadErrorStatus(err error, fallback int) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return accessErrorStatus(err, fallback)
+}
@@ -0,0 +1,311 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	trans "file-transfer/transfer/trans-init"
+
+	"github.com/gin-gonic/gin"
+)
+
+// formPart multipart 请求中的一个字段，filename 不为空时为文件
+type formPart struct {
+	name, filename, value string
+}
+
+// postUpload 按给定顺序构造 multipart 请求并调用 CommonUpload
+func postUpload(t *testing.T, query string, parts ...formPart) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var err error
+		if p.filename != "" {
+			w, e := mw.CreateFormFile(p.name, p.filename)
+			if e == nil {
+				_, e = w.Write([]byte(p.value))
+			}
+			err = e
+		} else {
+			err = mw.WriteField(p.name, p.value)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mw.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/filetransfer/upload?"+query, &body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	c.Set("username", "alice")
+	CommonUpload(c)
+	return w
+}
+
+func TestCommonUploadFieldOrder(t *testing.T) {
+	target := useService(t, nil)
+	hostID := useHost(t, target)
+
+	tests := []struct {
+		name        string
+		query       func(dir string) string
+		parts       func(dir string) []formPart
+		want        int
+		wantMessage string   // 响应中应包含的内容
+		wantFiles   []string // 上传成功的文件
+	}{
+		{
+			name: "字段在文件之前",
+			parts: func(dir string) []formPart {
+				return []formPart{{name: "host_id", value: hostID}, {name: "path", value: dir + "/"}, {name: "file", filename: "a.txt", value: "a"}, {name: "file", filename: "b.txt", value: "b"}}
+			},
+			want:      http.StatusOK,
+			wantFiles: []string{"a.txt", "b.txt"},
+		},
+		{
+			name:  "参数放在查询字符串中",
+			query: func(dir string) string { return "host_id=" + hostID + "&path=" + dir + "/a.txt" },
+			parts: func(dir string) []formPart {
+				return []formPart{{name: "file", filename: "x.txt", value: "a"}}
+			},
+			want:      http.StatusOK,
+			wantFiles: []string{"a.txt"},
+		},
+		{
+			name: "文件之后的无关字段被忽略",
+			parts: func(dir string) []formPart {
+				return []formPart{{name: "host_id", value: hostID}, {name: "path", value: dir + "/a.txt"}, {name: "file", filename: "a.txt", value: "a"}, {name: "note", value: "x"}}
+			},
+			want:      http.StatusOK,
+			wantFiles: []string{"a.txt"},
+		},
+		{
+			name: "所有字段都在文件之后",
+			parts: func(dir string) []formPart {
+				return []formPart{{name: "file", filename: "a.txt", value: "a"}, {name: "host_id", value: hostID}, {name: "path", value: dir + "/a.txt"}}
+			},
+			want:        http.StatusBadRequest,
+			wantMessage: "缺少目标服务器",
+		},
+		{
+			name: "路径在文件之后",
+			parts: func(dir string) []formPart {
+				return []formPart{{name: "host_id", value: hostID}, {name: "file", filename: "a.txt", value: "a"}, {name: "path", value: dir + "/a.txt"}}
+			},
+			want:        http.StatusBadRequest,
+			wantMessage: "缺少目标路径",
+		},
+		{
+			name: "第一个文件之后再出现参数字段",
+			parts: func(dir string) []formPart {
+				return []formPart{{name: "host_id", value: hostID}, {name: "path", value: dir + "/"}, {name: "file", filename: "a.txt", value: "a"}, {name: "path", value: dir + "/other/"}, {name: "file", filename: "b.txt", value: "b"}}
+			},
+			want:        http.StatusBadRequest,
+			wantMessage: "字段 path 必须放在第一个文件之前",
+			wantFiles:   []string{"a.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			query := ""
+			if tt.query != nil {
+				query = tt.query(dir)
+			}
+			w := postUpload(t, query, tt.parts(dir)...)
+			if w.Code != tt.want {
+				t.Fatalf("状态码 = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			var resp struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(resp.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want 包含 %q", resp.Message, tt.wantMessage)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				if !e.IsDir() {
+					got = append(got, e.Name())
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantFiles, ",") {
+				t.Errorf("上传的文件 = %v, want %v", got, tt.wantFiles)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "other")); err == nil {
+				t.Error("第一个文件之后的参数不应生效")
+			}
+		})
+	}
+}
+
+func TestCommonUploadFanout(t *testing.T) {
+	up := useService(t, nil)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := up
+	down.Port = ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	ids := useHosts(t, map[string]trans.SSHTarget{"sftp-up": up, "sftp-down": down, "sftp-up2": up})
+
+	targets := func(names ...string) string {
+		var list []UploadTarget
+		for _, name := range names {
+			id := ids[name]
+			if id == "" {
+				id = name
+			}
+			list = append(list, UploadTarget{HostID: id})
+		}
+		data, _ := json.Marshal(list)
+		return string(data)
+	}
+
+	sizes := map[string]int64{"a.txt": 3, "b.txt": 2}
+	tests := []struct {
+		name        string
+		targets     string
+		want        int
+		wantMessage string
+		wantResults map[string]bool // 服务器:文件 -> 是否成功
+		wantFiles   []string
+	}{
+		{
+			name:        "一台服务器连接失败",
+			targets:     targets("sftp-up", "sftp-down"),
+			want:        http.StatusMultiStatus,
+			wantMessage: "成功 2 个，失败 2 个",
+			wantResults: map[string]bool{"sftp-up:a.txt": true, "sftp-up:b.txt": true, "sftp-down:a.txt": false, "sftp-down:b.txt": false},
+			wantFiles:   []string{"a.txt", "b.txt"},
+		},
+		{
+			name:        "全部成功",
+			targets:     targets("sftp-up", "sftp-up2"),
+			want:        http.StatusOK,
+			wantResults: map[string]bool{"sftp-up:a.txt": true, "sftp-up:b.txt": true, "sftp-up2:a.txt": true, "sftp-up2:b.txt": true},
+			wantFiles:   []string{"a.txt", "b.txt"},
+		},
+		{
+			name:        "主机不存在时拒绝整个请求",
+			targets:     targets("sftp-up", "no-such-host"),
+			want:        http.StatusNotFound,
+			wantMessage: "解析服务器失败",
+		},
+		{
+			name:        "无效的 targets",
+			targets:     "[{",
+			want:        http.StatusBadRequest,
+			wantMessage: "解析 targets 失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w := postUpload(t, "", formPart{name: "targets", value: tt.targets}, formPart{name: "path", value: dir + "/"},
+				formPart{name: "file", filename: "a.txt", value: "aaa"}, formPart{name: "file", filename: "b.txt", value: "bb"})
+			if w.Code != tt.want {
+				t.Fatalf("状态码 = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			var resp struct {
+				Message string         `json:"message"`
+				Results []UploadResult `json:"results"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(resp.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want 包含 %q", resp.Message, tt.wantMessage)
+			}
+
+			got := make(map[string]bool)
+			for _, r := range resp.Results {
+				got[r.Server+":"+r.File] = r.Success
+				if r.Success && r.Size != sizes[r.File] {
+					t.Errorf("%s:%s 的大小 = %d", r.Server, r.File, r.Size)
+				}
+			}
+			if len(got) != len(tt.wantResults) {
+				t.Errorf("results = %v, want %v", got, tt.wantResults)
+			}
+			for k, v := range tt.wantResults {
+				if success, ok := got[k]; !ok || success != v {
+					t.Errorf("%s 的结果 = %t（存在 %t），want %t", k, success, ok, v)
+				}
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			for _, e := range entries {
+				files = append(files, e.Name())
+			}
+			if strings.Join(files, ",") != strings.Join(tt.wantFiles, ",") {
+				t.Errorf("上传的文件 = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestFanoutWriter(t *testing.T) {
+	var got [3]bytes.Buffer
+	var writers []*io.PipeWriter
+	var wg sync.WaitGroup
+	for i := range got {
+		pr, pw := io.Pipe()
+		writers = append(writers, pw)
+		wg.Add(1)
+		go func(buf *bytes.Buffer, fail bool) {
+			defer wg.Done()
+			if fail { // 模拟上传提前结束的服务器：读到一部分后关闭管道
+				io.CopyN(buf, pr, 2)
+				pr.CloseWithError(io.ErrClosedPipe)
+				return
+			}
+			io.Copy(buf, pr)
+		}(&got[i], i == 1)
+	}
+
+	fanout := &fanoutWriter{writers: writers}
+	for _, chunk := range []string{"ab", "cd", "ef"} {
+		if n, err := fanout.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write = %d, %v", n, err)
+		}
+	}
+	for _, pw := range writers {
+		pw.Close()
+	}
+	wg.Wait()
+
+	if len(fanout.writers) != 2 {
+		t.Errorf("失败的管道应被移除，剩余 %d 个", len(fanout.writers))
+	}
+	for i, want := range []string{"abcdef", "ab", "abcdef"} {
+		if got[i].String() != want {
+			t.Errorf("管道 %d 收到 %q, want %q", i, got[i].String(), want)
+		}
+	}
+}
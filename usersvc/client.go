@@ -0,0 +1,172 @@
+package usersvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"file-transfer/config"
+	"file-transfer/proto/user"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultAddr    = "localhost:9001"
+	defaultTimeout = 3 * time.Second
+)
+
+var (
+	ErrNotConfigured = errors.New("用户服务未初始化")
+	ErrUnavailable   = errors.New("用户服务不可用")
+)
+
+// cacheEntry 缓存的查询结果
+type cacheEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// Client 用户服务客户端，查询结果按 TTL 缓存；用户服务异常时一律拒绝（fail-closed）
+type Client struct {
+	conn     *grpc.ClientConn
+	rpc      user.UserServiceClient
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	users map[string]cacheEntry[*user.GetUserInfoResponse]
+	hosts map[string]cacheEntry[*user.GetHostInfoResponse]
+}
+
+var Default *Client // 全局用户服务客户端
+
+// NewClient 创建用户服务客户端，连接在第一次调用时建立
+func NewClient(cfg config.UserServiceConfig) (*Client, error) {
+	addr := cfg.Addr
+	if addr == "" {
+		addr = defaultAddr
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("连接用户服务失败: %v", err)
+	}
+	return NewClientFromConn(conn, cfg), nil
+}
+
+// NewClientFromConn 使用已有的连接创建客户端，便于接入本地的假用户服务
+func NewClientFromConn(conn *grpc.ClientConn, cfg config.UserServiceConfig) *Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Client{
+		conn:     conn,
+		rpc:      user.NewUserServiceClient(conn),
+		timeout:  timeout,
+		cacheTTL: cfg.CacheTTL,
+		users:    make(map[string]cacheEntry[*user.GetUserInfoResponse]),
+		hosts:    make(map[string]cacheEntry[*user.GetHostInfoResponse]),
+	}
+}
+
+// Close 关闭与用户服务的连接
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// GetUser 查询用户信息，用户不存在时返回 nil
+func (c *Client) GetUser(ctx context.Context, username string) (*user.GetUserInfoResponse, error) {
+	if u, ok := lookup(c, c.users, username); ok {
+		return u, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	u, err := c.rpc.GetUserInfo(ctx, &user.GetUserInfoRequest{Username: username})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: 查询用户失败: %v", ErrUnavailable, err)
+	}
+	store(c, c.users, username, u)
+	return u, nil
+}
+
+// GetHost 查询服务器信息，服务器未登记时返回 nil
+func (c *Client) GetHost(ctx context.Context, hostname string) (*user.GetHostInfoResponse, error) {
+	if h, ok := lookup(c, c.hosts, hostname); ok {
+		return h, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	h, err := c.rpc.GetHostInfo(ctx, &user.GetHostInfoRequest{Hostname: hostname})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: 查询服务器失败: %v", ErrUnavailable, err)
+	}
+	store(c, c.hosts, hostname, h)
+	return h, nil
+}
+
+// ServerBelongs 判断服务器是否属于用户本人或用户所在的公司；用户或服务器不存在时视为不属于
+func (c *Client) ServerBelongs(ctx context.Context, username, server string) (bool, error) {
+	u, err := c.GetUser(ctx, username)
+	if err != nil || u == nil {
+		return false, err
+	}
+	h, err := c.GetHost(ctx, server)
+	if err != nil || h == nil {
+		return false, err
+	}
+
+	if h.UserName == u.Name {
+		return true, nil // 服务器属于用户
+	}
+	// 公司ID为0表示未加入公司，不能据此判断归属
+	return u.CompanyId != 0 && u.CompanyId == h.CompanyId, nil
+}
+
+// Invalidate 清除缓存，用户或服务器信息变更后可以立即生效
+func (c *Client) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.users)
+	clear(c.hosts)
+}
+
+// lookup 读取未过期的缓存
+func lookup[T any](c *Client, cache map[string]cacheEntry[T], key string) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero T
+	entry, exists := cache[key]
+	if !exists {
+		return zero, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(cache, key)
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// store 写入缓存，TTL 为 0 时不缓存
+func store[T any](c *Client, cache map[string]cacheEntry[T], key string, value T) {
+	if c.cacheTTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cache[key] = cacheEntry[T]{value: value, expiresAt: time.Now().Add(c.cacheTTL)}
+}
@@ -0,0 +1,178 @@
+package usersvc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"file-transfer/config"
+	"file-transfer/usersvc/fake"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestClient 启动假用户服务并创建连接到它的客户端
+func newTestClient(t *testing.T, ttl time.Duration) (*Client, *fake.UserService) {
+	t.Helper()
+	svc := fake.NewUserService()
+	addr, stop, err := svc.Serve("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动假用户服务失败: %v", err)
+	}
+	t.Cleanup(stop)
+
+	c, err := NewClient(config.UserServiceConfig{Addr: addr, CacheTTL: ttl})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, svc
+}
+
+func TestServerBelongs(t *testing.T) {
+	c, svc := newTestClient(t, 0)
+	svc.AddUser("alice", 2, 7)
+	svc.AddUser("bob", 2, 8)
+	svc.AddUser("carol", 2, 0)
+	svc.AddUser("dave", 2, 0)
+	svc.AddHost("alice-host", "alice", 0)
+	svc.AddHost("company-host", "erin", 7)
+	svc.AddHost("carol-host", "carol", 0)
+	svc.AddHost("orphan-host", "nobody", 0)
+
+	tests := []struct {
+		name     string
+		username string
+		server   string
+		want     bool
+	}{
+		{"服务器属于用户本人", "alice", "alice-host", true},
+		{"服务器属于用户所在的公司", "alice", "company-host", true},
+		{"服务器属于其他公司", "bob", "company-host", false},
+		{"服务器属于其他用户", "bob", "alice-host", false},
+		{"未加入公司的用户访问自己的服务器", "carol", "carol-host", true},
+		{"未加入公司的用户不能借公司ID为0访问他人服务器", "dave", "carol-host", false},
+		{"未加入公司的服务器", "dave", "orphan-host", false},
+		{"用户不存在", "mallory", "alice-host", false},
+		{"服务器未登记", "alice", "unknown-host", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ServerBelongs(context.Background(), tt.username, tt.server)
+			if err != nil {
+				t.Fatalf("ServerBelongs 返回错误: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ServerBelongs(%q, %q) = %t, want %t", tt.username, tt.server, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerBelongsFailClosed(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"服务不可用", status.Error(codes.Unavailable, "connection refused")},
+		{"内部错误", status.Error(codes.Internal, "db error")},
+		{"超时", status.Error(codes.DeadlineExceeded, "timeout")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, svc := newTestClient(t, 0)
+			svc.AddUser("alice", 2, 7)
+			svc.AddHost("alice-host", "alice", 7)
+			svc.SetError(tt.err)
+
+			got, err := c.ServerBelongs(context.Background(), "alice", "alice-host")
+			if got {
+				t.Error("用户服务异常时不应判定为属于")
+			}
+			if !errors.Is(err, ErrUnavailable) {
+				t.Errorf("err = %v, want ErrUnavailable", err)
+			}
+		})
+	}
+}
+
+func TestServerBelongsUnreachable(t *testing.T) {
+	c, err := NewClient(config.UserServiceConfig{Addr: "127.0.0.1:1", Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	defer c.Close()
+
+	got, err := c.ServerBelongs(context.Background(), "alice", "alice-host")
+	if got || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("ServerBelongs = %t, %v, want false, ErrUnavailable", got, err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		expire    bool // 查询后让缓存过期
+		invalid   bool // 查询后清除缓存
+		wantCache bool // 用户服务故障后是否仍能从缓存得到结果
+	}{
+		{"TTL 内使用缓存", time.Minute, false, false, true},
+		{"TTL 为 0 不缓存", 0, false, false, false},
+		{"缓存过期后重新查询", time.Minute, true, false, false},
+		{"Invalidate 后重新查询", time.Minute, false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, svc := newTestClient(t, tt.ttl)
+			svc.AddUser("alice", 2, 7)
+			svc.AddHost("alice-host", "alice", 7)
+
+			if ok, err := c.ServerBelongs(context.Background(), "alice", "alice-host"); !ok || err != nil {
+				t.Fatalf("ServerBelongs = %t, %v, want true, nil", ok, err)
+			}
+			if tt.expire {
+				c.mu.Lock()
+				for k, e := range c.users {
+					e.expiresAt = time.Now().Add(-time.Second)
+					c.users[k] = e
+				}
+				for k, e := range c.hosts {
+					e.expiresAt = time.Now().Add(-time.Second)
+					c.hosts[k] = e
+				}
+				c.mu.Unlock()
+			}
+			if tt.invalid {
+				c.Invalidate()
+			}
+			svc.SetError(status.Error(codes.Unavailable, "down"))
+
+			ok, err := c.ServerBelongs(context.Background(), "alice", "alice-host")
+			if tt.wantCache {
+				if !ok || err != nil {
+					t.Errorf("ServerBelongs = %t, %v, want true, nil", ok, err)
+				}
+				return
+			}
+			if ok || !errors.Is(err, ErrUnavailable) {
+				t.Errorf("ServerBelongs = %t, %v, want false, ErrUnavailable", ok, err)
+			}
+		})
+	}
+}
+
+func TestCacheMissNotCached(t *testing.T) {
+	c, svc := newTestClient(t, time.Minute)
+	if u, err := c.GetUser(context.Background(), "alice"); u != nil || err != nil {
+		t.Fatalf("GetUser = %v, %v, want nil, nil", u, err)
+	}
+	// 用户不存在的结果不缓存，用户创建后立即可以查到
+	svc.AddUser("alice", 2, 7)
+	u, err := c.GetUser(context.Background(), "alice")
+	if err != nil || u == nil || u.CompanyId != 7 {
+		t.Errorf("GetUser = %v, %v, want company 7", u, err)
+	}
+}
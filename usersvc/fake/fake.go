@@ -0,0 +1,100 @@
+package fake
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"file-transfer/proto/user"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UserService 内存中的用户服务，用于本地联调和测试，不依赖真实的用户服务
+type UserService struct {
+	user.UnimplementedUserServiceServer
+
+	mu    sync.RWMutex
+	users map[string]*user.GetUserInfoResponse
+	hosts map[string]*user.GetHostInfoResponse
+	err   error // 不为 nil 时所有调用都返回该错误，用于模拟用户服务故障
+}
+
+func NewUserService() *UserService {
+	return &UserService{
+		users: make(map[string]*user.GetUserInfoResponse),
+		hosts: make(map[string]*user.GetHostInfoResponse),
+	}
+}
+
+// AddUser 添加用户，companyID 为 0 表示未加入公司
+func (s *UserService) AddUser(name string, roleID, companyID int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[name] = &user.GetUserInfoResponse{
+		Id:         int32(len(s.users) + 1),
+		Name:       name,
+		RoleId:     roleID,
+		CompanyId:  companyID,
+		IsVerified: true,
+	}
+}
+
+// AddHost 登记服务器及其所属的用户和公司
+func (s *UserService) AddHost(hostname, owner string, companyID int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hosts[hostname] = &user.GetHostInfoResponse{
+		Id:        int32(len(s.hosts) + 1),
+		UserName:  owner,
+		HostName:  hostname,
+		CompanyId: companyID,
+	}
+}
+
+// SetError 设置后所有调用返回该错误，传 nil 恢复正常
+func (s *UserService) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *UserService) GetUserInfo(ctx context.Context, req *user.GetUserInfoRequest) (*user.GetUserInfoResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	u, exists := s.users[req.Username]
+	if !exists {
+		return nil, status.Error(codes.NotFound, "用户不存在")
+	}
+	return u, nil
+}
+
+func (s *UserService) GetHostInfo(ctx context.Context, req *user.GetHostInfoRequest) (*user.GetHostInfoResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	h, exists := s.hosts[req.Hostname]
+	if !exists {
+		return nil, status.Error(codes.NotFound, "服务器不存在")
+	}
+	return h, nil
+}
+
+// Serve 在 addr 上启动用户服务，addr 为 "127.0.0.1:0" 时使用随机端口；返回实际监听地址和停止函数
+func (s *UserService) Serve(addr string) (string, func(), error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, err
+	}
+	server := grpc.NewServer()
+	user.RegisterUserServiceServer(server, s)
+	go server.Serve(lis)
+	return lis.Addr().String(), server.Stop, nil
+}
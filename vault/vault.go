@@ -0,0 +1,221 @@
+package vault
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"file-transfer/config"
+)
+
+// 主密钥与数据密钥均为 AES-256 密钥
+const keySize = 32
+
+var (
+	ErrNoMasterKey  = errors.New("未配置主密钥")
+	ErrUnknownKeyID = errors.New("未知的主密钥ID")
+)
+
+// Envelope 信封加密的结果：内容由随机生成的数据密钥加密，数据密钥再由主密钥加密
+type Envelope struct {
+	KeyID      string `json:"key_id"`      // 加密数据密钥所用主密钥的ID
+	WrappedKey string `json:"wrapped_key"` // 被主密钥加密的数据密钥（base64，含nonce）
+	Ciphertext string `json:"ciphertext"`  // 被数据密钥加密的内容（base64，含nonce）
+}
+
+// Keyring 主密钥集合，新数据使用当前主密钥加密，旧主密钥仅用于解密和轮换
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// LoadKeyring 从环境变量和密钥文件中加载主密钥
+// 环境变量的值形如 "v2:<base64>,v1:<base64>"，密钥文件每行一个 "id:<base64>"，# 开头为注释；
+// ActiveKeyID 为空时使用最先出现的主密钥加密新数据
+func LoadKeyring(cfg config.VaultConfig) (*Keyring, error) {
+	var entries []string
+	if cfg.MasterKeyEnv != "" {
+		entries = append(entries, strings.Split(os.Getenv(cfg.MasterKeyEnv), ",")...)
+	}
+	if cfg.MasterKeyFile != "" {
+		file, err := os.Open(cfg.MasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取主密钥文件失败: %v", err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			entries = append(entries, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("读取主密钥文件失败: %v", err)
+		}
+	}
+
+	k := &Keyring{keys: make(map[string][]byte)}
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" || strings.HasPrefix(e, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(e, ":")
+		if !ok || id == "" {
+			return nil, errors.New("主密钥格式错误，应为 id:<base64>")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("主密钥 %s 必须是 base64 编码的 %d 字节", id, keySize)
+		}
+		if _, exists := k.keys[id]; exists {
+			continue
+		}
+		k.keys[id] = key
+		if k.active == "" {
+			k.active = id
+		}
+	}
+
+	if len(k.keys) == 0 {
+		return nil, ErrNoMasterKey
+	}
+	if cfg.ActiveKeyID != "" {
+		if _, exists := k.keys[cfg.ActiveKeyID]; !exists {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, cfg.ActiveKeyID)
+		}
+		k.active = cfg.ActiveKeyID
+	}
+	return k, nil
+}
+
+// ActiveKeyID 返回当前用于加密的主密钥ID
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// Seal 使用新的数据密钥加密内容，aad 为附加认证数据（如凭据ID），解密时必须一致
+func (k *Keyring) Seal(plaintext, aad []byte) (*Envelope, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := encrypt(dataKey, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := encrypt(k.keys[k.active], dataKey, []byte(k.active))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		KeyID:      k.active,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// Open 解密信封中的内容
+func (k *Keyring) Open(env *Envelope, aad []byte) ([]byte, error) {
+	dataKey, err := k.unwrap(env)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("密文格式错误: %v", err)
+	}
+	plaintext, err := decrypt(dataKey, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败: %v", err)
+	}
+	return plaintext, nil
+}
+
+// Rewrap 用当前主密钥重新加密数据密钥，内容密文保持不变；已使用当前主密钥的信封原样返回
+func (k *Keyring) Rewrap(env *Envelope) (*Envelope, error) {
+	if env.KeyID == k.active {
+		return env, nil
+	}
+	dataKey, err := k.unwrap(env)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := encrypt(k.keys[k.active], dataKey, []byte(k.active))
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		KeyID:      k.active,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		Ciphertext: env.Ciphertext,
+	}, nil
+}
+
+// Reseal 解密后用新的数据密钥和当前主密钥重新加密
+func (k *Keyring) Reseal(env *Envelope, aad []byte) (*Envelope, error) {
+	plaintext, err := k.Open(env, aad)
+	if err != nil {
+		return nil, err
+	}
+	return k.Seal(plaintext, aad)
+}
+
+// unwrap 用对应的主密钥解出数据密钥
+func (k *Keyring) unwrap(env *Envelope) ([]byte, error) {
+	masterKey, exists := k.keys[env.KeyID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, env.KeyID)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(env.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("数据密钥格式错误: %v", err)
+	}
+	dataKey, err := decrypt(masterKey, wrapped, []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("解密数据密钥失败: %v", err)
+	}
+	return dataKey, nil
+}
+
+// encrypt 使用 AES-256-GCM 加密，返回 nonce||密文
+func encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// decrypt 解密 nonce||密文
+func decrypt(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("密文长度不足")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}